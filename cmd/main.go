@@ -2,36 +2,71 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ca-gip/kotary/internal/controller"
 	"github.com/ca-gip/kotary/internal/utils"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/troian/healthcheck"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 
 	clientset "github.com/ca-gip/kotary/pkg/generated/clientset/versioned"
 	informers "github.com/ca-gip/kotary/pkg/generated/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 )
 
 var (
 	masterURL  string
 	kubeconfig string
+
+	leaderElect                  bool
+	leaderElectLeaseDuration     time.Duration
+	leaderElectRenewDeadline     time.Duration
+	leaderElectRetryPeriod       time.Duration
+	leaderElectResourceName      string
+	leaderElectResourceNamespace string
+
+	webhookPort     int
+	webhookCertFile string
+	webhookKeyFile  string
+	quotaWriters    string
 )
 
 const resyncPeriod = time.Minute * 30
 
+// Namespace of the Lease when the controller namespace is unknown
+const defaultLeaderElectNamespace = "kube-system"
+
 func main() {
 	flag.StringVar(&kubeconfig, "kubeconfig", defaultKubeconfig(), "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Elect a leader among the replicas with a Lease, only the leader evaluates the claims.")
+	flag.DurationVar(&leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "Duration a standby replica waits before taking over a lease that is not renewed.")
+	flag.DurationVar(&leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "Duration the leader retries to renew its lease before giving up the leadership.")
+	flag.DurationVar(&leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "Duration between two attempts to acquire or renew the lease.")
+	flag.StringVar(&leaderElectResourceName, "leader-elect-resource-name", "kotary", "Name of the Lease used for the leader election.")
+	flag.StringVar(&leaderElectResourceNamespace, "leader-elect-resource-namespace", "", "Namespace of the Lease used for the leader election. Defaults to the namespace of the controller.")
+
+	flag.IntVar(&webhookPort, "webhook-port", 0, "Port of the validating webhook of the claims, served over TLS. The webhook is disabled when it is 0.")
+	flag.StringVar(&webhookCertFile, "webhook-tls-cert-file", "/etc/kotary/webhook/tls.crt", "Path to the TLS certificate of the validating webhook.")
+	flag.StringVar(&webhookKeyFile, "webhook-tls-private-key-file", "/etc/kotary/webhook/tls.key", "Path to the TLS private key of the validating webhook.")
+	flag.StringVar(&quotaWriters, "webhook-quota-writers", "system:serviceaccount:kube-system:kotary,system:serviceaccount:kube-system:namespace-controller", "Comma separated users allowed by the validating webhook to write the managed quotas.")
 
 	klog.InitFlags(nil)
 
@@ -77,12 +112,17 @@ func main() {
 		klog.Fatalf("Error building kubernetes clientset: %s", err.Error())
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Error building dynamic client: %s", err.Error())
+	}
+
 	// Prometheus metrics endpoint
 	http.Handle("/metrics", promhttp.Handler())
 	go http.ListenAndServe(":9080", nil)
 
 	// Load config
-	settingsManger := utils.NewSettingManger(settingsClient)
+	settingsManger := utils.NewSettingManger(settingsClient, quotaClaimClient)
 	settingsManger.Load()
 
 	namespaceInformerFactory := kubeinformers.NewSharedInformerFactory(namespaceClient, resyncPeriod)
@@ -91,20 +131,70 @@ func main() {
 	podInformerFactory := kubeinformers.NewSharedInformerFactory(podClient, resyncPeriod)
 	quotaClaimInformerFactory := informers.NewSharedInformerFactory(quotaClaimClient, resyncPeriod)
 
+	// The ConfigMap is only watched in the namespace of the controller
+	var configMapInformer coreinformers.ConfigMapInformer
+	var configMapInformerFactory kubeinformers.SharedInformerFactory
+	if settingsManger.Namespace != "" {
+		configMapInformerFactory = kubeinformers.NewSharedInformerFactoryWithOptions(settingsClient, resyncPeriod,
+			kubeinformers.WithNamespace(settingsManger.Namespace),
+			kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("metadata.name", utils.ConfigMapName).String()
+			}))
+		configMapInformer = configMapInformerFactory.Core().V1().ConfigMaps()
+	}
+
 	kotaryController := controller.NewController(
 		settingsManger.Conf,
-		namespaceClient, quotaClient, nodeClient, podClient, quotaClaimClient,
+		namespaceClient, quotaClient, nodeClient, podClient, quotaClaimClient, dynamicClient,
 		namespaceInformerFactory.Core().V1().Namespaces(),
 		quotaInformerFactory.Core().V1().ResourceQuotas(),
 		nodeInformerFactory.Core().V1().Nodes(),
 		podInformerFactory.Core().V1().Pods(),
-		quotaClaimInformerFactory.Cagip().V1().ResourceQuotaClaims())
+		quotaClaimInformerFactory.Cagip().V1().ResourceQuotaClaims(),
+		quotaClaimInformerFactory.Cagip().V1().ScheduledQuotaClaims(),
+		quotaClaimInformerFactory.Cagip().V1().QuotaPolicies(),
+		quotaClaimInformerFactory.Cagip().V1().QuotaCapacities(),
+		configMapInformer)
+
+	// The approvals signed by the webhook of any replica are verified by the leader with the same key
+	// The accepted specs signed on the managed quotas are read with it by the replica taking over the leadership
+	approvalKeyNamespace := settingsManger.Namespace
+	if approvalKeyNamespace == "" {
+		approvalKeyNamespace = defaultLeaderElectNamespace
+	}
+	approvalKey, err := utils.LoadApprovalKey(settingsClient, approvalKeyNamespace)
+	if err != nil {
+		klog.Fatalf("Error loading the approval signing key: %s", err.Error())
+	}
+	kotaryController.SetApprovalKey(approvalKey)
+	kotaryController.SetApprovalWebhook(webhookPort > 0)
 
 	// Liveness and Readiness probes
 	health := healthcheck.NewHandler()
 	_ = health.AddLivenessCheck("goroutine-threshold", healthcheck.GoroutineCountCheck(100))
 	_ = health.AddReadinessCheck("sync-shared-informer", kotaryController.SharedInformersState)
-	go http.ListenAndServe(":8086", health)
+	healthMux := http.NewServeMux()
+	healthMux.Handle("/", health)
+
+	// The replicas report the leader they observed on the probes port
+	var election *controller.LeaderElection
+	if leaderElect {
+		election = controller.NewLeaderElection(leaderIdentity())
+		_ = health.AddReadinessCheck("leader-election", election.Check)
+		healthMux.Handle("/leader", election)
+	}
+	go http.ListenAndServe(":8086", healthMux)
+
+	// Every replica validates the claims, the standby ones keep their caches warm
+	if webhookPort > 0 {
+		webhookMux := http.NewServeMux()
+		webhookMux.Handle("/validate-resourcequotaclaim", controller.NewClaimWebhook(kotaryController))
+		webhookMux.Handle("/mutate-resourcequotaclaim", controller.NewApprovalWebhook(kotaryController))
+		webhookMux.Handle("/validate-resourcequota", controller.NewQuotaWebhook(strings.Split(quotaWriters, ",")))
+		go func() {
+			klog.Fatal(http.ListenAndServeTLS(fmt.Sprintf(":%d", webhookPort), webhookCertFile, webhookKeyFile, webhookMux))
+		}()
+	}
 
 	// Start all the informeer
 	namespaceInformerFactory.Start(wait.NeverStop)
@@ -112,11 +202,56 @@ func main() {
 	nodeInformerFactory.Start(wait.NeverStop)
 	podInformerFactory.Start(wait.NeverStop)
 	quotaClaimInformerFactory.Start(wait.NeverStop)
+	if configMapInformerFactory != nil {
+		configMapInformerFactory.Start(wait.NeverStop)
+	}
+
+	if election == nil {
+		if err = kotaryController.Run(2, wait.NeverStop); err != nil {
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+		return
+	}
+
+	// Standby replicas keep their informers warm and take over once the lease expires
+	namespace := leaderElectResourceNamespace
+	if namespace == "" {
+		namespace = settingsManger.Namespace
+	}
+	if namespace == "" {
+		namespace = defaultLeaderElectNamespace
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, namespace, leaderElectResourceName,
+		settingsClient.CoreV1(), settingsClient.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: election.Status().Identity})
+	if err != nil {
+		klog.Fatalf("Error creating leader election lock: %s", err.Error())
+	}
 
-	if err = kotaryController.Run(2, wait.NeverStop); err != nil {
+	err = election.Run(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaderElectLeaseDuration,
+		RenewDeadline: leaderElectRenewDeadline,
+		RetryPeriod:   leaderElectRetryPeriod,
+		Name:          leaderElectResourceName,
+	}, kotaryController, 2, wait.NeverStop)
+	if err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
 
+	// The work queues have been shut down, the replica restarts as a standby
+	klog.Fatalf("Leader election lost by %s", election.Status().Identity)
+
+}
+
+// Identity of the replica in the leader election, unique across restarts of the same pod
+func leaderIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Fatalf("Error getting hostname: %s", err.Error())
+	}
+	return hostname + "_" + string(uuid.NewUUID())
 }
 
 func defaultKubeconfig() string {
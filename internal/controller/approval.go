@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Event reason of an approved claim being accepted
+const reasonApproved = "Approved"
+
+// Event reason of an approval policy that can not be satisfied, no decision can be recorded
+const reasonApprovalWebhookDisabled = "ApprovalWebhookDisabled"
+
+// Set the key verifying the approval decisions, the one the claim webhook signs them with
+// The decisions are not trusted until it is set
+func (c *Controller) SetApprovalKey(key []byte) {
+	c.approvalKey = key
+}
+
+// Set whether the claim webhook recording the approval decisions is served
+// Without it the claims requiring an approval can not be decided on
+func (c *Controller) SetApprovalWebhook(enabled bool) {
+	c.approvalWebhook = enabled
+}
+
+// Report an approval policy set while the claim webhook is disabled
+// The error is logged and sent as an event on the QuotaPolicy when there is one
+func (c *Controller) checkApprovalWebhook(settings *utils.Config) {
+	if c.approvalWebhook || !settings.RequiresApproval() {
+		return
+	}
+	klog.Errorf("< %s >", utils.MessageApprovalWebhookDisabled)
+	if policy, err := c.quotaPolicyLister.Get(cagipv1.QuotaPolicyName); err == nil {
+		c.recorder.Event(policy, v1Core.EventTypeWarning, reasonApprovalWebhookDisabled, utils.MessageApprovalWebhookDisabled)
+	}
+}
+
+// Check if the claim must be approved before being applied and if an approver decided on it
+// Return the reason and msg of a claim awaiting approval or denied, an empty msg when it can be applied
+func (c *Controller) checkApproval(claim *cagipv1.ResourceQuotaClaim) (reason string, msg string, err error) {
+	decision, approver, msg := c.claimDecision(claim)
+	if msg != utils.EmptyMsg {
+		return cagipv1.ReasonInvalidApproval, msg, nil
+	}
+	if decision == cagipv1.ApprovalDenied {
+		return cagipv1.ReasonApprovalDenied, fmt.Sprintf(utils.MessageApprovalDenied, approver), nil
+	}
+
+	if msg, err = c.approvalRequirement(claim); err != nil || msg == utils.EmptyMsg {
+		return "", utils.EmptyMsg, err
+	}
+	if decision == cagipv1.ApprovalApproved {
+		return "", utils.EmptyMsg, nil
+	}
+	return cagipv1.ReasonAwaitingApproval, msg, nil
+}
+
+// Notify via an event that an approved claim has been accepted
+// Outside of GitOps mode the claim is deleted, the event keeps track of its approver
+func (c *Controller) recordApproval(claim *cagipv1.ResourceQuotaClaim) {
+	if decision, approver, _ := c.claimDecision(claim); decision == cagipv1.ApprovalApproved {
+		klog.Infof("< RequestQuotaClaim '%s' approved by %s >", claim.Name, approver)
+		c.recorder.Event(claim, v1Core.EventTypeNormal, reasonApproved, fmt.Sprintf(utils.MessageApproved, approver))
+	}
+}
+
+// Return the decision and the approver recorded on a claim, both empty when no decision has been taken
+// Only the decisions signed by the claim webhook are trusted, the annotations can be written by anyone editing the claim
+// Return a msg when the annotations are invalid or not signed
+func (c *Controller) claimDecision(claim *cagipv1.ResourceQuotaClaim) (decision string, approver string, msg string) {
+	decision, approver, msg = parseClaimDecision(claim)
+	if decision == "" || msg != utils.EmptyMsg {
+		return decision, approver, msg
+	}
+	if !utils.VerifyApproval(c.approvalKey, claim, decision, approver) {
+		return "", "", fmt.Sprintf(utils.MessageUnsignedApproval, decision, approver)
+	}
+	return decision, approver, utils.EmptyMsg
+}
+
+// Return the decision and the approver written in the annotations of a claim, both empty when no decision has been taken
+// Return a msg when the annotations are invalid
+func parseClaimDecision(claim *cagipv1.ResourceQuotaClaim) (decision string, approver string, msg string) {
+	decision, found := claim.Annotations[cagipv1.AnnotationApproval]
+	if !found {
+		return "", "", utils.EmptyMsg
+	}
+	approver = claim.Annotations[cagipv1.AnnotationApprover]
+	if (decision != cagipv1.ApprovalApproved && decision != cagipv1.ApprovalDenied) || approver == "" {
+		return "", "", fmt.Sprintf(utils.MessageInvalidApproval, cagipv1.AnnotationApproval, decision, cagipv1.AnnotationApprover)
+	}
+	return decision, approver, utils.EmptyMsg
+}
+
+// Check if the approval policy of the namespace requires an approval for the claim
+// Only the claims growing the managed quota can require one, the growth is measured against the
+// current managed quota or against the default claim when the namespace does not have one yet
+// Return the msg explaining why an approval is required, an empty msg otherwise
+func (c *Controller) approvalRequirement(claim *cagipv1.ResourceQuotaClaim) (string, error) {
+	settings, tier := c.namespaceSettings(claim.Namespace)
+	if !settings.RequireApproval && settings.ApprovalGrowthRatio <= 0 && settings.ApprovalCPUThreshold.IsZero() {
+		return utils.EmptyMsg, nil
+	}
+
+	current := settings.DefaultClaimSpec
+	managedQuota, err := c.resourceQuotaLister.ResourceQuotas(claim.Namespace).Get(utils.ResourceQuotaName)
+	if err == nil {
+		current = managedQuota.Spec.Hard
+	} else if !errors.IsNotFound(err) {
+		return utils.EmptyMsg, err
+	}
+
+	for _, name := range utils.SortedResourceNames(claim.Spec) {
+		claimed := claim.Spec[name]
+		previous := current[name]
+		if claimed.Cmp(previous) <= 0 {
+			continue
+		}
+
+		if settings.RequireApproval {
+			return fmt.Sprintf(utils.MessageApprovalRequired, tierName(tier)), nil
+		}
+
+		// A growth from zero is always above the ratio
+		if ratio := settings.ApprovalGrowthRatio; ratio > 0 && claimed.Cmp(utils.ScaleQuantity(utils.CapacityResourceName(name), previous, 1+ratio)) > 0 {
+			return fmt.Sprintf(utils.MessageApprovalGrowth, name, previous.String(), claimed.String(), ratio*100), nil
+		}
+
+		if threshold := settings.ApprovalCPUThreshold; !threshold.IsZero() && utils.CapacityResourceName(name) == v1Core.ResourceCPU {
+			growth := claimed.DeepCopy()
+			growth.Sub(previous)
+			if growth.Cmp(threshold) > 0 {
+				return fmt.Sprintf(utils.MessageApprovalCPU, growth.String(), threshold.String()), nil
+			}
+		}
+	}
+	return utils.EmptyMsg, nil
+}
+
+// Name of a tier in the messages, the global settings apply outside of any tier
+func tierName(tier string) string {
+	if tier == "" {
+		return "global"
+	}
+	return tier
+}
+
+// Update claim phase to AwaitingApproval with the reason of the approval
+// The claim is evaluated again once an approver sets the approval annotations
+func (c *Controller) claimAwaitingApproval(claim *cagipv1.ResourceQuotaClaim, msg string) (err error) {
+	// Without the claim webhook the approvers can not decide, the claim reports why
+	if !c.approvalWebhook {
+		msg = fmt.Sprintf(utils.MessageAwaitingDisabledWebhook, msg)
+	}
+
+	// A claim that is still awaiting approval for the same reason is only evaluated again
+	if claim.Status.Phase != cagipv1.PhaseAwaitingApproval || claim.Status.ObservedGeneration != claim.Generation || claim.Status.Details != msg {
+		klog.Infof("< RequestQuotaClaim '%s' set to AWAITING_APPROVAL >", claim.Name)
+		// Notify via an event
+		if c.approvalWebhook {
+			c.recorder.Event(claim, v1Core.EventTypeNormal, cagipv1.PhaseAwaitingApproval, msg)
+		} else {
+			c.recorder.Event(claim, v1Core.EventTypeWarning, reasonApprovalWebhookDisabled, msg)
+		}
+		utils.ClaimCounter.WithLabelValues("awaiting_approval").Inc()
+		c.countTierClaim(claim, "awaiting_approval")
+	}
+
+	_, err = c.updateResourceQuotaClaimStatus(claim, cagipv1.PhaseAwaitingApproval, cagipv1.ReasonAwaitingApproval, msg)
+
+	// A claim leaving the queue lets the next claims be evaluated
+	if err == nil && claim.Status.Phase == cagipv1.PhaseWaiting {
+		c.requeueWaitingClaims()
+	}
+	return err
+}
+
+// Return the approval to report in the status of a claim, nil when no decision has been taken
+// The decision keeps the time it has first been observed at
+func (c *Controller) claimApproval(claim *cagipv1.ResourceQuotaClaim) *cagipv1.ClaimApproval {
+	decision, approver, msg := c.claimDecision(claim)
+	if decision == "" || msg != utils.EmptyMsg {
+		return nil
+	}
+	if previous := claim.Status.Approval; previous != nil && previous.Decision == decision && previous.Approver == approver {
+		return previous.DeepCopy()
+	}
+	return &cagipv1.ClaimApproval{Decision: decision, Approver: approver, Time: metav1.NewTime(c.clock.Now())}
+}
+
+// Put the claims awaiting approval back on the work queue, a new policy may not require an approval anymore
+func (c *Controller) requeueAwaitingApprovalClaims() {
+	claims, err := c.resourceQuotaClaimLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Could not retrieve the claims awaiting approval : %s", err)
+		return
+	}
+
+	for _, claim := range claims {
+		if claim.Status.Phase == cagipv1.PhaseAwaitingApproval {
+			c.enqueueResourceQuotaClaim(claim)
+		}
+	}
+}
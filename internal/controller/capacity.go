@@ -0,0 +1,358 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// Karpenter NodePools, their limits bound the capacity Karpenter can provision
+var nodePoolResource = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1", Resource: "nodepools"}
+
+// Key of the status ConfigMap of cluster-autoscaler
+const autoscalerStatusKey = "status"
+
+// CapacityProvider is a source of the capacity the claims are evaluated against
+type CapacityProvider interface {
+	// Name of the provider in the logs
+	Name() string
+	// Capacity of each resource known by the provider
+	Capacity() (v1Core.ResourceList, error)
+}
+
+// Build the capacity providers of the settings
+func (c *Controller) capacityProviders() []CapacityProvider {
+	specs := c.settings.EffectiveCapacityProviders()
+	providers := make([]CapacityProvider, 0, len(specs))
+	for _, spec := range specs {
+		switch spec.Type {
+		case cagipv1.CapacityProviderNodes:
+			providers = append(providers, &nodeCapacity{controller: c})
+		case cagipv1.CapacityProviderStatic:
+			providers = append(providers, &staticCapacity{budget: spec.Resources})
+		case cagipv1.CapacityProviderClusterAutoscaler:
+			namespace, name := utils.AutoscalerStatusConfigMap(spec)
+			providers = append(providers, c.cachedProvider(&autoscalerCapacity{
+				clientset:      c.nodesclientset,
+				nodeLister:     c.nodeLister,
+				namespace:      namespace,
+				name:           name,
+				nodeGroupLabel: spec.NodeGroupLabel,
+			}, strings.Join([]string{spec.Type, namespace, name, spec.NodeGroupLabel}, "/")))
+		case cagipv1.CapacityProviderKarpenter:
+			providers = append(providers, c.cachedProvider(&karpenterCapacity{client: c.dynamicclientset}, spec.Type))
+		}
+	}
+	return providers
+}
+
+// Cache the capacity of a provider calling the API server or another controller
+func (c *Controller) cachedProvider(provider CapacityProvider, key string) CapacityProvider {
+	return &cachedProvider{CapacityProvider: provider, key: key, cache: c.capacityCache, clock: c.clock, readOnly: c.capacityReadOnly}
+}
+
+// Return a message for the live providers of the settings that could not be called
+func (c *Controller) capacityProviderFailures() []string {
+	var keys []string
+	for _, provider := range c.capacityProviders() {
+		if cached, ok := provider.(*cachedProvider); ok {
+			keys = append(keys, cached.key)
+		}
+	}
+	return c.capacityCache.failures(keys)
+}
+
+// Gather the capacity of the providers, combined as the settings require
+// A live provider that can not be called counts with its last known capacity, or is replaced by the nodes
+func (c *Controller) totalCapacity() (*v1Core.ResourceList, error) {
+	var capacities []v1Core.ResourceList
+	fallbackOnNodes, nodesProvided := false, false
+	for _, provider := range c.capacityProviders() {
+		capacity, err := provider.Capacity()
+		_, live := provider.(*cachedProvider)
+		switch {
+		case err != nil && !live:
+			klog.Errorf("Could not retrieve the capacity of provider %s : %s", provider.Name(), err)
+			return nil, err
+		case err != nil && capacity == nil:
+			klog.Warningf("Could not retrieve the capacity of provider %s, the capacity of the nodes is used : %s", provider.Name(), err)
+			fallbackOnNodes = true
+			continue
+		case err != nil:
+			klog.Warningf("Could not retrieve the capacity of provider %s, its last known capacity is used : %s", provider.Name(), err)
+		}
+		nodesProvided = nodesProvided || provider.Name() == cagipv1.CapacityProviderNodes
+		capacities = append(capacities, capacity)
+	}
+	if fallbackOnNodes && !nodesProvided {
+		nodes, err := (&nodeCapacity{controller: c}).Capacity()
+		if err != nil {
+			return nil, err
+		}
+		capacities = append(capacities, nodes)
+	}
+
+	total := combineCapacities(capacities, c.settings.CapacityCombination)
+	klog.Infof("Cluster capacity : %s", formatResourceList(total))
+	return &total, nil
+}
+
+// Gather the capacity a claim is evaluated against
+// A namespace bound to a node pool is evaluated against the allocatable of the nodes of the pool only
+func (c *Controller) claimCapacity(claim *cagipv1.ResourceQuotaClaim) (*v1Core.ResourceList, error) {
+	return c.poolCapacity(c.namespacePool(claim.Namespace))
+}
+
+// Gather the capacity of a node pool, the one of the whole cluster when there is no pool
+func (c *Controller) poolCapacity(pool *utils.NodePool) (*v1Core.ResourceList, error) {
+	if pool == nil {
+		return c.totalCapacity()
+	}
+	nodes, err := c.poolNodes(pool)
+	if err != nil {
+		return nil, err
+	}
+	total := c.smoothedCapacity(pool.Name, nodes)
+	klog.Infof("Capacity of node pool %s : %d Nodes %s", pool.Name, len(nodes), formatResourceList(*total))
+	return total, nil
+}
+
+// Gather the nodes providing the capacity of a node pool, the ones of the whole cluster when there is no pool
+func (c *Controller) poolNodes(pool *utils.NodePool) ([]*v1Core.Node, error) {
+	nodes, err := c.workerNodes()
+	if err != nil || pool == nil {
+		return nodes, err
+	}
+	var poolNodes []*v1Core.Node
+	for _, node := range nodes {
+		if pool.SelectsNode(node) {
+			poolNodes = append(poolNodes, node)
+		}
+	}
+	return poolNodes, nil
+}
+
+// Combine the capacities of the providers with the maximum of each resource, or their sum
+func combineCapacities(capacities []v1Core.ResourceList, combination string) v1Core.ResourceList {
+	total := v1Core.ResourceList{}
+	for _, capacity := range capacities {
+		for name, quantity := range capacity {
+			current, found := total[name]
+			switch {
+			case !found:
+				total[name] = quantity.DeepCopy()
+			case combination == cagipv1.CapacityCombinationSum:
+				current.Add(quantity)
+				total[name] = current
+			case quantity.Cmp(current) > 0:
+				total[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return total
+}
+
+// Allocatable of the ready worker nodes
+type nodeCapacity struct {
+	controller *Controller
+}
+
+func (p *nodeCapacity) Name() string {
+	return cagipv1.CapacityProviderNodes
+}
+
+func (p *nodeCapacity) Capacity() (v1Core.ResourceList, error) {
+	total, err := p.controller.nodesTotalCapacity()
+	if err != nil {
+		return nil, err
+	}
+	return *total, nil
+}
+
+// Fixed budget set in the configuration
+type staticCapacity struct {
+	budget v1Core.ResourceList
+}
+
+func (p *staticCapacity) Name() string {
+	return cagipv1.CapacityProviderStatic
+}
+
+func (p *staticCapacity) Capacity() (v1Core.ResourceList, error) {
+	return p.budget.DeepCopy(), nil
+}
+
+// Maximum size of the cluster-autoscaler node groups
+// A node group provides its maximum size times the allocatable of its largest node
+// The node groups without any node can not be measured, they are not counted
+type autoscalerCapacity struct {
+	clientset      kubernetes.Interface
+	nodeLister     corelisters.NodeLister
+	namespace      string
+	name           string
+	nodeGroupLabel string
+}
+
+func (p *autoscalerCapacity) Name() string {
+	return cagipv1.CapacityProviderClusterAutoscaler
+}
+
+func (p *autoscalerCapacity) Capacity() (v1Core.ResourceList, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), capacityProviderTimeout)
+	defer cancel()
+	configMap, err := p.clientset.CoreV1().ConfigMaps(p.namespace).Get(ctx, p.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	maxSizes, err := parseAutoscalerStatus(configMap.Data[autoscalerStatusKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid status of ConfigMap %s/%s : %s", p.namespace, p.name, err)
+	}
+
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	largestNodes := map[string]v1Core.ResourceList{}
+	for _, node := range nodes {
+		group, found := node.Labels[p.nodeGroupLabel]
+		if !found {
+			continue
+		}
+		largestNodes[group] = combineCapacities([]v1Core.ResourceList{largestNodes[group], node.Status.Allocatable}, cagipv1.CapacityCombinationMax)
+	}
+
+	total := v1Core.ResourceList{}
+	for _, group := range sortedKeys(maxSizes) {
+		largestNode, found := largestNodes[group]
+		if !found {
+			klog.Infof("Node group %s of cluster-autoscaler has no node labeled %s, it is not counted", group, p.nodeGroupLabel)
+			continue
+		}
+		for name, quantity := range largestNode {
+			groupCapacity := utils.ScaleQuantity(name, quantity, float64(maxSizes[group]))
+			sum := total[name]
+			sum.Add(groupCapacity)
+			total[name] = sum
+		}
+	}
+	return total, nil
+}
+
+// Node groups of the legacy text status, one health line follows the name of each group
+var (
+	autoscalerNodeGroupName    = regexp.MustCompile(`^\s*Name:\s*(\S+)\s*$`)
+	autoscalerNodeGroupMaxSize = regexp.MustCompile(`maxSize=(\d+)`)
+)
+
+// Status of cluster-autoscaler, only the fields read by the controller
+type autoscalerStatus struct {
+	NodeGroups []struct {
+		Name   string `json:"name"`
+		Health struct {
+			MaxSize int `json:"maxSize"`
+		} `json:"health"`
+	} `json:"nodeGroups"`
+}
+
+// Return the maximum size of each node group of a cluster-autoscaler status
+// The YAML status of the recent versions and the text status of the older ones are both read
+func parseAutoscalerStatus(status string) (map[string]int, error) {
+	maxSizes := map[string]int{}
+
+	parsed := autoscalerStatus{}
+	if err := yaml.Unmarshal([]byte(status), &parsed); err == nil && len(parsed.NodeGroups) > 0 {
+		for _, group := range parsed.NodeGroups {
+			maxSizes[group.Name] = group.Health.MaxSize
+		}
+		return maxSizes, nil
+	}
+
+	group := ""
+	for _, line := range strings.Split(status, "\n") {
+		if match := autoscalerNodeGroupName.FindStringSubmatch(line); match != nil {
+			group = match[1]
+			continue
+		}
+		if match := autoscalerNodeGroupMaxSize.FindStringSubmatch(line); match != nil && group != "" {
+			maxSizes[group], _ = strconv.Atoi(match[1])
+			group = ""
+		}
+	}
+	if len(maxSizes) == 0 {
+		return nil, fmt.Errorf("no node group found")
+	}
+	return maxSizes, nil
+}
+
+// Limits of the Karpenter NodePools
+// A NodePool without limits is not bounded, it is not counted
+type karpenterCapacity struct {
+	client dynamic.Interface
+}
+
+func (p *karpenterCapacity) Name() string {
+	return cagipv1.CapacityProviderKarpenter
+}
+
+func (p *karpenterCapacity) Capacity() (v1Core.ResourceList, error) {
+	if p.client == nil {
+		return nil, fmt.Errorf("no dynamic client to read the NodePools")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), capacityProviderTimeout)
+	defer cancel()
+	nodePools, err := p.client.Resource(nodePoolResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	total := v1Core.ResourceList{}
+	for _, nodePool := range nodePools.Items {
+		limits, found, err := unstructured.NestedMap(nodePool.Object, "spec", "limits")
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits of NodePool %s : %s", nodePool.GetName(), err)
+		}
+		if !found || len(limits) == 0 {
+			klog.Infof("NodePool %s has no limits, it is not counted", nodePool.GetName())
+			continue
+		}
+		for name, value := range limits {
+			quantity, err := resource.ParseQuantity(fmt.Sprint(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid limit %s of NodePool %s : %s", name, nodePool.GetName(), err)
+			}
+			sum := total[v1Core.ResourceName(name)]
+			sum.Add(quantity)
+			total[v1Core.ResourceName(name)] = sum
+		}
+	}
+	return total, nil
+}
+
+// Return the keys of a map sorted, the node groups are logged in a stable order
+func sortedKeys(values map[string]int) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
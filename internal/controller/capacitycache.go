@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/utils/clock"
+
+	v1Core "k8s.io/api/core/v1"
+)
+
+// Period the capacity of a live provider is reused for, the claims and the webhook do not call it each time
+const capacityCacheTTL = 30 * time.Second
+
+// Timeout of a call to a live provider, the capacity decisions wait for it
+const capacityProviderTimeout = 5 * time.Second
+
+// Error of a live provider read before its first call
+var errCapacityNotFetched = errors.New("capacity not fetched yet")
+
+// Capacity last returned by a live provider
+type cachedCapacity struct {
+	provider string
+	capacity v1Core.ResourceList
+	// Time of the last call and of the last successful one
+	calledAt  time.Time
+	fetchedAt time.Time
+	// Error of the last call, the last known capacity is used meanwhile
+	err error
+}
+
+// capacityCache keeps the capacity of the live providers, keyed by provider
+type capacityCache struct {
+	mutex      sync.Mutex
+	capacities map[string]cachedCapacity
+}
+
+// Create an empty cache
+func newCapacityCache() *capacityCache {
+	return &capacityCache{capacities: map[string]cachedCapacity{}}
+}
+
+// Return the capacity of a provider, it is called again once the cached one is older than the TTL
+// When the call fails the last known capacity is returned along with the error, nil when there is none
+// A failed call is not retried before the TTL either, an unavailable provider does not slow down every claim
+func (cc *capacityCache) get(key string, provider CapacityProvider, now time.Time) (v1Core.ResourceList, error) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	cached, found := cc.capacities[key]
+	if !found || !now.Before(cached.calledAt.Add(capacityCacheTTL)) {
+		capacity, err := provider.Capacity()
+		cached.provider = provider.Name()
+		cached.calledAt = now
+		cached.err = err
+		if err == nil {
+			cached.capacity = capacity
+			cached.fetchedAt = now
+		}
+		cc.capacities[key] = cached
+	}
+	return cached.capacity.DeepCopy(), cached.err
+}
+
+// Return the capacity last returned by a provider without calling it
+func (cc *capacityCache) peek(key string) (v1Core.ResourceList, error) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	cached, found := cc.capacities[key]
+	if !found {
+		return nil, errCapacityNotFetched
+	}
+	return cached.capacity.DeepCopy(), cached.err
+}
+
+// Return a message for the providers whose last call failed
+func (cc *capacityCache) failures(keys []string) (messages []string) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	for _, key := range keys {
+		cached, found := cc.capacities[key]
+		if !found || cached.err == nil {
+			continue
+		}
+		fallback := utils.MessageProviderFallbackNodes
+		if cached.capacity != nil {
+			fallback = fmt.Sprintf(utils.MessageProviderFallbackLastKnown, cached.fetchedAt.Format(time.RFC3339))
+		}
+		messages = append(messages, fmt.Sprintf(utils.MessageProviderUnavailable, cached.provider, cached.err, fallback))
+	}
+	return messages
+}
+
+// Live provider whose capacity is cached
+type cachedProvider struct {
+	CapacityProvider
+	key   string
+	cache *capacityCache
+	clock clock.Clock
+	// Only the cached capacity is read, the provider is not called
+	readOnly bool
+}
+
+func (p *cachedProvider) Capacity() (v1Core.ResourceList, error) {
+	if p.readOnly {
+		return p.cache.peek(p.key)
+	}
+	return p.cache.get(p.key, p.CapacityProvider, p.clock.Now())
+}
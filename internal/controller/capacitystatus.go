@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Delay gathering the changes of nodes, quotas and settings into a single update of the capacity status
+const capacityStatusDelay = 5 * time.Second
+
+// Period the capacity status is refreshed at, the pods of the namespaces without quota change it as well
+const capacityStatusResyncPeriod = time.Minute
+
+// syncHandlerCapacity reports the capacity of the cluster and of its node pools in the status of the QuotaCapacity
+// The QuotaCapacity is created when it does not exist
+func (c *Controller) syncHandlerCapacity() error {
+	capacity, err := c.quotaCapacityLister.Get(cagipv1.QuotaCapacityName)
+	if errors.IsNotFound(err) {
+		klog.Infof("< QuotaCapacity '%s' created >", cagipv1.QuotaCapacityName)
+		capacity, err = c.resourcequotaclaimclientset.CagipV1().QuotaCapacities().Create(context.TODO(), &cagipv1.QuotaCapacity{
+			ObjectMeta: metav1.ObjectMeta{Name: cagipv1.QuotaCapacityName},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := c.newQuotaCapacityStatus(capacity, metav1.NewTime(c.clock.Now()))
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(*status, capacity.Status) {
+		return nil
+	}
+	status.LastUpdateTime = metav1.NewTime(c.clock.Now())
+
+	return c.updateQuotaCapacityStatus(capacity, status)
+}
+
+// Build the status of the QuotaCapacity from the capacity of the cluster and of its node pools
+// The update time is kept, it only changes when the capacity does
+func (c *Controller) newQuotaCapacityStatus(capacity *cagipv1.QuotaCapacity, now metav1.Time) (*cagipv1.QuotaCapacityStatus, error) {
+	status := &cagipv1.QuotaCapacityStatus{
+		LastUpdateTime: capacity.Status.LastUpdateTime,
+		Conditions:     capacity.Status.DeepCopy().Conditions,
+	}
+
+	summary, err := c.capacitySummary(nil)
+	if err != nil {
+		return nil, err
+	}
+	status.CapacitySummary = summary
+	overCommitted := overCommittedResources(nil, summary)
+
+	for i := range c.settings.NodePools {
+		pool := &c.settings.NodePools[i]
+		summary, err := c.capacitySummary(pool)
+		if err != nil {
+			return nil, err
+		}
+		status.NodePools = append(status.NodePools, cagipv1.NodePoolCapacity{Name: pool.Name, CapacitySummary: summary})
+		overCommitted = append(overCommitted, overCommittedResources(pool, summary)...)
+	}
+
+	condition := metav1.Condition{
+		Type:               cagipv1.ConditionOverCommitted,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: now,
+		Reason:             cagipv1.ReasonReservedWithinAllocatable,
+		Message:            utils.MessageReservedWithinAllocatable,
+	}
+	if len(overCommitted) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = cagipv1.ReasonReservedOverAllocatable
+		condition.Message = strings.Join(overCommitted, ", ")
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	condition = metav1.Condition{
+		Type:               cagipv1.ConditionCapacityDegraded,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: now,
+		Reason:             cagipv1.ReasonProvidersAvailable,
+		Message:            utils.MessageProvidersAvailable,
+	}
+	if failures := c.capacityProviderFailures(); len(failures) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = cagipv1.ReasonProviderUnavailable
+		condition.Message = strings.Join(failures, ", ")
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	return status, nil
+}
+
+// Detail the capacity of a node pool, the one of the whole cluster when there is no pool
+// The over-commit and the allocation limit follow the ratios of the pool, the ones of each tier are applied on its own
+func (c *Controller) capacitySummary(pool *utils.NodePool) (cagipv1.CapacitySummary, error) {
+	allocatable, err := c.poolCapacity(pool)
+	if err != nil {
+		return cagipv1.CapacitySummary{}, err
+	}
+
+	quotaResources, err := c.poolResourceQuota(pool, "")
+	if err != nil {
+		return cagipv1.CapacitySummary{}, err
+	}
+
+	requestsByNamespace, err := c.unquotedNamespaceRequests(pool, "")
+	if err != nil {
+		return cagipv1.CapacitySummary{}, err
+	}
+	unquotedResources := v1Core.ResourceList{}
+	for _, requests := range requestsByNamespace {
+		unquotedResources = quota.Add(unquotedResources, *requests)
+	}
+	reservedResources := quota.Add(*quotaResources, unquotedResources)
+
+	settings := c.settings
+	if pool != nil {
+		settings = pool.Apply(settings)
+	}
+	overCommittedResources := applyOverProvisioning(settings, allocatable)
+
+	summary := cagipv1.CapacitySummary{
+		Allocatable:        *allocatable,
+		OverCommitted:      *overCommittedResources,
+		ReservedByQuotas:   *quotaResources,
+		ReservedByUnquoted: unquotedResources,
+		Free:               v1Core.ResourceList{},
+		MaxClaimable:       maxClaimable(settings, *allocatable, reservedResources),
+	}
+	for name, capacity := range *overCommittedResources {
+		summary.Free[name] = freeCapacity(capacity, reservedResources[name])
+	}
+
+	// The namespaces of a tier bound to the pool are evaluated with the ratios of the tier
+	for _, tier := range c.settings.Tiers {
+		tierSettings := tier.Settings
+		if pool != nil {
+			tierSettings = pool.Apply(tierSettings)
+		}
+		summary.Tiers = append(summary.Tiers, cagipv1.TierCapacity{
+			Name:         tier.Name,
+			MaxClaimable: maxClaimable(tierSettings, *allocatable, reservedResources),
+		})
+	}
+
+	return summary, nil
+}
+
+// Return the maximum a namespace without quota can claim with some settings
+// A namespace can not claim more than the allocation limit, nor more than what is left of the over-committed capacity
+func maxClaimable(settings utils.Config, allocatable v1Core.ResourceList, reservedResources v1Core.ResourceList) v1Core.ResourceList {
+	claimable := v1Core.ResourceList{}
+	for name, capacity := range *applyOverProvisioning(settings, &allocatable) {
+		free := freeCapacity(capacity, reservedResources[name])
+		limit := utils.ScaleQuantity(name, allocatable[name], settings.ResourcePolicy(name).RatioMaxAllocation)
+		if limit.Cmp(free) > 0 {
+			limit = free
+		}
+		claimable[name] = limit
+	}
+	return claimable
+}
+
+// Return a message for each resource reserved over the allocatable of a node pool or of the whole cluster
+func overCommittedResources(pool *utils.NodePool, summary cagipv1.CapacitySummary) (messages []string) {
+	reservedResources := quota.Add(summary.ReservedByQuotas, summary.ReservedByUnquoted)
+	for _, name := range utils.SortedResourceNames(summary.Allocatable) {
+		allocatable := summary.Allocatable[name]
+		reserved := reservedResources[name]
+		if reserved.Cmp(allocatable) > 0 {
+			messages = append(messages, fmt.Sprintf(utils.MessageReservedOverAllocatable,
+				reserved.String(), name, allocatable.String(), utils.NodePoolLabel(poolName(pool))))
+		}
+	}
+	return messages
+}
+
+// Update the QuotaCapacityStatus
+func (c *Controller) updateQuotaCapacityStatus(capacity *cagipv1.QuotaCapacity, status *cagipv1.QuotaCapacityStatus) error {
+	// DeepCopy of the original object, very important has we area dealing with a SharedInformer
+	capacityCopy := capacity.DeepCopy()
+	capacityCopy.Status = *status
+
+	_, err := c.resourcequotaclaimclientset.CagipV1().QuotaCapacities().UpdateStatus(context.TODO(), capacityCopy, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("Could not update status on QuotaCapacity %s ", capacity.Name)
+		return err
+	}
+
+	klog.Infof("< QuotaCapacity '%s' updated : %s free >", capacity.Name, formatResourceList(status.Free))
+	return nil
+}
+
+// enqueueCapacityStatus puts the QuotaCapacity on the work queue, the changes happening meanwhile are gathered
+func (c *Controller) enqueueCapacityStatus() {
+	c.quotaCapacityWorkQueue.AddAfter(cagipv1.QuotaCapacityName, capacityStatusDelay)
+}
@@ -3,10 +3,11 @@ package controller
 import (
 	"context"
 	"fmt"
-	"math"
+	"time"
 
 	"github.com/ca-gip/kotary/internal/utils"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/labels"
 	quota "k8s.io/apiserver/pkg/quota/v1"
@@ -21,6 +22,17 @@ import (
 
 // Handle claims from the workqueue
 func (c *Controller) syncHandlerClaim(key string) error {
+	// The batching window is over
+	if key == admissionBatchKey {
+		return c.admitBatch()
+	}
+
+	// A claim of a batch being admitted is not evaluated by a worker at the same time
+	if !c.evaluating.acquire(key) {
+		return errClaimInProgress
+	}
+	defer c.evaluating.release(key)
+
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -41,91 +53,215 @@ func (c *Controller) syncHandlerClaim(key string) error {
 		return err
 	}
 
-	// TODO : Add feature gate
-	// Get the managed quota
-	// It there was an error different than not found the error is return
-	// If it was found it's possible to check scaledown
-	managedQuota, err := c.resourceQuotaLister.ResourceQuotas(claim.Namespace).Get(utils.ResourceQuotaName)
-	if !errors.IsNotFound(err) && err != nil {
+	// A burst claim restores the previous quota once it expires
+	// Only a spec edit makes it a new request
+	if claim.Status.ExpiresAt != nil && claim.Status.ObservedGeneration == claim.Generation {
+		return c.syncExpiringClaim(claim)
+	}
+
+	// In GitOps mode an accepted claim stays the source of truth of the managed quota
+	// Only a spec edit is evaluated again
+	if c.settings.KeepAcceptedClaims && claim.Status.ObservedGeneration == claim.Generation {
+		switch claim.Status.Phase {
+		case cagipv1.PhaseAccepted:
+			return c.updateResourceQuota(claim)
+		case cagipv1.PhaseSuperseded:
+			return nil
+		}
+	}
+
+	// Burst claims carry their expiry as annotations
+	// If they are invalid the claim is rejected
+	expiresAt, msg := claimExpiry(claim, c.clock.Now())
+	if msg != utils.EmptyMsg {
+		err := c.claimRejected(claim, cagipv1.ReasonInvalidExpiry, msg)
+		return err
+	}
+
+	// The priority annotation must be an integer
+	if _, msg = c.claimPriority(claim); msg != utils.EmptyMsg {
+		err := c.claimRejected(claim, cagipv1.ReasonInvalidPriority, msg)
 		return err
-	} else if !errors.IsNotFound(err) {
-		// List pod in the claim ns
-		pods, err := c.podsLister.Pods(claim.Namespace).List(utils.DefaultLabelSelector())
+	}
 
-		pods = utils.FilterRunningPods(pods) // Keep only Running Pods
+	// Check if the quota is scaling down
+	// If scaling down checks if the claim is higher than the total amount of request on the NS
+	msg, err = c.checkDownscale(claim)
+	if err != nil {
+		return err
+	} else if msg != utils.EmptyMsg {
+		err = c.claimPending(claim, cagipv1.ReasonAwaitingLowerUsage, msg)
+		return err
+	}
 
-		if err != nil {
+	// Claims are collected during the batching window to be admitted in priority order
+	if c.settings.AdmissionBatchWindow > 0 && !c.admitting {
+		c.batchClaim(key)
+		return c.claimBatched(claim)
+	}
+
+	// Evaluate the claim against the cluster capacity, the managed quota is updated when it fits
+	revertTo, reason, msg, err := c.reserveCapacity(claim, expiresAt != nil)
+	if err != nil {
+		return err
+	} else if msg != utils.EmptyMsg {
+		// A claim that does not fit waits for capacity when the queue is enabled
+		if reason == cagipv1.ReasonAwaitingCapacity || (reason == cagipv1.ReasonInsufficientCapacity && c.settings.WaitForCapacity) {
+			return c.claimWaiting(claim, reason, msg)
+		}
+		// A claim that fits but requires an approval is held until an approver decides
+		if reason == cagipv1.ReasonAwaitingApproval {
+			return c.claimAwaitingApproval(claim, msg)
+		}
+		err = c.claimRejected(claim, reason, msg)
+		return err
+	}
+	c.recordApproval(claim)
+
+	switch {
+	case expiresAt != nil:
+		// The claim is kept until it expires
+		err = c.claimAcceptedUntil(claim, expiresAt, revertTo)
+	case c.settings.KeepAcceptedClaims:
+		// The claim is kept and the previously accepted ones are superseded
+		err = c.claimAccepted(claim)
+	default:
+		// The claim is removed along with the burst claims it replaces
+		if err = c.recordScheduledRun(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg); err != nil {
 			return err
 		}
-		// Check is the quota is scaling down
-		// If scaling down checks if the claim is higher than the total amount of request on the NS
-		if isDownscale := isDownscaleQuota(claim, managedQuota); isDownscale {
-			if msg := canDownscaleQuota(claim, utils.TotalRequestNS(pods)); msg != utils.EmptyMsg {
-				err = c.claimPending(claim, msg)
-				return err
-			}
+		if err = c.supersedeAcceptedClaims(claim); err == nil {
+			err = c.deleteResourceQuotaClaim(claim)
 		}
 	}
+	if err != nil {
+		return err
+	}
+
+	// The next claim of the queue may fit now
+	if claim.Status.Phase == cagipv1.PhaseWaiting {
+		c.requeueWaitingClaims()
+	}
+
+	utils.ClaimCounter.WithLabelValues("success").Inc()
+	c.countTierClaim(claim, "success")
+	klog.Infof("< RequestQuotaClaim '%s' ACCEPTED >", claim.Name)
+
+	// Everything went well
+	return nil
+}
+
+// Check that the claim fits in the cluster capacity and update the managed quota when it does
+// The decisions are serialized, a claim is evaluated against the quotas granted to the claims evaluated
+// before it even when the informer has not observed them yet
+// Return the rejection reason and msg, or an empty msg when the quota has been updated
+func (c *Controller) reserveCapacity(claim *cagipv1.ResourceQuotaClaim, burst bool) (revertTo v1Core.ResourceList, reason string, msg string, err error) {
+	c.reservations.decision.Lock()
+	defer c.reservations.decision.Unlock()
 
 	// Gather Nodes and ResourceQuota ResourceList to evaluate if there is enough capacity to accept
 	// the ResourceQuotaClaim
-	availableResources, err := c.nodesTotalCapacity()
+	availableResources, err := c.claimCapacity(claim)
 	if err != nil {
-		return err
+		return nil, "", utils.EmptyMsg, err
 	}
 
-	// Gather ResourceQuotas on the cluster minus the one of the namespace that is being evaluated
-	reservedResources, err := c.totalResourceQuota(claim)
+	// Gather ResourceQuotas on the cluster, or on the node pool of the namespace, minus the one of the namespace that is being evaluated
+	quotaResources, err := c.totalResourceQuota(claim)
 	if err != nil {
-		return err
+		return nil, "", utils.EmptyMsg, err
+	}
+
+	// Pods running outside of any quota consume capacity as well
+	unquotedResources, err := c.unquotedRequests(claim)
+	if err != nil {
+		return nil, "", utils.EmptyMsg, err
 	}
+	reservedResources := quota.Add(*quotaResources, *unquotedResources)
+	reportReservedCapacity(quotaResources, unquotedResources)
 
 	// Check that the claim respect the allocation limit
 	// If it does not the claim is rejected
-	if msg := c.checkAllocationLimit(claim, availableResources); msg != utils.EmptyMsg {
-		err := c.claimRejected(claim, msg)
-		return err
+	if msg = c.checkAllocationLimit(claim, availableResources); msg != utils.EmptyMsg {
+		return nil, cagipv1.ReasonAllocationLimitExceeded, msg, nil
 	}
 
-	// Check that there are enough resources to fit the claim
-	// If it does not the claim is rejected
-	if msg := c.checkResourceFit(claim, availableResources, reservedResources); msg != utils.EmptyMsg {
-		err := c.claimRejected(claim, msg)
-		return err
+	// The claims waiting for capacity are admitted first in first out
+	// If others waited longer the claim waits behind them
+	if c.settings.WaitForCapacity {
+		if msg, err = c.checkWaitingQueue(claim); err != nil {
+			return nil, "", utils.EmptyMsg, err
+		} else if msg != utils.EmptyMsg {
+			return nil, cagipv1.ReasonAwaitingCapacity, msg, nil
+		}
 	}
 
-	// The claim has passed the verification
+	// Check that there are enough resources to fit the claim
+	// If it does not the unused quota of the namespaces of lower priority can be reclaimed, otherwise the claim is rejected
+	if msg = c.checkResourceFit(claim, availableResources, &reservedResources); msg != utils.EmptyMsg {
+		if !c.settings.ReclaimUnusedQuota || c.settings.KeepAcceptedClaims {
+			return nil, cagipv1.ReasonInsufficientCapacity, msg, nil
+		}
+		// The quotas of other namespaces are only lowered for a claim that can be applied
+		if reason, msg, err := c.checkApproval(claim); err != nil || msg != utils.EmptyMsg {
+			return nil, reason, msg, err
+		}
+		reclaimed, err := c.reclaimUnusedQuota(claim, c.capacityShortfall(claim, availableResources, &reservedResources))
+		if err != nil {
+			return nil, "", utils.EmptyMsg, err
+		} else if !reclaimed {
+			return nil, cagipv1.ReasonInsufficientCapacity, msg, nil
+		}
 
-	// The managed quota is updated
-	err = c.updateResourceQuota(claim)
-	if err != nil {
-		return err
+		// The fit is checked again against the lowered quotas
+		if quotaResources, err = c.totalResourceQuota(claim); err != nil {
+			return nil, "", utils.EmptyMsg, err
+		}
+		reservedResources = quota.Add(*quotaResources, *unquotedResources)
+		if msg = c.checkResourceFit(claim, availableResources, &reservedResources); msg != utils.EmptyMsg {
+			return nil, cagipv1.ReasonInsufficientCapacity, msg, nil
+		}
 	}
 
-	// The claim is removed
-	err = c.deleteResourceQuotaClaim(claim)
-	if err != nil {
-		return err
+	// The claims fitting the capacity may require an approval before being applied
+	if reason, msg, err = c.checkApproval(claim); err != nil || msg != utils.EmptyMsg {
+		return nil, reason, msg, err
 	}
 
-	utils.ClaimCounter.WithLabelValues("success").Inc()
-	klog.Infof("< RequestQuotaClaim '%s' ACCEPTED >", claim.Name)
+	// The claim has passed the verification
 
-	// Everything went well
-	return nil
+	// A burst claim remembers the quota to restore before it is replaced
+	if burst {
+		if revertTo, err = c.burstRevertTo(claim); err != nil {
+			return nil, "", utils.EmptyMsg, err
+		}
+	}
+
+	// The managed quota is updated
+	return revertTo, "", utils.EmptyMsg, c.updateResourceQuota(claim)
 }
 
 // Update the ResourceQuotaClaimStatus
-func (c *Controller) updateResourceQuotaClaimStatus(claim *cagipv1.ResourceQuotaClaim, phase string, details string) (claimCopy *cagipv1.ResourceQuotaClaim, err error) {
+func (c *Controller) updateResourceQuotaClaimStatus(claim *cagipv1.ResourceQuotaClaim, phase string, reason string, details string) (claimCopy *cagipv1.ResourceQuotaClaim, err error) {
+
+	return c.setResourceQuotaClaimStatus(claim, newResourceQuotaClaimStatus(claim, phase, reason, details, metav1.NewTime(c.clock.Now())))
+}
+
+// Replace the ResourceQuotaClaimStatus
+func (c *Controller) setResourceQuotaClaimStatus(claim *cagipv1.ResourceQuotaClaim, status cagipv1.ResourceQuotaClaimStatus) (claimCopy *cagipv1.ResourceQuotaClaim, err error) {
 
 	// DeepCopy of the original claim, very important has we area dealing with a SharedInformer
 	claimCopy = claim.DeepCopy()
 
 	// Update to the specified Phase
-	claimCopy.Status = cagipv1.ResourceQuotaClaimStatus{
-		Phase:   phase,
-		Details: details,
-	}
+	claimCopy.Status = status
+
+	// Report the tier and the priority the claim has been evaluated with
+	_, claimCopy.Status.Tier = c.namespaceSettings(claim.Namespace)
+	claimCopy.Status.Priority, _ = c.claimPriority(claim)
+
+	// Report the decision of the approver, if any
+	claimCopy.Status.Approval = c.claimApproval(claim)
 
 	// ResourceQuotaClaimStatus feature gate is enabled,
 	// we must use UpdateStatus instead of Update to update the Status block.
@@ -141,30 +277,201 @@ func (c *Controller) updateResourceQuotaClaimStatus(claim *cagipv1.ResourceQuota
 
 	klog.V(6).Infof("Updated phase on %s/%s ", claim.Name, claim.Namespace)
 
-	return claimCopy, nil
+	// Claims emitted by a ScheduledQuotaClaim report their outcome
+	return claimCopy, c.recordScheduledRun(claim, status.Phase, status.Reason, status.Details)
+
+}
+
+// Build the status of a claim evaluated at a given time
+// The conditions keep their transition time when their status does not change
+func newResourceQuotaClaimStatus(claim *cagipv1.ResourceQuotaClaim, phase string, reason string, details string, now metav1.Time) cagipv1.ResourceQuotaClaimStatus {
+	status := cagipv1.ResourceQuotaClaimStatus{
+		Phase:              phase,
+		Details:            details,
+		Reason:             reason,
+		ObservedGeneration: claim.Generation,
+		LastEvaluationTime: &now,
+		Conditions:         claim.Status.DeepCopy().Conditions,
+	}
+
+	accepted := phase == cagipv1.PhaseAccepted || phase == cagipv1.PhaseSuperseded
+	applied := phase == cagipv1.PhaseAccepted
+
+	for _, conditionState := range []struct {
+		conditionType string
+		status        bool
+	}{
+		{cagipv1.ConditionEvaluated, true},
+		{cagipv1.ConditionAccepted, accepted},
+		{cagipv1.ConditionApplied, applied},
+	} {
+		condition := metav1.Condition{
+			Type:               conditionState.conditionType,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: claim.Generation,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            details,
+		}
+		if conditionState.status {
+			condition.Status = metav1.ConditionTrue
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
 
+	return status
 }
 
 // Update claim phase to Rejected with a msg
-func (c *Controller) claimRejected(claim *cagipv1.ResourceQuotaClaim, msg string) (err error) {
+// A waiting claim that is rejected lets the next claims of the queue be evaluated
+func (c *Controller) claimRejected(claim *cagipv1.ResourceQuotaClaim, reason string, msg string) (err error) {
 	klog.Infof("< RequestQuotaClaim '%s' set to REJECTED >", claim.Name)
 	// Notify via an event
 	c.recorder.Event(claim, v1Core.EventTypeWarning, cagipv1.PhaseRejected, msg)
 	// Update ResourceQuotaClaim Status to Rejected Phase
-	_, err = c.updateResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, msg)
+	_, err = c.updateResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, reason, msg)
 	utils.ClaimCounter.WithLabelValues("rejected").Inc()
+	c.countTierClaim(claim, "rejected")
+	if err == nil && claim.Status.Phase == cagipv1.PhaseWaiting {
+		c.requeueWaitingClaims()
+	}
 	return
 }
 
-// Update claim phase to Pending with a msg
-func (c *Controller) claimPending(claim *cagipv1.ResourceQuotaClaim, msg string) (err error) {
-	klog.Infof("< RequestQuotaClaim '%s' set to PENDING >", claim.Name)
-	// Notify via an event
-	c.recorder.Event(claim, v1Core.EventTypeWarning, cagipv1.PhasePending, msg)
-	// Update ResourceQuotaClaim Status to Rejected Phase
-	_, err = c.updateResourceQuotaClaimStatus(claim, cagipv1.PhasePending, msg)
-	utils.ClaimCounter.WithLabelValues("pending").Inc()
-	return
+// Update claim phase to Pending with a msg and requeue it
+// A claim pending for longer than the pending timeout is rejected
+func (c *Controller) claimPending(claim *cagipv1.ResourceQuotaClaim, reason string, msg string) (err error) {
+	now := c.clock.Now()
+	pendingSince := claimPendingSince(claim, now)
+	if timeout := c.settings.PendingTimeout; timeout > 0 && !now.Before(pendingSince.Add(timeout)) {
+		return c.claimRejected(claim, cagipv1.ReasonPendingTimeout, fmt.Sprintf(utils.MessagePendingTimeout, timeout, msg))
+	}
+
+	// A claim that is still pending for the same reason is only evaluated again
+	if !isStillPending(claim, reason, msg) {
+		klog.Infof("< RequestQuotaClaim '%s' set to PENDING >", claim.Name)
+		// Notify via an event
+		c.recorder.Event(claim, v1Core.EventTypeWarning, cagipv1.PhasePending, msg)
+		utils.ClaimCounter.WithLabelValues("pending").Inc()
+		c.countTierClaim(claim, "pending")
+	}
+
+	// Update ResourceQuotaClaim Status to Pending Phase
+	status := newResourceQuotaClaimStatus(claim, cagipv1.PhasePending, reason, msg, metav1.NewTime(now))
+	status.PendingSince = &pendingSince
+	if _, err = c.setResourceQuotaClaimStatus(claim, status); err != nil {
+		return err
+	}
+
+	c.requeuePendingClaim(claim, pendingSince.Time)
+	return nil
+}
+
+// Time at which a claim started to be pending, a spec edit starts a new wait
+func claimPendingSince(claim *cagipv1.ResourceQuotaClaim, now time.Time) metav1.Time {
+	if claim.Status.Phase == cagipv1.PhasePending && claim.Status.PendingSince != nil && claim.Status.ObservedGeneration == claim.Generation {
+		return *claim.Status.PendingSince.DeepCopy()
+	}
+	return metav1.NewTime(now)
+}
+
+// Check if a claim has already been set to pending for the same reason
+func isStillPending(claim *cagipv1.ResourceQuotaClaim, reason string, msg string) bool {
+	return claim.Status.Phase == cagipv1.PhasePending && claim.Status.ObservedGeneration == claim.Generation &&
+		claim.Status.Reason == reason && claim.Status.Details == msg
+}
+
+// Put a pending claim back on the work queue, the pod events of its namespace may not lower its usage
+func (c *Controller) requeuePendingClaim(claim *cagipv1.ResourceQuotaClaim, pendingSince time.Time) {
+	if delay := c.pendingRequeueDelay(pendingSince); delay > 0 {
+		c.enqueueResourceQuotaClaimAfter(claim, delay)
+	}
+}
+
+// Delay after which a pending claim is evaluated again, 0 when it is only evaluated on pod events
+// It is the requeue interval, or the time left before the pending timeout if it comes first
+func (c *Controller) pendingRequeueDelay(pendingSince time.Time) time.Duration {
+	delay := c.settings.PendingRequeueInterval
+	if timeout := c.settings.PendingTimeout; timeout > 0 {
+		if remaining := pendingSince.Add(timeout).Sub(c.clock.Now()); delay <= 0 || remaining < delay {
+			delay = remaining
+		}
+	}
+	return delay
+}
+
+// Update claim phase to Accepted and supersede the other accepted claims of the namespace
+func (c *Controller) claimAccepted(claim *cagipv1.ResourceQuotaClaim) (err error) {
+	if _, err = c.updateResourceQuotaClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg); err != nil {
+		return err
+	}
+
+	return c.supersedeAcceptedClaims(claim)
+}
+
+// Supersede the other accepted claims of the namespace
+// Outside of GitOps mode they are burst claims, they are removed
+func (c *Controller) supersedeAcceptedClaims(claim *cagipv1.ResourceQuotaClaim) (err error) {
+	claims, err := c.resourceQuotaClaimLister.ResourceQuotaClaims(claim.Namespace).List(utils.DefaultLabelSelector())
+	if err != nil {
+		return err
+	}
+
+	for _, other := range claims {
+		if other.Name == claim.Name || other.Status.Phase != cagipv1.PhaseAccepted {
+			continue
+		}
+		if !c.settings.KeepAcceptedClaims {
+			if err = c.deleteResourceQuotaClaim(other); err != nil {
+				return err
+			}
+			continue
+		}
+		klog.Infof("< RequestQuotaClaim '%s' set to SUPERSEDED >", other.Name)
+		if _, err = c.updateResourceQuotaClaimStatus(other, cagipv1.PhaseSuperseded, cagipv1.ReasonSuperseded, fmt.Sprintf(utils.MessageSuperseded, claim.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check if a claim has already been evaluated and does not need to be processed again
+func isClaimEvaluated(claim *cagipv1.ResourceQuotaClaim) bool {
+	switch claim.Status.Phase {
+	case cagipv1.PhaseRejected, cagipv1.PhaseAccepted, cagipv1.PhaseSuperseded, cagipv1.PhaseExpired:
+		return true
+	}
+	return false
+}
+
+// Check if the claim scales down the managed-quota under the current requests of the namespace
+// If it does return a pending msg
+// Otherwise return an empty msg
+func (c *Controller) checkDownscale(claim *cagipv1.ResourceQuotaClaim) (string, error) {
+	// TODO : Add feature gate
+	// Get the managed quota
+	// It there was an error different than not found the error is return
+	// If it was found it's possible to check scaledown
+	managedQuota, err := c.resourceQuotaLister.ResourceQuotas(claim.Namespace).Get(utils.ResourceQuotaName)
+	if errors.IsNotFound(err) {
+		return utils.EmptyMsg, nil
+	} else if err != nil {
+		return utils.EmptyMsg, err
+	}
+
+	if !isDownscaleQuota(claim, managedQuota) {
+		return utils.EmptyMsg, nil
+	}
+
+	// List pod in the claim ns
+	pods, err := c.podsLister.Pods(claim.Namespace).List(utils.DefaultLabelSelector())
+	if err != nil {
+		return utils.EmptyMsg, err
+	}
+
+	// The pods are charged as the ResourceQuota admission does, Pending pods included
+	return canDownscaleQuota(claim, utils.PodsQuotaUsage(pods, c.clock.Now())), nil
 }
 
 // Update the specification of the managed-quota
@@ -175,63 +482,69 @@ func (c *Controller) updateResourceQuota(claim *cagipv1.ResourceQuotaClaim) erro
 	// If the resource doesn't exist, we create it
 	if errors.IsNotFound(err) {
 		klog.V(4).Infof("No existing ResourceQuota for ns %s", claim.Namespace)
-		resourceQuota, err = c.resourcequotaclientset.CoreV1().ResourceQuotas(claim.Namespace).Create(context.TODO(), newResourceQuota(claim), metav1.CreateOptions{})
+		resourceQuota, err = c.resourcequotaclientset.CoreV1().ResourceQuotas(claim.Namespace).Create(context.TODO(), c.newManagedQuota(claim), metav1.CreateOptions{})
 
 		// If an error occurs during Create, the item is requeue
 		if err != nil {
 			klog.V(4).Infof("Error creating ResourceQuota for ns %s", claim.Namespace)
 			return err
 		}
+		c.reservations.record(claim.Namespace, claim.Spec, c.clock.Now())
+		c.acceptedSpecs.record(claim.Namespace, claim.Spec)
 	} else if !quota.Equals(resourceQuota.Status.Hard, claim.Spec) {
 		// If this spec of the ResourceQuota is not the desired one we update it
 		klog.V(4).Infof("ResourceQuota not synced, updating for ns %s", claim.Namespace)
-		_, err := c.resourcequotaclientset.CoreV1().ResourceQuotas(claim.Namespace).Update(context.TODO(), newResourceQuota(claim), metav1.UpdateOptions{})
+		_, err := c.resourcequotaclientset.CoreV1().ResourceQuotas(claim.Namespace).Update(context.TODO(), c.newManagedQuota(claim), metav1.UpdateOptions{})
 		if err != nil {
 			klog.Errorf("Could not update ResourceQuotas for ns %s ", claim.Annotations)
 			// If an error occurs during Create, the item is requeue
 			return err
 		}
+		c.reservations.record(claim.Namespace, claim.Spec, c.clock.Now())
+		c.acceptedSpecs.record(claim.Namespace, claim.Spec)
+	} else if err == nil {
+		c.acceptedSpecs.record(claim.Namespace, claim.Spec)
 	}
 
 	return err
 }
 
-// Apply over provisioning on a resource list
-func (c *Controller) applyOverProvisioning(current *v1Core.ResourceList) (overProvisioned *v1Core.ResourceList) {
-	return &v1Core.ResourceList{
-		v1Core.ResourceCPU: *resource.NewMilliQuantity(
-			int64(math.Round(float64(current.Cpu().MilliValue())*c.settings.RatioOverCommitCPU)),
-			resource.DecimalSI),
-		v1Core.ResourceMemory: *resource.NewQuantity(
-			int64(math.Round(float64(current.Memory().Value())*c.settings.RatioOverCommitMemory)),
-			resource.BinarySI),
+// Apply the over provisioning of the settings on a resource list
+func applyOverProvisioning(settings utils.Config, current *v1Core.ResourceList) (overProvisioned *v1Core.ResourceList) {
+	overProvisioned = &v1Core.ResourceList{}
+	for name, quantity := range *current {
+		(*overProvisioned)[name] = utils.ScaleQuantity(name, quantity, settings.ResourcePolicy(name).OverCommitRatio())
 	}
+	return overProvisioned
+}
+
+// Count an evaluated claim under the tier of its namespace
+func (c *Controller) countTierClaim(claim *cagipv1.ResourceQuotaClaim, status string) {
+	_, tier := c.namespaceSettings(claim.Namespace)
+	utils.TierClaimCounter.WithLabelValues(utils.TierLabel(tier), status).Inc()
 }
 
 // Check if a claim is under the allocation limit
 // If it doesn't comply return an error msg
 // Otherwise return an empty msg
 func (c *Controller) checkAllocationLimit(claim *cagipv1.ResourceQuotaClaim, availableResources *v1Core.ResourceList) string {
+	settings, _ := c.namespaceSettings(claim.Namespace)
 
-	allocationLimit := &v1Core.ResourceList{
-		v1Core.ResourceMemory: *resource.NewQuantity(
-			int64(math.Round(float64(availableResources.Memory().Value())*c.settings.RatioMaxAllocationMemory)),
-			resource.BinarySI),
-		v1Core.ResourceCPU: *resource.NewMilliQuantity(
-			int64(math.Round(float64(availableResources.Cpu().MilliValue())*c.settings.RatioMaxAllocationCPU)),
-			resource.DecimalSI),
-	}
+	for _, name := range utils.SortedResourceNames(claim.Spec) {
+		capacityName := utils.CapacityResourceName(name)
 
-	if claim.Spec.Memory().Value() > allocationLimit.Memory().Value() {
-		return fmt.Sprintf(utils.MessageMemoryAllocationLimit,
-			claim.Spec.Memory().String(),
-			utils.BytesSize(float64(allocationLimit.Memory().Value())))
-	}
+		// Resources that are not provided by the nodes can not be evaluated
+		capacity, found := (*availableResources)[capacityName]
+		if !found {
+			continue
+		}
+
+		allocationLimit := utils.ScaleQuantity(capacityName, capacity, settings.ResourcePolicy(capacityName).RatioMaxAllocation)
+		claimed := claim.Spec[name]
 
-	if claim.Spec.Cpu().MilliValue() > allocationLimit.Cpu().MilliValue() {
-		return fmt.Sprintf(utils.MessageCpuAllocationLimit,
-			claim.Spec.Cpu().String(),
-			allocationLimit.Cpu().String())
+		if claimed.Cmp(allocationLimit) > 0 {
+			return allocationLimitMessage(name, claimed, allocationLimit)
+		}
 	}
 
 	return utils.EmptyMsg
@@ -240,50 +553,116 @@ func (c *Controller) checkAllocationLimit(claim *cagipv1.ResourceQuotaClaim, ava
 // Check that they are enough resources to fit the claim
 func (c *Controller) checkResourceFit(claim *cagipv1.ResourceQuotaClaim, availableResources *v1Core.ResourceList, reservedResources *v1Core.ResourceList) string {
 
-	// Apply OverProvisioning
-	overCommittedResources := c.applyOverProvisioning(availableResources)
+	// Apply OverProvisioning with the ratios of the namespace tier
+	settings, _ := c.namespaceSettings(claim.Namespace)
+	overCommittedResources := applyOverProvisioning(settings, availableResources)
 
-	// Calculate
-	freeResources := quota.SubtractWithNonNegativeResult(*overCommittedResources, *reservedResources)
+	for _, name := range utils.SortedResourceNames(claim.Spec) {
 
-	// Difference between the freeResources and the claim
-	// If diff is different than zero there is enough capacity to accept the claim
-	diff := quota.SubtractWithNonNegativeResult(freeResources, claim.Spec)
+		// Resources that are not provided by the nodes can not be evaluated
+		capacity, found := (*overCommittedResources)[utils.CapacityResourceName(name)]
+		if !found {
+			continue
+		}
 
-	// ResourceQuotaClaims cannot fit because of Memory
-	if diff.Memory().IsZero() {
-		return fmt.Sprintf(utils.MessageRejectedMemory,
-			claim.Spec.Memory().String(),
-			utils.BytesSize(float64(freeResources.Memory().Value())))
+		// ResourceQuotaClaims cannot fit because of this resource
+		free := freeCapacity(capacity, (*reservedResources)[utils.CapacityResourceName(name)])
+		claimed := claim.Spec[name]
+		if claimed.Cmp(free) > 0 {
+			return rejectedMessage(name, claimed, free)
+		}
 	}
 
-	// ResourceQuotaClaims cannot fit because of CPU
-	if diff.Cpu().IsZero() {
-		return fmt.Sprintf(utils.MessageRejectedCPU,
-			claim.Spec.Cpu().String(),
-			freeResources.Cpu().String())
+	return utils.EmptyMsg
+}
+
+// Return the amount of each resource missing to fit the claim, keyed by capacity resource name
+func (c *Controller) capacityShortfall(claim *cagipv1.ResourceQuotaClaim, availableResources *v1Core.ResourceList, reservedResources *v1Core.ResourceList) v1Core.ResourceList {
+	settings, _ := c.namespaceSettings(claim.Namespace)
+	overCommittedResources := applyOverProvisioning(settings, availableResources)
+
+	shortfall := v1Core.ResourceList{}
+	for name, claimed := range claim.Spec {
+		capacityName := utils.CapacityResourceName(name)
+		capacity, found := (*overCommittedResources)[capacityName]
+		if !found {
+			continue
+		}
+
+		missing := claimed.DeepCopy()
+		missing.Sub(freeCapacity(capacity, (*reservedResources)[capacityName]))
+		if missing.Sign() > 0 {
+			if previous, found := shortfall[capacityName]; !found || missing.Cmp(previous) > 0 {
+				shortfall[capacityName] = missing
+			}
+		}
 	}
+	return shortfall
+}
 
-	return utils.EmptyMsg
+// Capacity left once the reserved resources are removed, it is never negative
+func freeCapacity(capacity resource.Quantity, reserved resource.Quantity) resource.Quantity {
+	free := capacity.DeepCopy()
+	free.Sub(reserved)
+	if free.Sign() < 0 {
+		free = *resource.NewQuantity(0, capacity.Format)
+	}
+	return free
+}
+
+// Gather the nodes providing the cluster capacity
+func (c *Controller) workerNodes() ([]*v1Core.Node, error) {
+	nodeList, err := c.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Could not retrieve Nodes : %s", err)
+		return nil, err
+	}
+
+	var workerNodes []*v1Core.Node
+	excluded := map[string]int{}
+	for _, node := range nodeList {
+		if exclusion := c.settings.NodeExclusion(node); exclusion != "" {
+			klog.V(4).Infof("Node %s does not provide capacity : %s", node.Name, exclusion)
+			excluded[exclusion]++
+			continue
+		}
+		workerNodes = append(workerNodes, node)
+	}
+	if !c.capacityReadOnly {
+		c.reportCapacityNodes(nodeList)
+	}
+	if len(excluded) > 0 && !c.capacityReadOnly {
+		klog.Infof("Nodes not providing capacity : %v", excluded)
+	}
+	return workerNodes, nil
+}
+
+// Report every node, the ones left out carry the reason why
+func (c *Controller) reportCapacityNodes(nodes []*v1Core.Node) {
+	utils.CapacityNodesGauge.Reset()
+	for _, node := range nodes {
+		eligibility := c.settings.NodeExclusion(node)
+		if eligibility == "" {
+			eligibility = utils.NodeEligible
+			if c.settings.InMaintenance(node) {
+				eligibility = utils.NodeMaintenance
+			}
+		}
+		utils.CapacityNodesGauge.WithLabelValues(node.Name, eligibility).Set(1)
+	}
 }
 
 // Gather the nodes total capacity
 func (c *Controller) nodesTotalCapacity() (total *v1Core.ResourceList, err error) {
 
 	// Get worker Nodes
-	nodeList, err := c.nodeLister.List(labels.Everything())
+	workerNodes, err := c.workerNodes()
 	if err != nil {
-		klog.Errorf("Could not retrieve Nodes : %s", err)
 		return total, err
 	}
 
-	workerNodes := utils.FilterNodesWithPredicate(nodeList, utils.FilterWorkerNode())
-
-	// Only keep CPU and Memory
-	total = &v1Core.ResourceList{
-		v1Core.ResourceCPU:    *resource.NewMilliQuantity(utils.NodesCpuAllocatable(workerNodes), resource.DecimalSI),
-		v1Core.ResourceMemory: *resource.NewQuantity(utils.NodesMemAllocatable(workerNodes), resource.BinarySI),
-	}
+	// Sum every resource allocatable on the worker nodes
+	total = c.smoothedCapacity("", workerNodes)
 
 	klog.Infof("Found %d Worker Nodes : %s Memory %s CPU", len(workerNodes), total.Memory().String(), total.Cpu().String())
 
@@ -292,7 +671,15 @@ func (c *Controller) nodesTotalCapacity() (total *v1Core.ResourceList, err error
 }
 
 // Gather the total of resource quota except the one on the namespace being evaluated
+// Each namespace reserves the binding limits of its quotas, keyed by capacity resource name
+// A namespace bound to a node pool only shares it with the namespaces bound to the same pool
 func (c *Controller) totalResourceQuota(claim *cagipv1.ResourceQuotaClaim) (sumResourceQuota *v1Core.ResourceList, err error) {
+	return c.poolResourceQuota(c.namespacePool(claim.Namespace), claim.Namespace)
+}
+
+// Gather the total of resource quota of the namespaces sharing a node pool, the whole cluster when there is no pool
+// The quotas of the excluded namespace are not counted
+func (c *Controller) poolResourceQuota(pool *utils.NodePool, excluded string) (sumResourceQuota *v1Core.ResourceList, err error) {
 	sumResourceQuota = &v1Core.ResourceList{}
 	// Retrieve ResourceQuotas
 	if resourceQuotasAllNS, err := c.resourceQuotaLister.List(utils.DefaultLabelSelector()); err != nil {
@@ -300,16 +687,34 @@ func (c *Controller) totalResourceQuota(claim *cagipv1.ResourceQuotaClaim) (sumR
 		return sumResourceQuota, err
 
 	} else {
-		// Exclude the resource quota of the claim namespace and sum the resources
+		// The managed quotas just written replace the ones of the cache until the informer observes them
+		pending := c.reservations.pending(resourceQuotasAllNS, c.clock.Now())
+
+		// Exclude the resource quota of the claim namespace and group the others by namespace
+		// A scoped quota does not bind every pod of its namespace, it does not lower the limit of the others
+		hardsByNamespace := map[string][]v1Core.ResourceList{}
 		for _, resourceQuota := range resourceQuotasAllNS {
-			if resourceQuota.Namespace != claim.Namespace {
-				*sumResourceQuota = quota.Add(sumResourceQuota.DeepCopy(), resourceQuota.Spec.Hard.DeepCopy())
+			if _, found := pending[resourceQuota.Namespace]; (found && resourceQuota.Name == utils.ResourceQuotaName) || utils.IsScopedQuota(resourceQuota) {
+				continue
+			}
+			if resourceQuota.Namespace != excluded && c.sharesPool(pool, resourceQuota.Namespace) {
+				hardsByNamespace[resourceQuota.Namespace] = append(hardsByNamespace[resourceQuota.Namespace], resourceQuota.Spec.Hard)
+			}
+		}
+		for namespace, hard := range pending {
+			if namespace != excluded && c.sharesPool(pool, namespace) {
+				hardsByNamespace[namespace] = append(hardsByNamespace[namespace], hard)
 			}
 		}
 
+		// Several quotas of a namespace do not add up, the lowest one binds the pods
+		for _, hards := range hardsByNamespace {
+			*sumResourceQuota = quota.Add(*sumResourceQuota, utils.EffectiveQuotaHard(hards...))
+		}
+
 		klog.V(4).Infof(
-			"Found %d ResourceQuotas : %s Memory %s CPU",
-			len(*sumResourceQuota),
+			"Found ResourceQuotas on %d Namespaces : %s Memory %s CPU",
+			len(hardsByNamespace),
 			sumResourceQuota.Memory().String(),
 			sumResourceQuota.Cpu().String())
 
@@ -317,32 +722,160 @@ func (c *Controller) totalResourceQuota(claim *cagipv1.ResourceQuotaClaim) (sumR
 	}
 }
 
+// Gather the requests of the running pods of the namespaces without ResourceQuota, on the worker nodes
+// or on the nodes of the node pool of the namespace being evaluated
+// The namespace being evaluated is excluded, its pods are accounted by the claimed quota
+func (c *Controller) unquotedRequests(claim *cagipv1.ResourceQuotaClaim) (total *v1Core.ResourceList, err error) {
+	total = &v1Core.ResourceList{}
+
+	requestsByNamespace, err := c.unquotedNamespaceRequests(c.namespacePool(claim.Namespace), claim.Namespace)
+	if err != nil {
+		return total, err
+	}
+
+	// Namespaces that are not reported anymore must be removed from the metrics
+	utils.UnquotedRequestsGauge.Reset()
+	for namespace, requests := range requestsByNamespace {
+		klog.V(4).Infof("Namespace %s without ResourceQuota requests %s Memory %s CPU", namespace, requests.Memory().String(), requests.Cpu().String())
+		for name, quantity := range *requests {
+			utils.UnquotedRequestsGauge.WithLabelValues(namespace, string(name)).Set(quantity.AsApproximateFloat64())
+		}
+		*total = quota.Add(*total, *requests)
+	}
+
+	return total, nil
+}
+
+// Gather the requests of the running pods of each namespace without ResourceQuota, on the nodes of a node pool
+// or on the worker nodes when there is no pool
+// The pods of the excluded namespace are not counted
+func (c *Controller) unquotedNamespaceRequests(pool *utils.NodePool, excluded string) (map[string]*v1Core.ResourceList, error) {
+	resourceQuotas, err := c.resourceQuotaLister.List(utils.DefaultLabelSelector())
+	if err != nil {
+		klog.Errorf("Could not retrieve ResourceQuotas : %s", err)
+		return nil, err
+	}
+	// A namespace with scoped quotas only still runs pods outside of any quota
+	quoted := make(map[string]bool, len(resourceQuotas))
+	for _, resourceQuota := range resourceQuotas {
+		if !utils.IsScopedQuota(resourceQuota) {
+			quoted[resourceQuota.Namespace] = true
+		}
+	}
+	// The managed quotas written but not observed yet quote their namespace as well
+	for namespace := range c.reservations.pending(resourceQuotas, c.clock.Now()) {
+		quoted[namespace] = true
+	}
+
+	workerNodes, err := c.poolNodes(pool)
+	if err != nil {
+		return nil, err
+	}
+	onWorkerNode := make(map[string]bool, len(workerNodes))
+	for _, node := range workerNodes {
+		onWorkerNode[node.Name] = true
+	}
+
+	pods, err := c.podsLister.List(utils.DefaultLabelSelector())
+	if err != nil {
+		klog.Errorf("Could not retrieve Pods : %s", err)
+		return nil, err
+	}
+
+	podsByNamespace := map[string][]*v1Core.Pod{}
+	for _, pod := range utils.FilterRunningPods(pods) {
+		if pod.Namespace == excluded || quoted[pod.Namespace] || !onWorkerNode[pod.Spec.NodeName] || !c.settings.ReservesUnquotedNamespace(pod.Namespace) {
+			continue
+		}
+		podsByNamespace[pod.Namespace] = append(podsByNamespace[pod.Namespace], pod)
+	}
+
+	requestsByNamespace := make(map[string]*v1Core.ResourceList, len(podsByNamespace))
+	for namespace, namespacePods := range podsByNamespace {
+		requestsByNamespace[namespace] = utils.TotalRequestNS(namespacePods)
+	}
+
+	return requestsByNamespace, nil
+}
+
+// Log and expose the capacity reserved by the quotas and by the namespaces without ResourceQuota
+func reportReservedCapacity(quotaResources *v1Core.ResourceList, unquotedResources *v1Core.ResourceList) {
+	klog.Infof("Reserved capacity : %s Memory %s CPU by ResourceQuotas, %s Memory %s CPU by Namespaces without ResourceQuota",
+		quotaResources.Memory().String(), quotaResources.Cpu().String(),
+		unquotedResources.Memory().String(), unquotedResources.Cpu().String())
+
+	utils.ReservedCapacityGauge.Reset()
+	for name, quantity := range *quotaResources {
+		utils.ReservedCapacityGauge.WithLabelValues("quota", string(name)).Set(quantity.AsApproximateFloat64())
+	}
+	for name, quantity := range *unquotedResources {
+		utils.ReservedCapacityGauge.WithLabelValues("unquoted", string(name)).Set(quantity.AsApproximateFloat64())
+	}
+}
+
 // Check is the managed quota is scaling down
 func isDownscaleQuota(claim *cagipv1.ResourceQuotaClaim, managedQuota *v1Core.ResourceQuota) bool {
-	return claim.Spec.Cpu().MilliValue() < managedQuota.Spec.Hard.Cpu().MilliValue() ||
-		claim.Spec.Memory().Value() < managedQuota.Spec.Hard.Memory().Value()
+	for name, claimed := range claim.Spec {
+		if current, found := managedQuota.Spec.Hard[name]; found && claimed.Cmp(current) < 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // Check that it is possible to scale down the quota
 // Return an empty message if possible else
 // Return the reason
 func canDownscaleQuota(claim *cagipv1.ResourceQuotaClaim, totalRequest *v1Core.ResourceList) string {
-	if totalRequest.Memory().Value() > claim.Spec.Memory().Value() {
-		return fmt.Sprintf(
-			utils.MessagePendingMemoryDownscale,
-			utils.BytesSize(float64(claim.Spec.Memory().Value())),
-			utils.BytesSize(float64(totalRequest.Memory().Value())))
+	for _, name := range utils.SortedResourceNames(claim.Spec) {
+		requested, found := (*totalRequest)[name]
+		if !found {
+			requested, found = (*totalRequest)[utils.CapacityResourceName(name)]
+		}
+		if !found {
+			continue
+		}
 
+		claimed := claim.Spec[name]
+		if requested.Cmp(claimed) > 0 {
+			return pendingDownscaleMessage(name, claimed, requested)
+		}
 	}
 
-	if totalRequest.Cpu().MilliValue() > claim.Spec.Cpu().MilliValue() {
-		return fmt.Sprintf(
-			utils.MessagePendingCpuDownscale,
-			claim.Spec.Cpu().String(),
-			totalRequest.Cpu().String())
+	return utils.EmptyMsg
+}
+
+// Format the message of a claim exceeding the allocation limit of a resource
+func allocationLimitMessage(name v1Core.ResourceName, claimed resource.Quantity, limit resource.Quantity) string {
+	switch name {
+	case v1Core.ResourceMemory:
+		return fmt.Sprintf(utils.MessageMemoryAllocationLimit, claimed.String(), utils.BytesSize(float64(limit.Value())))
+	case v1Core.ResourceCPU:
+		return fmt.Sprintf(utils.MessageCpuAllocationLimit, claimed.String(), limit.String())
 	}
+	return fmt.Sprintf(utils.MessageResourceAllocationLimit, name, claimed.String(), limit.String())
+}
 
-	return utils.EmptyMsg
+// Format the message of a claim that does not fit in the free capacity of a resource
+func rejectedMessage(name v1Core.ResourceName, claimed resource.Quantity, free resource.Quantity) string {
+	switch name {
+	case v1Core.ResourceMemory:
+		return fmt.Sprintf(utils.MessageRejectedMemory, claimed.String(), utils.BytesSize(float64(free.Value())))
+	case v1Core.ResourceCPU:
+		return fmt.Sprintf(utils.MessageRejectedCPU, claimed.String(), free.String())
+	}
+	return fmt.Sprintf(utils.MessageRejectedResource, name, claimed.String(), free.String())
+}
+
+// Format the message of a downscale awaiting a lower consumption of a resource
+func pendingDownscaleMessage(name v1Core.ResourceName, claimed resource.Quantity, requested resource.Quantity) string {
+	switch name {
+	case v1Core.ResourceMemory:
+		return fmt.Sprintf(utils.MessagePendingMemoryDownscale, utils.BytesSize(float64(claimed.Value())), utils.BytesSize(float64(requested.Value())))
+	case v1Core.ResourceCPU:
+		return fmt.Sprintf(utils.MessagePendingCpuDownscale, claimed.String(), requested.String())
+	}
+	return fmt.Sprintf(utils.MessagePendingResourceDownscale, name, claimed.String(), requested.String())
 }
 
 // Delete a ResourceQuotaClaims
@@ -351,11 +884,18 @@ func (c *Controller) deleteResourceQuotaClaim(claim *cagipv1.ResourceQuotaClaim)
 }
 
 // Create a ResourceQuota from a ResourceQuotaClaim resource.
+// Build the managed quota of a claim, recording its spec as the accepted one
+func (c *Controller) newManagedQuota(claim *cagipv1.ResourceQuotaClaim) *v1Core.ResourceQuota {
+	resourceQuota := newResourceQuota(claim)
+	c.annotateAcceptedSpec(resourceQuota)
+	return resourceQuota
+}
+
 func newResourceQuota(claim *cagipv1.ResourceQuotaClaim) *v1Core.ResourceQuota {
 	labels := map[string]string{
 		"creator": utils.ControllerName,
 	}
-	return &v1Core.ResourceQuota{
+	resourceQuota := &v1Core.ResourceQuota{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      utils.ResourceQuotaName,
 			Namespace: claim.Namespace,
@@ -365,4 +905,5 @@ func newResourceQuota(claim *cagipv1.ResourceQuotaClaim) *v1Core.ResourceQuota {
 			Hard: quota.Add(v1Core.ResourceList{}, claim.Spec),
 		},
 	}
+	return resourceQuota
 }
@@ -2,14 +2,17 @@ package controller
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ca-gip/kotary/internal/utils"
 	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
 	"github.com/storageos/go-api/types"
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	quota "k8s.io/apiserver/pkg/quota/v1"
 )
 
 func TestApplyOverProvisioning(t *testing.T) {
@@ -73,7 +76,7 @@ func TestApplyOverProvisioning(t *testing.T) {
 			c.settings.RatioOverCommitMemory = testCase.overcommit
 			c.settings.RatioOverCommitCPU = testCase.overcommit
 
-			result := c.applyOverProvisioning(testCase.given)
+			result := applyOverProvisioning(c.settings, testCase.given)
 
 			assert.Equal(t, testCase.expect.Cpu().MilliValue(), result.Cpu().MilliValue())
 			assert.Equal(t, testCase.expect.Memory().MilliValue(), result.Memory().MilliValue())
@@ -81,6 +84,27 @@ func TestApplyOverProvisioning(t *testing.T) {
 	}
 }
 
+func TestApplyOverProvisioningResourcePolicies(t *testing.T) {
+
+	f := newFixture(t)
+	c, _, _, _, _, _ := f.newController()
+	c.settings.RatioOverCommitCPU = 2
+	c.settings.ResourcePolicies = map[v1.ResourceName]utils.ResourcePolicy{
+		v1.ResourceEphemeralStorage: {RatioMaxAllocation: 1, RatioOverCommit: 1.5},
+	}
+
+	result := applyOverProvisioning(c.settings, &v1.ResourceList{
+		v1.ResourceCPU:              resource.MustParse("4"),
+		v1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+		"nvidia.com/gpu":            resource.MustParse("4"),
+	})
+
+	assert.Equal(t, result.Cpu().MilliValue(), int64(8000))
+	assert.Equal(t, result.StorageEphemeral().Value(), int64(150*1024*1024*1024))
+	gpu := (*result)["nvidia.com/gpu"]
+	assert.Equal(t, gpu.Value(), int64(4))
+}
+
 func TestCheckAllocationLimit(t *testing.T) {
 
 	TestCases := map[string]struct {
@@ -421,6 +445,93 @@ func TestCheckResourceFit(t *testing.T) {
 	}
 }
 
+func TestCheckResourcePolicies(t *testing.T) {
+
+	availableResources := &v1.ResourceList{
+		v1.ResourceCPU:              resource.MustParse("10"),
+		v1.ResourceMemory:           resource.MustParse("10Gi"),
+		v1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+		"nvidia.com/gpu":            resource.MustParse("4"),
+	}
+
+	TestCases := map[string]struct {
+		claim             *v1.ResourceList
+		reservedResources *v1.ResourceList
+		expectLimitMsg    string
+		expectFitMsg      string
+	}{
+		"gpu under the allocation limit should pass": {
+			claim: &v1.ResourceList{
+				"requests.nvidia.com/gpu": resource.MustParse("2"),
+			},
+			reservedResources: &v1.ResourceList{},
+			expectLimitMsg:    utils.EmptyMsg,
+			expectFitMsg:      utils.EmptyMsg,
+		},
+		"gpu over the allocation limit should fail": {
+			claim: &v1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("3"),
+			},
+			reservedResources: &v1.ResourceList{},
+			expectLimitMsg:    "Exceeded nvidia.com/gpu allocation limit claiming 3 but limited to 2",
+			expectFitMsg:      utils.EmptyMsg,
+		},
+		"gpu should not be over-committed": {
+			claim: &v1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("2"),
+			},
+			reservedResources: &v1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("3"),
+			},
+			expectLimitMsg: utils.EmptyMsg,
+			expectFitMsg:   "Not enough nvidia.com/gpu claiming 2 but 1 currently available",
+		},
+		"gpu claimed on requests name should be checked against the reserved gpu": {
+			claim: &v1.ResourceList{
+				"requests.nvidia.com/gpu": resource.MustParse("2"),
+			},
+			reservedResources: &v1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("3"),
+			},
+			expectLimitMsg: utils.EmptyMsg,
+			expectFitMsg:   "Not enough requests.nvidia.com/gpu claiming 2 but 1 currently available",
+		},
+		"ephemeral-storage should be over-committed": {
+			claim: &v1.ResourceList{
+				v1.ResourceEphemeralStorage: resource.MustParse("50Gi"),
+			},
+			reservedResources: &v1.ResourceList{
+				v1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+			},
+			expectLimitMsg: utils.EmptyMsg,
+			expectFitMsg:   utils.EmptyMsg,
+		},
+		"resource without capacity should not be evaluated": {
+			claim: &v1.ResourceList{
+				v1.ResourceServices: resource.MustParse("1k"),
+			},
+			reservedResources: &v1.ResourceList{},
+			expectLimitMsg:    utils.EmptyMsg,
+			expectFitMsg:      utils.EmptyMsg,
+		},
+	}
+
+	for testName, testCase := range TestCases {
+		t.Run(testName, func(t *testing.T) {
+			f := newFixture(t)
+			c, _, _, _, _, _ := f.newController()
+			c.settings.ResourcePolicies = map[v1.ResourceName]utils.ResourcePolicy{
+				"nvidia.com/gpu":            {RatioMaxAllocation: 0.5, RatioOverCommit: 2, NoOverCommit: true},
+				v1.ResourceEphemeralStorage: {RatioMaxAllocation: 1, RatioOverCommit: 1.5},
+			}
+			claim := newTestResourceQuotaClaim("test", testCase.claim)
+
+			assert.Equal(t, c.checkAllocationLimit(claim, availableResources), testCase.expectLimitMsg)
+			assert.Equal(t, c.checkResourceFit(claim, availableResources, testCase.reservedResources), testCase.expectFitMsg)
+		})
+	}
+}
+
 func TestNodesTotalCapacity(t *testing.T) {
 
 	testCases := map[string]struct {
@@ -796,6 +907,100 @@ func TestTotalResourceQuota(t *testing.T) {
 				v1.ResourceCPU:    resource.MustParse("3k"),
 			},
 		},
+		"quota on requests names": {
+			claim: &cagipv1.ResourceQuotaClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: types.DefaultNamespace,
+				},
+			},
+			quotas: []*v1.ResourceQuota{
+				newTestResourceQuota("test-01", "handwritten", &v1.ResourceList{
+					v1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+					v1.ResourceRequestsCPU:    resource.MustParse("2"),
+				}),
+				newTestResourceQuota("test-02", utils.ResourceQuotaName, &v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("8Gi"),
+					v1.ResourceCPU:    resource.MustParse("3"),
+				}),
+			},
+			expect: &v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse("12Gi"),
+				v1.ResourceCPU:    resource.MustParse("5"),
+			},
+		},
+		"several quotas on a namespace should reserve the binding limit": {
+			claim: &cagipv1.ResourceQuotaClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: types.DefaultNamespace,
+				},
+			},
+			quotas: []*v1.ResourceQuota{
+				newTestResourceQuota("test-01", utils.ResourceQuotaName, &v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("8Gi"),
+					v1.ResourceCPU:    resource.MustParse("3"),
+				}),
+				newTestResourceQuota("test-01", "handwritten", &v1.ResourceList{
+					v1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+					v1.ResourceRequestsCPU:    resource.MustParse("5"),
+					v1.ResourcePods:           resource.MustParse("10"),
+				}),
+			},
+			expect: &v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+				v1.ResourceCPU:    resource.MustParse("3"),
+				v1.ResourcePods:   resource.MustParse("10"),
+			},
+		},
+		"scoped quota should not lower the binding limit": {
+			claim: &cagipv1.ResourceQuotaClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: types.DefaultNamespace,
+				},
+			},
+			quotas: []*v1.ResourceQuota{
+				newTestResourceQuota("test-01", utils.ResourceQuotaName, &v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("8Gi"),
+					v1.ResourceCPU:    resource.MustParse("3"),
+				}),
+				newTestScopedResourceQuota("test-01", "best-effort", []v1.ResourceQuotaScope{v1.ResourceQuotaScopeBestEffort}, nil, &v1.ResourceList{
+					v1.ResourcePods: resource.MustParse("0"),
+				}),
+				newTestScopedResourceQuota("test-01", "low-priority", nil, &v1.ScopeSelector{
+					MatchExpressions: []v1.ScopedResourceSelectorRequirement{{
+						ScopeName: v1.ResourceQuotaScopePriorityClass,
+						Operator:  v1.ScopeSelectorOpIn,
+						Values:    []string{"low"},
+					}},
+				}, &v1.ResourceList{
+					v1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+					v1.ResourceRequestsCPU:    resource.MustParse("500m"),
+				}),
+			},
+			expect: &v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+				v1.ResourceCPU:    resource.MustParse("3"),
+			},
+		},
+		"limits should be reserved apart from requests": {
+			claim: &cagipv1.ResourceQuotaClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: types.DefaultNamespace,
+				},
+			},
+			quotas: []*v1.ResourceQuota{
+				newTestResourceQuota("test-01", utils.ResourceQuotaName, &v1.ResourceList{
+					v1.ResourceRequestsCPU: resource.MustParse("2"),
+					v1.ResourceLimitsCPU:   resource.MustParse("1"),
+				}),
+				newTestResourceQuota("test-02", utils.ResourceQuotaName, &v1.ResourceList{
+					v1.ResourceLimitsCPU: resource.MustParse("4"),
+				}),
+			},
+			expect: &v1.ResourceList{
+				v1.ResourceCPU:       resource.MustParse("2"),
+				v1.ResourceLimitsCPU: resource.MustParse("5"),
+			},
+		},
 	}
 
 	for testName, testCase := range testCases {
@@ -811,6 +1016,7 @@ func TestTotalResourceQuota(t *testing.T) {
 			assert.NilError(t, err)
 			assert.Equal(t, result.Cpu().Value(), testCase.expect.Cpu().Value())
 			assert.Equal(t, result.Memory().Value(), testCase.expect.Memory().Value())
+			assert.Assert(t, quota.Equals(*result, *testCase.expect), "reserved %v, expected %v", *result, *testCase.expect)
 		})
 	}
 }
@@ -901,6 +1107,23 @@ func TestIsDownscaleQuota(t *testing.T) {
 			},
 			expect: true,
 		},
+		"claiming less GPU than the managed quota should return true": {
+			claim: &cagipv1.ResourceQuotaClaim{
+				Spec: v1.ResourceList{
+					v1.ResourceCPU:   resource.MustParse("1"),
+					"nvidia.com/gpu": resource.MustParse("1"),
+				},
+			},
+			managedQuota: &v1.ResourceQuota{
+				Spec: v1.ResourceQuotaSpec{
+					Hard: v1.ResourceList{
+						v1.ResourceCPU:   resource.MustParse("1"),
+						"nvidia.com/gpu": resource.MustParse("2"),
+					},
+				},
+			},
+			expect: true,
+		},
 		"empty resources should return false": {
 			claim:        &cagipv1.ResourceQuotaClaim{},
 			managedQuota: &v1.ResourceQuota{},
@@ -975,6 +1198,19 @@ func TestCanDownscaleQuota(t *testing.T) {
 			},
 			expect: "Awaiting lower Memory consumption claiming 900Mi but current total of request is 1Gi",
 		},
+		"claiming less GPU than the total of request should return GPU msg": {
+			claim: &cagipv1.ResourceQuotaClaim{
+				Spec: v1.ResourceList{
+					v1.ResourceCPU:            resource.MustParse("1"),
+					"requests.nvidia.com/gpu": resource.MustParse("1"),
+				},
+			},
+			totalRequest: &v1.ResourceList{
+				v1.ResourceCPU:   resource.MustParse("1"),
+				"nvidia.com/gpu": resource.MustParse("2"),
+			},
+			expect: "Awaiting lower requests.nvidia.com/gpu consumption claiming 1 but current total of request is 2",
+		},
 	}
 
 	for testName, testCase := range testCases {
@@ -985,3 +1221,42 @@ func TestCanDownscaleQuota(t *testing.T) {
 	}
 
 }
+
+func TestNewResourceQuotaClaimStatus(t *testing.T) {
+
+	claim := &cagipv1.ResourceQuotaClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  metav1.NamespaceDefault,
+			Generation: 2,
+		},
+	}
+	rejectedTime := metav1.NewTime(time.Date(2020, time.January, 24, 8, 0, 0, 0, time.UTC))
+	acceptedTime := metav1.NewTime(time.Date(2020, time.January, 24, 9, 0, 0, 0, time.UTC))
+
+	t.Run("rejected claim should be evaluated but not accepted", func(t *testing.T) {
+		status := newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity, "Not enough CPU", rejectedTime)
+
+		assert.Equal(t, status.Reason, cagipv1.ReasonInsufficientCapacity)
+		assert.Equal(t, status.ObservedGeneration, int64(2))
+		assert.Equal(t, *status.LastEvaluationTime, rejectedTime)
+		assert.Assert(t, meta.IsStatusConditionTrue(status.Conditions, cagipv1.ConditionEvaluated))
+		assert.Assert(t, meta.IsStatusConditionFalse(status.Conditions, cagipv1.ConditionAccepted))
+		assert.Assert(t, meta.IsStatusConditionFalse(status.Conditions, cagipv1.ConditionApplied))
+		assert.Equal(t, meta.FindStatusCondition(status.Conditions, cagipv1.ConditionAccepted).Message, "Not enough CPU")
+	})
+
+	t.Run("accepted claim should keep the transition time of unchanged conditions", func(t *testing.T) {
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity, "Not enough CPU", rejectedTime)
+
+		status := newResourceQuotaClaimStatus(rejectedClaim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, acceptedTime)
+
+		assert.Equal(t, len(status.Conditions), 3)
+		assert.Equal(t, *status.LastEvaluationTime, acceptedTime)
+		assert.Equal(t, meta.FindStatusCondition(status.Conditions, cagipv1.ConditionEvaluated).LastTransitionTime, rejectedTime)
+		assert.Assert(t, meta.IsStatusConditionTrue(status.Conditions, cagipv1.ConditionAccepted))
+		assert.Equal(t, meta.FindStatusCondition(status.Conditions, cagipv1.ConditionAccepted).LastTransitionTime, acceptedTime)
+		assert.Assert(t, meta.IsStatusConditionTrue(status.Conditions, cagipv1.ConditionApplied))
+	})
+}
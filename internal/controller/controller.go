@@ -2,16 +2,20 @@ package controller
 
 import (
 	"fmt"
+	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/ca-gip/kotary/internal/utils"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
 	clientset "github.com/ca-gip/kotary/pkg/generated/clientset/versioned"
@@ -34,6 +38,8 @@ type Controller struct {
 	resourcequotaclientset      kubernetes.Interface
 	podsclientset               kubernetes.Interface
 	resourcequotaclaimclientset clientset.Interface
+	// read the custom resources of the capacity providers, nil when it is not available
+	dynamicclientset dynamic.Interface
 
 	// ns
 	namespaceLister  corelisters.NamespaceLister
@@ -54,20 +60,76 @@ type Controller struct {
 	resourceQuotaClaimLister listers.ResourceQuotaClaimLister
 	resourceQuotaClaimSynced cache.InformerSynced
 
+	// scheduledquotaclaim
+	scheduledQuotaClaimLister listers.ScheduledQuotaClaimLister
+	scheduledQuotaClaimSynced cache.InformerSynced
+
+	// quotapolicy
+	quotaPolicyLister listers.QuotaPolicyLister
+	quotaPolicySynced cache.InformerSynced
+
+	// quotacapacity
+	quotaCapacityLister listers.QuotaCapacityLister
+	quotaCapacitySynced cache.InformerSynced
+
+	// configmap holding the settings when there is no QuotaPolicy, nil when it is not watched
+	configMapLister corelisters.ConfigMapLister
+	configMapSynced cache.InformerSynced
+
 	// resourceQuotaClaimWorkQueue and namespaceWorkQueue are a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
 	// time, and makes it easy to ensure we are never processing the same item
 	// simultaneously in two different workers.
-	resourceQuotaClaimWorkQueue workqueue.RateLimitingInterface
-	namespaceWorkQueue          workqueue.RateLimitingInterface
+	resourceQuotaClaimWorkQueue  workqueue.RateLimitingInterface
+	namespaceWorkQueue           workqueue.RateLimitingInterface
+	scheduledQuotaClaimWorkQueue workqueue.RateLimitingInterface
+	quotaPolicyWorkQueue         workqueue.RateLimitingInterface
+	// Managed quotas edited or deleted outside of the controller
+	resourceQuotaWorkQueue workqueue.RateLimitingInterface
+	// Single key, the capacity status is refreshed once for a burst of changes
+	quotaCapacityWorkQueue workqueue.RateLimitingInterface
 
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
 
-	// Settings
+	// Settings used by a sync, it is a snapshot of the current settings taken when the sync starts
 	settings utils.Config
+	// Settings currently applied, swapped when the configuration is reloaded
+	currentSettings *atomic.Pointer[utils.Config]
+
+	// clock used to timestamp the claim evaluations
+	clock clock.Clock
+
+	// Managed quotas written by the controller that the informer may not have observed yet
+	reservations *reservationLedger
+
+	// Specs of the managed quotas accepted by the controller, restored when they drift
+	acceptedSpecs *acceptedSpecLedger
+	// Set while the replica leads, the standby ones do not repair the drifts
+	leading *atomic.Bool
+
+	// Key signing the approval decisions recorded by the claim webhook and the accepted specs of the managed quotas
+	approvalKey []byte
+	// Set when the claim webhook recording the approval decisions is served
+	approvalWebhook bool
+
+	// Capacity of the nodes seen over the smoothing window
+	capacityHistory *capacityHistory
+
+	// Capacity last returned by the live providers
+	capacityCache *capacityCache
+
+	// Claims collected during the batching window, to be admitted in priority order
+	batch *admissionBatch
+	// Claims being evaluated, by a worker or by the admission of a batch
+	evaluating *claimGuard
+	// Set on the snapshot admitting a batch, its claims are evaluated right away
+	admitting bool
+	// Set on the snapshot of the webhook, the capacity is read without updating the metrics,
+	// recording a smoothing sample or calling the live providers
+	capacityReadOnly bool
 }
 
 // NewController returns a new resourcequotaclaim controller
@@ -78,11 +140,16 @@ func NewController(
 	nodesclientset kubernetes.Interface,
 	podsclientset kubernetes.Interface,
 	resourcequotaclaimclientset clientset.Interface,
+	dynamicclientset dynamic.Interface,
 	namespaceInformer coreinformers.NamespaceInformer,
 	resourceQuotaInformer coreinformers.ResourceQuotaInformer,
 	nodesInformer coreinformers.NodeInformer,
 	podsInformer coreinformers.PodInformer,
-	resourceQuotaClaimInformer informers.ResourceQuotaClaimInformer) *Controller {
+	resourceQuotaClaimInformer informers.ResourceQuotaClaimInformer,
+	scheduledQuotaClaimInformer informers.ScheduledQuotaClaimInformer,
+	quotaPolicyInformer informers.QuotaPolicyInformer,
+	quotaCapacityInformer informers.QuotaCapacityInformer,
+	configMapInformer coreinformers.ConfigMapInformer) *Controller {
 
 	// Create event broadcaster
 	// Add resourcequotaclaim-controller types to the default Kubernetes Scheme so Events can be
@@ -95,26 +162,47 @@ func NewController(
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: utils.ControllerName})
 
 	controller := &Controller{
-		namespaceclientset:          namespaceclientset,
-		resourcequotaclientset:      resourcequotaclientset,
-		nodesclientset:              nodesclientset,
-		podsclientset:               podsclientset,
-		resourcequotaclaimclientset: resourcequotaclaimclientset,
-		namespaceLister:             namespaceInformer.Lister(),
-		namespacesSynced:            namespaceInformer.Informer().HasSynced,
-		resourceQuotaLister:         resourceQuotaInformer.Lister(),
-		resourceQuotaSynced:         resourceQuotaInformer.Informer().HasSynced,
-		nodeLister:                  nodesInformer.Lister(),
-		nodesSynced:                 nodesInformer.Informer().HasSynced,
-		podsLister:                  podsInformer.Lister(),
-		podsSynced:                  podsInformer.Informer().HasSynced,
-		resourceQuotaClaimLister:    resourceQuotaClaimInformer.Lister(),
-		resourceQuotaClaimSynced:    resourceQuotaClaimInformer.Informer().HasSynced,
-		resourceQuotaClaimWorkQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ResourceQuotaClaims"),
-		namespaceWorkQueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Namespaces"),
-		recorder:                    recorder,
-		settings:                    settings,
+		namespaceclientset:           namespaceclientset,
+		resourcequotaclientset:       resourcequotaclientset,
+		nodesclientset:               nodesclientset,
+		podsclientset:                podsclientset,
+		resourcequotaclaimclientset:  resourcequotaclaimclientset,
+		dynamicclientset:             dynamicclientset,
+		namespaceLister:              namespaceInformer.Lister(),
+		namespacesSynced:             namespaceInformer.Informer().HasSynced,
+		resourceQuotaLister:          resourceQuotaInformer.Lister(),
+		resourceQuotaSynced:          resourceQuotaInformer.Informer().HasSynced,
+		nodeLister:                   nodesInformer.Lister(),
+		nodesSynced:                  nodesInformer.Informer().HasSynced,
+		podsLister:                   podsInformer.Lister(),
+		podsSynced:                   podsInformer.Informer().HasSynced,
+		resourceQuotaClaimLister:     resourceQuotaClaimInformer.Lister(),
+		resourceQuotaClaimSynced:     resourceQuotaClaimInformer.Informer().HasSynced,
+		scheduledQuotaClaimLister:    scheduledQuotaClaimInformer.Lister(),
+		scheduledQuotaClaimSynced:    scheduledQuotaClaimInformer.Informer().HasSynced,
+		quotaPolicyLister:            quotaPolicyInformer.Lister(),
+		quotaPolicySynced:            quotaPolicyInformer.Informer().HasSynced,
+		quotaCapacityLister:          quotaCapacityInformer.Lister(),
+		quotaCapacitySynced:          quotaCapacityInformer.Informer().HasSynced,
+		resourceQuotaClaimWorkQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ResourceQuotaClaims"),
+		namespaceWorkQueue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Namespaces"),
+		scheduledQuotaClaimWorkQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ScheduledQuotaClaims"),
+		quotaPolicyWorkQueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "QuotaPolicies"),
+		resourceQuotaWorkQueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ResourceQuotas"),
+		quotaCapacityWorkQueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "QuotaCapacities"),
+		recorder:                     recorder,
+		settings:                     settings,
+		currentSettings:              &atomic.Pointer[utils.Config]{},
+		clock:                        clock.RealClock{},
+		reservations:                 newReservationLedger(),
+		acceptedSpecs:                newAcceptedSpecLedger(),
+		leading:                      &atomic.Bool{},
+		capacityCache:                newCapacityCache(),
+		capacityHistory:              newCapacityHistory(),
+		batch:                        newAdmissionBatch(),
+		evaluating:                   newClaimGuard(),
 	}
+	controller.currentSettings.Store(&settings)
 
 	klog.Info("Setting up event handlers")
 	// Set up an event handler for claim creation and
@@ -128,20 +216,120 @@ func NewController(
 			if newQuotaClaim.ResourceVersion == oldQuotaClaim.ResourceVersion {
 				return
 			}
+			// Skip the status updates made by the controller, the spec did not change
+			if newQuotaClaim.Generation == oldQuotaClaim.Generation && !reflect.DeepEqual(newQuotaClaim.Status, oldQuotaClaim.Status) {
+				return
+			}
 			controller.enqueueResourceQuotaClaim(new)
 		},
+		DeleteFunc: controller.handleClaimDelete,
 	})
 
+	// A deleted namespace releases the capacity reserved by its quotas and pods
 	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueNamespace,
 		UpdateFunc: func(old, new interface{}) {
 			klog.Infof("============= Namespace Informer is invoqued =============")
 			controller.enqueueNamespace(new)
+			// The labels of a namespace may bind it to another node pool
+			controller.enqueueCapacityStatus()
+		},
+		DeleteFunc: func(obj interface{}) {
+			controller.requeueWaitingClaims()
+			controller.enqueueCapacityStatus()
+		},
+	})
+
+	// Quotas lowered or removed and nodes added release capacity for the claims waiting for it
+	// The managed quotas that drifted from their accepted spec are restored
+	resourceQuotaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			controller.enqueueQuotaDrift(obj)
+			controller.enqueueCapacityStatus()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleResourceQuotaUpdate(old, new)
+			controller.enqueueQuotaDrift(new)
+			controller.enqueueCapacityStatus()
+		},
+		DeleteFunc: func(obj interface{}) {
+			controller.requeueWaitingClaims()
+			controller.enqueueQuotaDeletion(obj)
+			controller.enqueueCapacityStatus()
+		},
+	})
+	nodesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			controller.requeueWaitingClaims()
+			controller.enqueueCapacityStatus()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleNodeChange(old, new)
+			controller.handleNodeUpdate(old, new)
+			controller.enqueueCapacityStatus()
+		},
+		DeleteFunc: func(obj interface{}) {
+			controller.handleNodeChange(obj, nil)
+			controller.enqueueCapacityStatus()
+		},
+	})
+
+	// The QuotaCapacity is created again when it is deleted
+	quotaCapacityInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			controller.enqueueCapacityStatus()
+		},
+	})
+
+	// Set up an event handler for scheduled claims, the status updates made by the controller are skipped
+	scheduledQuotaClaimInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueScheduledQuotaClaim,
+		UpdateFunc: func(old, new interface{}) {
+			newScheduled := new.(*cagipv1.ScheduledQuotaClaim)
+			oldScheduled := old.(*cagipv1.ScheduledQuotaClaim)
+			if newScheduled.ResourceVersion == oldScheduled.ResourceVersion {
+				return
+			}
+			if newScheduled.Generation == oldScheduled.Generation && !reflect.DeepEqual(newScheduled.Status, oldScheduled.Status) {
+				return
+			}
+			controller.enqueueScheduledQuotaClaim(new)
 		},
 	})
 
-	//Set up an event handler for pod deletions to handle changes in Resource Used
+	// Set up an event handler for policies, the status updates made by the controller are skipped
+	quotaPolicyInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueQuotaPolicy,
+		UpdateFunc: func(old, new interface{}) {
+			newPolicy := new.(*cagipv1.QuotaPolicy)
+			oldPolicy := old.(*cagipv1.QuotaPolicy)
+			if newPolicy.ResourceVersion == oldPolicy.ResourceVersion {
+				return
+			}
+			if newPolicy.Generation == oldPolicy.Generation && !reflect.DeepEqual(newPolicy.Status, oldPolicy.Status) {
+				return
+			}
+			controller.enqueueQuotaPolicy(new)
+		},
+		DeleteFunc: controller.enqueueQuotaPolicy,
+	})
+
+	// The ConfigMap is watched to reload the settings when there is no valid QuotaPolicy
+	if configMapInformer != nil {
+		controller.configMapLister = configMapInformer.Lister()
+		controller.configMapSynced = configMapInformer.Informer().HasSynced
+		configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: controller.enqueueSettingsReload,
+			UpdateFunc: func(old, new interface{}) {
+				controller.enqueueSettingsReload(new)
+			},
+			DeleteFunc: controller.enqueueSettingsReload,
+		})
+	}
+
+	//Set up an event handler for pod updates and deletions to handle changes in Resource Used
 	podsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: controller.handlePodUpdate,
 		DeleteFunc: func(obj interface{}) {
 			klog.Infof("============= Pods informer is invoqued (delete) =============")
 			controller.handlePod(obj)
@@ -180,16 +368,35 @@ func (c *Controller) SharedInformersState() error {
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
 	defer c.resourceQuotaClaimWorkQueue.ShutDown()
+	defer c.namespaceWorkQueue.ShutDown()
+	defer c.scheduledQuotaClaimWorkQueue.ShutDown()
+	defer c.quotaPolicyWorkQueue.ShutDown()
+	defer c.resourceQuotaWorkQueue.ShutDown()
+	defer c.quotaCapacityWorkQueue.ShutDown()
 
 	// Start the informer factories to begin populating the informer caches
 	klog.Info("Starting ResourceQuotaClaim controller")
 
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.namespacesSynced, c.resourceQuotaSynced, c.nodesSynced, c.podsSynced, c.resourceQuotaClaimSynced); !ok {
+	cachesSynced := []cache.InformerSynced{c.namespacesSynced, c.resourceQuotaSynced, c.nodesSynced, c.podsSynced, c.resourceQuotaClaimSynced, c.scheduledQuotaClaimSynced, c.quotaPolicySynced, c.quotaCapacitySynced}
+	if c.configMapSynced != nil {
+		cachesSynced = append(cachesSynced, c.configMapSynced)
+	}
+	if ok := cache.WaitForCacheSync(stopCh, cachesSynced...); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	// The claims awaiting approval could never be decided on
+	c.checkApprovalWebhook(c.currentSettings.Load())
+
+	// The drifts that happened before the replica leads are repaired
+	c.leading.Store(true)
+	defer c.leading.Store(false)
+	if err := c.requeueQuotaDrifts(); err != nil {
+		return err
+	}
+
 	klog.Info("Starting workers")
 
 	// Launch at least two workers one for the claim the other for namespace
@@ -198,6 +405,18 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 		go wait.Until(c.runWorkerNS, time.Second, stopCh)
 	}
 
+	// Scheduled claims only emit claims, a single worker is enough
+	go wait.Until(c.runWorkerSchedule, time.Second, stopCh)
+	// Policies are handled by a single worker, the settings reloads never overlap
+	go wait.Until(c.runWorkerPolicy, time.Second, stopCh)
+	// Drifts are rare, a single worker is enough
+	go wait.Until(c.runWorkerQuota, time.Second, stopCh)
+	// The capacity status has a single key, a single worker is enough
+	c.quotaCapacityWorkQueue.Add(cagipv1.QuotaCapacityName)
+	go wait.Until(c.runWorkerCapacity, time.Second, stopCh)
+	// The smoothing history is sampled between the evaluations as well
+	go wait.Until(func() { c.withSettings().recordCapacitySamples() }, capacitySamplePeriod, stopCh)
+
 	klog.Info("Started workers")
 	<-stopCh
 	klog.Info("Shutting down workers")
@@ -217,6 +436,26 @@ func (c *Controller) runWorkerNS() {
 	}
 }
 
+func (c *Controller) runWorkerSchedule() {
+	for c.processNextWorkSchedule() {
+	}
+}
+
+func (c *Controller) runWorkerPolicy() {
+	for c.processNextWorkPolicy() {
+	}
+}
+
+func (c *Controller) runWorkerQuota() {
+	for c.processNextWorkQuota() {
+	}
+}
+
+func (c *Controller) runWorkerCapacity() {
+	for c.processNextWorkCapacity() {
+	}
+}
+
 // processNextWorkClaim will read a single work item off the resourceQuotaClaimWorkQueue and
 // attempt to process it, by calling the syncHandlerClaim.
 func (c *Controller) processNextWorkClaim() bool {
@@ -253,7 +492,7 @@ func (c *Controller) processNextWorkClaim() bool {
 		}
 		// Run the syncHandlerClaim, passing it the namespace/name string of the
 		// ResourceQuotaClaims resource to be synced.
-		if err := c.syncHandlerClaim(key); err != nil {
+		if err := c.withSettings().syncHandlerClaim(key); err != nil {
 			// Put the item back on the resourceQuotaClaimWorkQueue to handle any transient errors.
 			c.resourceQuotaClaimWorkQueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -290,7 +529,7 @@ func (c *Controller) processNextWorkNS() bool {
 			return nil
 		}
 
-		if err := c.syncHandlerNS(key); err != nil {
+		if err := c.withSettings().syncHandlerNS(key); err != nil {
 			c.namespaceWorkQueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
@@ -308,6 +547,146 @@ func (c *Controller) processNextWorkNS() bool {
 	return true
 }
 
+func (c *Controller) processNextWorkSchedule() bool {
+	obj, shutdown := c.scheduledQuotaClaimWorkQueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer c.scheduledQuotaClaimWorkQueue.Done(obj)
+		var key string
+		var ok bool
+		if key, ok = obj.(string); !ok {
+			c.scheduledQuotaClaimWorkQueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in ScheduledQuotaClaim but got %#v", obj))
+			return nil
+		}
+
+		if err := c.syncHandlerSchedule(key); err != nil {
+			c.scheduledQuotaClaimWorkQueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+
+		c.scheduledQuotaClaimWorkQueue.Forget(obj)
+		klog.Infof("Successfully synced scheduled claim '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+func (c *Controller) processNextWorkPolicy() bool {
+	obj, shutdown := c.quotaPolicyWorkQueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer c.quotaPolicyWorkQueue.Done(obj)
+		var key string
+		var ok bool
+		if key, ok = obj.(string); !ok {
+			c.quotaPolicyWorkQueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in QuotaPolicy but got %#v", obj))
+			return nil
+		}
+
+		if err := c.syncHandlerPolicy(key); err != nil {
+			c.quotaPolicyWorkQueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+
+		c.quotaPolicyWorkQueue.Forget(obj)
+		klog.Infof("Successfully synced policy '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+func (c *Controller) processNextWorkQuota() bool {
+	obj, shutdown := c.resourceQuotaWorkQueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer c.resourceQuotaWorkQueue.Done(obj)
+		var item quotaDrift
+		var ok bool
+		if item, ok = obj.(quotaDrift); !ok {
+			c.resourceQuotaWorkQueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected quotaDrift in ResourceQuota but got %#v", obj))
+			return nil
+		}
+
+		if err := c.withSettings().syncHandlerQuota(item); err != nil {
+			c.resourceQuotaWorkQueue.AddRateLimited(item)
+			return fmt.Errorf("error syncing quota of ns '%s': %s, requeuing", item.namespace, err.Error())
+		}
+
+		c.resourceQuotaWorkQueue.Forget(obj)
+		klog.Infof("Successfully synced quota of ns '%s'", item.namespace)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+func (c *Controller) processNextWorkCapacity() bool {
+	obj, shutdown := c.quotaCapacityWorkQueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer c.quotaCapacityWorkQueue.Done(obj)
+		if _, ok := obj.(string); !ok {
+			c.quotaCapacityWorkQueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in QuotaCapacity but got %#v", obj))
+			return nil
+		}
+
+		if err := c.withSettings().syncHandlerCapacity(); err != nil {
+			c.quotaCapacityWorkQueue.AddRateLimited(obj)
+			return fmt.Errorf("error syncing capacity status: %s, requeuing", err.Error())
+		}
+
+		// The status is refreshed periodically, the pods of the namespaces without quota are not watched for it
+		c.quotaCapacityWorkQueue.Forget(obj)
+		c.quotaCapacityWorkQueue.AddAfter(obj, capacityStatusResyncPeriod)
+		klog.V(4).Infof("Successfully synced capacity status")
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
 // enqueueResourceQuotaClaim takes a resourceQuotaClaim resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than resourceQuotaClaim.
@@ -331,6 +710,40 @@ func (c *Controller) enqueueNamespace(obj interface{}) {
 	c.namespaceWorkQueue.Add(key)
 }
 
+func (c *Controller) enqueueScheduledQuotaClaim(obj interface{}) {
+	var key string
+	var err error
+	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.scheduledQuotaClaimWorkQueue.Add(key)
+}
+
+func (c *Controller) enqueueQuotaPolicy(obj interface{}) {
+	var key string
+	var err error
+	if key, err = cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.quotaPolicyWorkQueue.Add(key)
+}
+
+// enqueueSettingsReload puts the QuotaPolicy read by the controller on the work queue
+// Its sync reloads the settings, falling back on the ConfigMap
+func (c *Controller) enqueueSettingsReload(obj interface{}) {
+	c.quotaPolicyWorkQueue.Add(cagipv1.QuotaPolicyName)
+}
+
+// withSettings returns a copy of the controller holding a snapshot of the current settings
+// A sync is evaluated against the same settings even if they are reloaded meanwhile
+func (c *Controller) withSettings() *Controller {
+	snapshot := *c
+	snapshot.settings = *c.currentSettings.Load()
+	return &snapshot
+}
+
 // handleObject will take any resource implementing metav1.Object and attempt
 // to find the ResourceQuotaClaims resource that 'owns' it. It does this by looking at the
 // objects metadata.ownerReferences field for an appropriate OwnerReference.
@@ -372,6 +785,24 @@ func (c *Controller) handleObject(obj interface{}) {
 	}
 }
 
+// handlePodUpdate enqueues the claims of the pod namespace when the update lowers the usage charged to its quota
+// Pods that complete, are resized down or get past their deletion grace period can unblock a pending claim
+func (c *Controller) handlePodUpdate(old, new interface{}) {
+	oldPod, ok := old.(*v1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := new.(*v1.Pod)
+	if !ok || newPod.ResourceVersion == oldPod.ResourceVersion {
+		return
+	}
+	if !utils.LowersQuotaUsage(oldPod, newPod, c.clock.Now()) {
+		return
+	}
+	klog.Infof("============= Pods informer is invoqued (update) =============")
+	c.handlePod(new)
+}
+
 // handlePod will take a pod interface in argument and try to find all its Claims that are still not
 // treated and enqueue them to be processed.
 func (c *Controller) handlePod(obj interface{}) {
@@ -410,9 +841,7 @@ func (c *Controller) handlePod(obj interface{}) {
 
 	//iterate through the list and enqueue claims to be treated
 	for _, claim := range quotaClaims {
-		notReject := claim.Status.Phase != cagipv1.PhaseRejected
-		notAccepted := claim.Status.Phase != cagipv1.PhaseAccepted
-		if notReject && notAccepted {
+		if !isClaimEvaluated(claim) {
 			c.enqueueResourceQuotaClaim(claim)
 		}
 	}
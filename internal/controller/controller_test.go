@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	quota "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/client-go/tools/cache"
@@ -22,14 +27,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/diff"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
+	testingclock "k8s.io/utils/clock/testing"
 )
 
 var (
 	alwaysReady        = func() bool { return true }
 	noResyncPeriodFunc = func() time.Duration { return 0 }
+	testEvaluationTime = metav1.NewTime(time.Date(2020, time.January, 24, 8, 31, 32, 0, time.UTC))
+	testApprovalKey    = []byte("test-approval-key")
 )
 
 type reactorErr struct {
@@ -45,12 +54,16 @@ type fixture struct {
 	resourcequotaclientset      *k8sfake.Clientset
 	podsclientset               *k8sfake.Clientset
 	resourcequotaclaimclientset *fake.Clientset
+	dynamicclientset            *dynamicfake.FakeDynamicClient
 	// Objects to put in the store.
-	namespaceLister          []*v1Core.Namespace
-	resourceQuotaLister      []*v1Core.ResourceQuota
-	nodeLister               []*v1Core.Node
-	podLister                []*v1Core.Pod
-	resourceQuotaClaimLister []*cagipv1.ResourceQuotaClaim
+	namespaceLister           []*v1Core.Namespace
+	resourceQuotaLister       []*v1Core.ResourceQuota
+	nodeLister                []*v1Core.Node
+	podLister                 []*v1Core.Pod
+	resourceQuotaClaimLister  []*cagipv1.ResourceQuotaClaim
+	scheduledQuotaClaimLister []*cagipv1.ScheduledQuotaClaim
+	quotaPolicyLister         []*cagipv1.QuotaPolicy
+	configMapLister           []*v1Core.ConfigMap
 	// Actions expected to happen on the client.
 	kubeactions []core.Action
 	actions     []core.Action
@@ -60,6 +73,8 @@ type fixture struct {
 	rqobjects   []runtime.Object
 	podobjects  []runtime.Object
 	rqcobjects  []runtime.Object
+	// Custom resources of the capacity providers
+	dynamicobjects []runtime.Object
 	// Add errors as reactors
 	nserrors  []reactorErr
 	noderrors []reactorErr
@@ -68,6 +83,12 @@ type fixture struct {
 	rqcerrors []reactorErr
 	// settings for the controller
 	settings utils.Config
+	// claims collected during the batching window
+	batchedClaims []string
+	// the claim webhook recording the approval decisions is not served
+	approvalWebhookDisabled bool
+	// the replica does not lead
+	standby bool
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -78,6 +99,16 @@ func newFixture(t *testing.T) *fixture {
 	f.rqobjects = []runtime.Object{}
 	f.podobjects = []runtime.Object{}
 	f.rqcobjects = []runtime.Object{}
+	f.settings = utils.Config{
+		DefaultClaimSpec: v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("2"),
+			v1Core.ResourceMemory: resource.MustParse("6Gi"),
+		},
+		RatioMaxAllocationMemory: 0.33,
+		RatioMaxAllocationCPU:    0.33,
+		RatioOverCommitMemory:    1,
+		RatioOverCommitCPU:       1,
+	}
 	return f
 }
 
@@ -91,6 +122,15 @@ func newTestResourceQuotaClaim(name string, spec *v1Core.ResourceList) *cagipv1.
 	}
 }
 
+func newTestNamespace(name string, labels map[string]string) *v1Core.Namespace {
+	return &v1Core.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
 func newTestResourceQuota(namespace string, name string, spec *v1Core.ResourceList) *v1Core.ResourceQuota {
 	return &v1Core.ResourceQuota{
 		ObjectMeta: metav1.ObjectMeta{
@@ -104,6 +144,13 @@ func newTestResourceQuota(namespace string, name string, spec *v1Core.ResourceLi
 	}
 }
 
+func newTestScopedResourceQuota(namespace string, name string, scopes []v1Core.ResourceQuotaScope, scopeSelector *v1Core.ScopeSelector, spec *v1Core.ResourceList) *v1Core.ResourceQuota {
+	resourceQuota := newTestResourceQuota(namespace, name, spec)
+	resourceQuota.Spec.Scopes = scopes
+	resourceQuota.Spec.ScopeSelector = scopeSelector
+	return resourceQuota
+}
+
 func newTestNodes(number int, spec *v1Core.ResourceList) (nodes []*v1Core.Node) {
 	for i := 0; i < number; i++ {
 		nodes = append(nodes, &v1Core.Node{
@@ -148,6 +195,16 @@ func newTestPods(number int, request *v1Core.ResourceList, phase *v1Core.PodStat
 	return
 }
 
+// Running pods of a namespace without quota, scheduled on the first test node
+func newTestUnquotedPods(namespace string, number int, request *v1Core.ResourceList) (pods []*v1Core.Pod) {
+	pods = newTestPods(number, request, &v1Core.PodStatus{Phase: v1Core.PodRunning})
+	for _, pod := range pods {
+		pod.Namespace = namespace
+		pod.Spec.NodeName = "worker-0"
+	}
+	return
+}
+
 func newTestPodsStopped(number int, request *v1Core.ResourceList, phase *v1Core.PodStatus) (pods []*v1Core.Pod) {
 	for i := 0; i < number; i++ {
 		pods = append(pods, &v1Core.Pod{
@@ -177,17 +234,8 @@ func (f *fixture) newController() (*Controller, kubeinformers.SharedInformerFact
 	f.resourcequotaclientset = k8sfake.NewSimpleClientset(f.rqobjects...)
 	f.podsclientset = k8sfake.NewSimpleClientset(f.podobjects...)
 	f.resourcequotaclaimclientset = fake.NewSimpleClientset(f.rqcobjects...)
-
-	f.settings = utils.Config{
-		DefaultClaimSpec: v1Core.ResourceList{
-			v1Core.ResourceCPU:    resource.MustParse("2"),
-			v1Core.ResourceMemory: resource.MustParse("6Gi"),
-		},
-		RatioMaxAllocationMemory: 0.33,
-		RatioMaxAllocationCPU:    0.33,
-		RatioOverCommitMemory:    1,
-		RatioOverCommitCPU:       1,
-	}
+	f.dynamicclientset = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{nodePoolResource: "NodePoolList"}, f.dynamicobjects...)
 
 	nsI := kubeinformers.NewSharedInformerFactory(f.namespaceclientset, noResyncPeriodFunc())
 	nodeI := kubeinformers.NewSharedInformerFactory(f.namespaceclientset, noResyncPeriodFunc())
@@ -197,39 +245,37 @@ func (f *fixture) newController() (*Controller, kubeinformers.SharedInformerFact
 
 	c := NewController(
 		f.settings,
-		f.namespaceclientset, f.resourcequotaclientset, f.nodesclientset, f.podsclientset, f.resourcequotaclaimclientset,
+		f.namespaceclientset, f.resourcequotaclientset, f.nodesclientset, f.podsclientset, f.resourcequotaclaimclientset, f.dynamicclientset,
 		nsI.Core().V1().Namespaces(),
 		rqI.Core().V1().ResourceQuotas(),
 		nodeI.Core().V1().Nodes(),
 		poI.Core().V1().Pods(),
-		rqcI.Cagip().V1().ResourceQuotaClaims())
+		rqcI.Cagip().V1().ResourceQuotaClaims(),
+		rqcI.Cagip().V1().ScheduledQuotaClaims(),
+		rqcI.Cagip().V1().QuotaPolicies(),
+		rqcI.Cagip().V1().QuotaCapacities(),
+		nsI.Core().V1().ConfigMaps())
 
 	c.namespacesSynced = alwaysReady
 	c.resourceQuotaSynced = alwaysReady
 	c.nodesSynced = alwaysReady
 	c.podsSynced = alwaysReady
 	c.resourceQuotaClaimSynced = alwaysReady
+	c.scheduledQuotaClaimSynced = alwaysReady
+	c.quotaPolicySynced = alwaysReady
+	c.quotaCapacitySynced = alwaysReady
+	c.configMapSynced = alwaysReady
 
 	c.recorder = &record.FakeRecorder{}
+	c.clock = testingclock.NewFakeClock(testEvaluationTime.Time)
+	c.approvalKey = testApprovalKey
+	c.approvalWebhook = !f.approvalWebhookDisabled
+	c.leading.Store(!f.standby)
 
-	for _, ns := range f.namespaceLister {
-		_ = nsI.Core().V1().Namespaces().Informer().GetIndexer().Add(ns)
-	}
-
-	for _, rq := range f.resourceQuotaLister {
-		_ = rqI.Core().V1().ResourceQuotas().Informer().GetIndexer().Add(rq)
-	}
-
-	for _, node := range f.nodeLister {
-		_ = nodeI.Core().V1().Nodes().Informer().GetIndexer().Add(node)
-	}
-
-	for _, pod := range f.podLister {
-		_ = poI.Core().V1().Pods().Informer().GetIndexer().Add(pod)
-	}
+	f.seedListers(nsI, nodeI, rqI, poI, rqcI)
 
-	for _, rqc := range f.resourceQuotaClaimLister {
-		_ = rqcI.Cagip().V1().ResourceQuotaClaims().Informer().GetIndexer().Add(rqc)
+	for _, key := range f.batchedClaims {
+		c.batch.add(key)
 	}
 
 	for _, nserror := range f.nserrors {
@@ -265,6 +311,52 @@ func (f *fixture) newController() (*Controller, kubeinformers.SharedInformerFact
 	return c, nsI, nodeI, rqI, poI, rqcI
 }
 
+// Add the objects of the listers to the caches of the informers
+func (f *fixture) seedListers(nsI, nodeI, rqI, poI kubeinformers.SharedInformerFactory, rqcI informers.SharedInformerFactory) {
+	for _, ns := range f.namespaceLister {
+		_ = nsI.Core().V1().Namespaces().Informer().GetIndexer().Add(ns)
+	}
+
+	for _, rq := range f.resourceQuotaLister {
+		_ = rqI.Core().V1().ResourceQuotas().Informer().GetIndexer().Add(rq)
+	}
+
+	for _, node := range f.nodeLister {
+		_ = nodeI.Core().V1().Nodes().Informer().GetIndexer().Add(node)
+	}
+
+	for _, pod := range f.podLister {
+		_ = poI.Core().V1().Pods().Informer().GetIndexer().Add(pod)
+	}
+
+	for _, rqc := range f.resourceQuotaClaimLister {
+		_ = rqcI.Cagip().V1().ResourceQuotaClaims().Informer().GetIndexer().Add(rqc)
+	}
+
+	for _, sqc := range f.scheduledQuotaClaimLister {
+		_ = rqcI.Cagip().V1().ScheduledQuotaClaims().Informer().GetIndexer().Add(sqc)
+	}
+
+	for _, policy := range f.quotaPolicyLister {
+		_ = rqcI.Cagip().V1().QuotaPolicies().Informer().GetIndexer().Add(policy)
+	}
+
+	for _, configMap := range f.configMapLister {
+		_ = nsI.Core().V1().ConfigMaps().Informer().GetIndexer().Add(configMap)
+	}
+}
+
+// Start the informers and wait for the first list of the core ones
+// The list removes the objects only known by the listers, they are added again
+func (f *fixture) startInformers(stopCh chan struct{}, nsI, nodeI, rqI, poI kubeinformers.SharedInformerFactory, rqcI informers.SharedInformerFactory) {
+	for _, factory := range []kubeinformers.SharedInformerFactory{nsI, nodeI, rqI, poI} {
+		factory.Start(stopCh)
+		factory.WaitForCacheSync(stopCh)
+	}
+	rqcI.Start(stopCh)
+	f.seedListers(nsI, nodeI, rqI, poI, rqcI)
+}
+
 func (f *fixture) runClaim(name string) {
 	f.runClaimControllerWithAction(name, true, false)
 }
@@ -278,11 +370,7 @@ func (f *fixture) RunController() *Controller {
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
-	nsI.Start(stopCh)
-	nodeI.Start(stopCh)
-	rqI.Start(stopCh)
-	poI.Start(stopCh)
-	rqcI.Start(stopCh)
+	f.startInformers(stopCh, nsI, nodeI, rqI, poI, rqcI)
 
 	return c
 }
@@ -292,11 +380,7 @@ func (f *fixture) runClaimControllerWithAction(rqcName string, startInformers bo
 	if startInformers {
 		stopCh := make(chan struct{})
 		defer close(stopCh)
-		nsI.Start(stopCh)
-		nodeI.Start(stopCh)
-		rqI.Start(stopCh)
-		poI.Start(stopCh)
-		rqcI.Start(stopCh)
+		f.startInformers(stopCh, nsI, nodeI, rqI, poI, rqcI)
 	}
 
 	err := c.syncHandlerClaim(rqcName)
@@ -306,35 +390,7 @@ func (f *fixture) runClaimControllerWithAction(rqcName string, startInformers bo
 		f.t.Error("expected error syncing rqc, got nil")
 	}
 
-	actions := filterInformerActions(f.resourcequotaclaimclientset.Actions())
-	for i, action := range actions {
-		if len(f.actions) < i+1 {
-			f.t.Errorf("%d unexpected actions: %+v", len(actions)-len(f.actions), actions[i:])
-			break
-		}
-
-		expectedAction := f.actions[i]
-		checkAction(expectedAction, action, f.t)
-	}
-
-	if len(f.actions) > len(actions) {
-		f.t.Errorf("%d additional expected actions:%+v", len(f.actions)-len(actions), f.actions[len(actions):])
-	}
-
-	k8sActions := filterInformerActions(f.resourcequotaclientset.Actions())
-	for i, action := range k8sActions {
-		if len(f.kubeactions) < i+1 {
-			f.t.Errorf("%d unexpected actions: %+v", len(k8sActions)-len(f.kubeactions), k8sActions[i:])
-			break
-		}
-
-		expectedAction := f.kubeactions[i]
-		checkAction(expectedAction, action, f.t)
-	}
-
-	if len(f.kubeactions) > len(k8sActions) {
-		f.t.Errorf("%d additional expected actions:%+v", len(f.kubeactions)-len(k8sActions), f.kubeactions[len(k8sActions):])
-	}
+	f.checkActions()
 }
 
 func (f *fixture) runNS(name string) {
@@ -350,11 +406,7 @@ func (f *fixture) runNSControllerWithAction(nsName string, startInformers bool,
 	if startInformers {
 		stopCh := make(chan struct{})
 		defer close(stopCh)
-		nsI.Start(stopCh)
-		nodeI.Start(stopCh)
-		rqI.Start(stopCh)
-		poI.Start(stopCh)
-		rqcI.Start(stopCh)
+		f.startInformers(stopCh, nsI, nodeI, rqI, poI, rqcI)
 	}
 
 	err := c.syncHandlerNS(nsName)
@@ -364,6 +416,54 @@ func (f *fixture) runNSControllerWithAction(nsName string, startInformers bool,
 		f.t.Error("expected error syncing rqc, got nil")
 	}
 
+	f.checkActions()
+}
+
+func (f *fixture) runSchedule(name string) {
+	c, nsI, nodeI, rqI, poI, rqcI := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	f.startInformers(stopCh, nsI, nodeI, rqI, poI, rqcI)
+
+	if err := c.syncHandlerSchedule(name); err != nil {
+		f.t.Errorf("error syncing sqc: %v", err)
+	}
+
+	f.checkActions()
+}
+
+func (f *fixture) runPolicy(name string) *Controller {
+	c, nsI, nodeI, rqI, poI, rqcI := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	f.startInformers(stopCh, nsI, nodeI, rqI, poI, rqcI)
+
+	if err := c.syncHandlerPolicy(name); err != nil {
+		f.t.Errorf("error syncing policy: %v", err)
+	}
+
+	f.checkActions()
+	return c
+}
+
+func (f *fixture) runQuota(item quotaDrift, accepted *v1Core.ResourceList) {
+	c, nsI, nodeI, rqI, poI, rqcI := f.newController()
+	if accepted != nil {
+		c.acceptedSpecs.record(item.namespace, *accepted)
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	f.startInformers(stopCh, nsI, nodeI, rqI, poI, rqcI)
+
+	if err := c.syncHandlerQuota(item); err != nil {
+		f.t.Errorf("error syncing quota: %v", err)
+	}
+
+	f.checkActions()
+}
+
+// checkActions verifies that the actions made on the clients are the expected ones
+func (f *fixture) checkActions() {
 	actions := filterInformerActions(f.resourcequotaclaimclientset.Actions())
 	for i, action := range actions {
 		if len(f.actions) < i+1 {
@@ -436,6 +536,12 @@ func checkAction(expected, actual core.Action, t *testing.T) {
 			t.Errorf("Action %s %s has wrong patch\nDiff:\n %s",
 				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintSideBySide(expPatch, patch))
 		}
+	case core.GetActionImpl:
+		e, _ := expected.(core.GetActionImpl)
+		if a.GetName() != e.GetName() || a.GetNamespace() != e.GetNamespace() {
+			t.Errorf("Action %s %s is wrong \nExpected %s/%s got %s/%s ",
+				a.GetVerb(), a.GetResource().Resource, e.GetName(), e.GetNamespace(), a.GetName(), a.GetNamespace())
+		}
 	case core.DeleteActionImpl:
 		e, _ := expected.(core.DeleteActionImpl)
 		if a.GetName() != e.GetName() || a.GetNamespace() != e.GetNamespace() {
@@ -458,6 +564,12 @@ func filterInformerActions(actions []core.Action) []core.Action {
 		if len(action.GetNamespace()) == 0 &&
 			(action.Matches("list", "resourcequotaclaims") ||
 				action.Matches("watch", "resourcequotaclaims") ||
+				action.Matches("list", "scheduledquotaclaims") ||
+				action.Matches("watch", "scheduledquotaclaims") ||
+				action.Matches("list", "quotapolicies") ||
+				action.Matches("watch", "quotapolicies") ||
+				action.Matches("list", "quotacapacities") ||
+				action.Matches("watch", "quotacapacities") ||
 				action.Matches("list", "resourcequotas") ||
 				action.Matches("watch", "resourcequotas")) {
 			continue
@@ -468,11 +580,25 @@ func filterInformerActions(actions []core.Action) []core.Action {
 	return ret
 }
 
+// The managed quotas written by the controller record their spec as the accepted one
+func withAcceptedSpec(resourceQuota *v1Core.ResourceQuota) *v1Core.ResourceQuota {
+	annotated := resourceQuota.DeepCopy()
+	spec, signature := utils.SignAcceptedSpec(testApprovalKey, annotated.Namespace, annotated.Spec.Hard)
+	if annotated.Annotations == nil {
+		annotated.Annotations = map[string]string{}
+	}
+	annotated.Annotations[cagipv1.AnnotationAcceptedSpec] = spec
+	annotated.Annotations[cagipv1.AnnotationAcceptedSpecSignature] = signature
+	return annotated
+}
+
 func (f *fixture) expectCreateResourceQuotaAction(quota *v1Core.ResourceQuota) {
+	quota = withAcceptedSpec(quota)
 	f.kubeactions = append(f.kubeactions, core.NewCreateAction(schema.GroupVersionResource{Resource: "resourcequotas"}, quota.Namespace, quota))
 }
 
 func (f *fixture) expectUpdateResourceQuotaAction(quota *v1Core.ResourceQuota) {
+	quota = withAcceptedSpec(quota)
 	f.kubeactions = append(f.kubeactions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "resourcequotas"}, quota.Namespace, quota))
 }
 
@@ -496,6 +622,22 @@ func (f *fixture) expectClaimStatus(t *testing.T, claim *cagipv1.ResourceQuotaCl
 	assert.Equal(t, updatedClaim.Status.Details, details)
 }
 
+func (f *fixture) expectGetScheduledQuotaClaimAction(sqc *cagipv1.ScheduledQuotaClaim) {
+	f.actions = append(f.actions, core.NewGetAction(schema.GroupVersionResource{Resource: "scheduledquotaclaims"}, sqc.Namespace, sqc.Name))
+}
+
+func (f *fixture) expectUpdateStatusScheduledQuotaClaimAction(sqc *cagipv1.ScheduledQuotaClaim) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "scheduledquotaclaims"}, sqc.Namespace, sqc)
+	action.Subresource = "status"
+	f.actions = append(f.actions, action)
+}
+
+func (f *fixture) expectUpdateStatusQuotaPolicyAction(policy *cagipv1.QuotaPolicy) {
+	action := core.NewRootUpdateAction(schema.GroupVersionResource{Resource: "quotapolicies"}, policy)
+	action.Subresource = "status"
+	f.actions = append(f.actions, action)
+}
+
 func getClaimKey(rqc *cagipv1.ResourceQuotaClaim, t *testing.T) string {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(rqc)
 	if err != nil {
@@ -505,6 +647,15 @@ func getClaimKey(rqc *cagipv1.ResourceQuotaClaim, t *testing.T) string {
 	return key
 }
 
+func getScheduledKey(sqc *cagipv1.ScheduledQuotaClaim, t *testing.T) string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(sqc)
+	if err != nil {
+		t.Errorf("Unexpected error getting key for sqc %v: %v", sqc.Name, err)
+		return ""
+	}
+	return key
+}
+
 func getNSKey(ns *v1Core.Namespace, t *testing.T) string {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(ns)
 	if err != nil {
@@ -582,6 +733,34 @@ func TestClaimCreateNewQuota(t *testing.T) {
 		f.runClaim(getClaimKey(claim, t))
 	})
 
+	t.Run("1 Node 8Gi 1CPU - Claim 2Gi 300m - Pods without quota outside of the system namespaces", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.SystemNamespaces = []string{"kube-*"}
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Pods of a namespace without quota that is not a system namespace
+		f.podLister = newTestUnquotedPods("monitoring", 2, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("400m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		expResourceQuota := newResourceQuota(claim)
+		f.expectCreateResourceQuotaAction(expResourceQuota)
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
 	t.Run("error while creating quota should requeue", func(t *testing.T) {
 		f := newFixture(t)
 		// Nodes
@@ -631,6 +810,47 @@ func TestClaimCreateNewQuota(t *testing.T) {
 		f.runClaimExpectError(getClaimKey(claim, t))
 	})
 
+	t.Run("1 Node 8Gi 1CPU - Claim 1Gi 300m - Max Allocation CPU of the namespace tier", func(t *testing.T) {
+		f := newFixture(t)
+		// Tier restricting the CPU allocation
+		restricted := f.settings
+		restricted.RatioMaxAllocationCPU = 0.2
+		f.settings.Tiers = []utils.Tier{{
+			Name:              "restricted",
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "restricted"}},
+			Settings:          restricted,
+		}}
+		// Namespace of the tier
+		ns := &v1Core.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   metav1.NamespaceDefault,
+				Labels: map[string]string{"tier": "restricted"},
+			},
+		}
+		f.namespaceLister = append(f.namespaceLister, ns)
+		f.nsobjects = append(f.nsobjects, ns)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded CPU allocation limit claiming 300m but limited to 200m", testEvaluationTime)
+		claim.Status.Tier = "restricted"
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
 }
 
 func TestClaimUpdateQuota(t *testing.T) {
@@ -656,7 +876,7 @@ func TestClaimUpdateQuota(t *testing.T) {
 			},
 		}
 		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
 		// Test against claim
 		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
 			v1Core.ResourceCPU:    resource.MustParse("300m"),
@@ -694,7 +914,7 @@ func TestClaimUpdateQuota(t *testing.T) {
 			},
 		}
 		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
 		// Test against claim
 		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
 			v1Core.ResourceCPU:    resource.MustParse("900m"),
@@ -731,7 +951,7 @@ func TestClaimUpdateQuota(t *testing.T) {
 			},
 		}
 		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
 		// Test against claim
 		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
 			v1Core.ResourceCPU:    resource.MustParse("2.5"),
@@ -768,7 +988,7 @@ func TestClaimUpdateQuota(t *testing.T) {
 			},
 		}
 		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
 		// Test against claim
 		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
 			v1Core.ResourceCPU:    resource.MustParse("300m"),
@@ -807,7 +1027,7 @@ func TestClaimUpdateQuota(t *testing.T) {
 			},
 		}
 		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
 		// Test against claim
 		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
 			v1Core.ResourceCPU:    resource.MustParse("300m"),
@@ -826,71 +1046,200 @@ func TestClaimUpdateQuota(t *testing.T) {
 		f.runClaimExpectError(getClaimKey(claim, t))
 	})
 
-}
-
-func TestClaimPending(t *testing.T) {
-	t.Run("1 Node 16Gi 4CPU - Claim 5Gi 600m - Request 8Gi 750m - Should be Pending Memory", func(t *testing.T) {
+	t.Run("1 Node 8Gi 1CPU - Claim 1Gi 200m - Succeeded Pods 4Gi - Should downscale", func(t *testing.T) {
 		f := newFixture(t)
 		// Nodes
 		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
-			v1Core.ResourceCPU:    resource.MustParse("4"),
-			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
 		})
 		// Existing Quota
-		managedQuota := &v1Core.ResourceQuota{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      utils.ResourceQuotaName,
-				Namespace: metav1.NamespaceDefault,
-			},
-			Spec: v1Core.ResourceQuotaSpec{
-				Hard: v1Core.ResourceList{
-					v1Core.ResourceCPU:    resource.MustParse("800m"),
-					v1Core.ResourceMemory: resource.MustParse("8Gi"),
-				},
-			},
-		}
-		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
-
-		// Scheduled Pods
-		pods := newTestPods(4, &v1Core.ResourceList{
-			v1Core.ResourceCPU:    resource.MustParse("250m"),
-			v1Core.ResourceMemory: resource.MustParse("2Gi"),
-		}, &v1Core.PodStatus{
-			Phase: "Running",
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("500m"),
+			v1Core.ResourceMemory: resource.MustParse("4Gi"),
 		})
-		// Scheduled Pods that should be filtered out, because they are not in "Running or Pending" state
-		stoppedPods := newTestPods(5, &v1Core.ResourceList{
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// Terminal pods are not charged to the quota anymore
+		f.podLister = newTestPods(2, &v1Core.ResourceList{
 			v1Core.ResourceCPU:    resource.MustParse("250m"),
 			v1Core.ResourceMemory: resource.MustParse("2Gi"),
 		}, &v1Core.PodStatus{
-			Phase: "Stopped",
+			Phase: v1Core.PodSucceeded,
 		})
-
-		for _, stoppedPod := range stoppedPods {
-			pods = append(pods, stoppedPod)
-		}
-
-		// Keep only pods in "Running or Pending" state
-		pods = utils.FilterRunningPods(pods)
-
-		f.podLister = pods
 		// Test against claim
 		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
-			v1Core.ResourceCPU:    resource.MustParse("600m"),
-			v1Core.ResourceMemory: resource.MustParse("5Gi"),
+			v1Core.ResourceCPU:    resource.MustParse("200m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
 		})
 		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
 		f.rqcobjects = append(f.rqcobjects, claim)
-		// Expected Status
-		claim.Status.Phase = cagipv1.PhasePending
-		claim.Status.Details = "Awaiting lower Memory consumption claiming 5Gi but current total of request is 8Gi"
-		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
-		f.runClaim(getClaimKey(claim, t))
-	})
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
 
-	t.Run("1 Node 16Gi 4CPU - Claim 6Gi 600m - Request 6Gi 750m - Should be Pending CPU", func(t *testing.T) {
+		f.runClaim(getClaimKey(claim, t))
+	})
+}
+
+func TestClaimGitOps(t *testing.T) {
+
+	t.Run("accepted claim should be kept", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		acceptedClaim := claim.DeepCopy()
+		acceptedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(acceptedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("accepted claim should restore the managed quota without evaluation", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		// No nodes, an evaluation would reject the claim
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		})
+		claim.Generation = 2
+		claim.Status = cagipv1.ResourceQuotaClaimStatus{Phase: cagipv1.PhaseAccepted, ObservedGeneration: 2}
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Manually edited quota
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("10"),
+			v1Core.ResourceMemory: resource.MustParse("20Gi"),
+		})
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(claim))
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("edited accepted claim should be evaluated again", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("10Gi"),
+		})
+		claim.Generation = 3
+		claim.Status = cagipv1.ResourceQuotaClaimStatus{Phase: cagipv1.PhaseAccepted, ObservedGeneration: 2}
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded Memory allocation limit claiming 10Gi but limited to 2.64Gi", testEvaluationTime)
+		assert.Equal(t, rejectedClaim.Status.ObservedGeneration, int64(3))
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("superseded claim should be ignored", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		})
+		claim.Status = cagipv1.ResourceQuotaClaimStatus{Phase: cagipv1.PhaseSuperseded}
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("accepted claim should supersede the previous one", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Previously accepted claim
+		previousClaim := newTestResourceQuotaClaim("default", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("200m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		previousClaim.Status = cagipv1.ResourceQuotaClaimStatus{Phase: cagipv1.PhaseAccepted}
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, previousClaim)
+		f.rqcobjects = append(f.rqcobjects, previousClaim)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		acceptedClaim := claim.DeepCopy()
+		acceptedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(acceptedClaim)
+		supersededClaim := previousClaim.DeepCopy()
+		supersededClaim.Status = newResourceQuotaClaimStatus(previousClaim, cagipv1.PhaseSuperseded, cagipv1.ReasonSuperseded,
+			"Superseded by claim test", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(supersededClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+}
+
+func newTestBurstClaimStatus(claim *cagipv1.ResourceQuotaClaim, phase string, reason string, details string, expiresAt metav1.Time, revertTo v1Core.ResourceList) cagipv1.ResourceQuotaClaimStatus {
+	status := newResourceQuotaClaimStatus(claim, phase, reason, details, testEvaluationTime)
+	status.ExpiresAt = &expiresAt
+	status.RevertTo = revertTo
+	return status
+}
+
+// Status of a claim awaiting a lower usage since a given time
+func newTestPendingClaimStatus(claim *cagipv1.ResourceQuotaClaim, details string, pendingSince metav1.Time) cagipv1.ResourceQuotaClaimStatus {
+	status := newResourceQuotaClaimStatus(claim, cagipv1.PhasePending, cagipv1.ReasonAwaitingLowerUsage, details, testEvaluationTime)
+	status.PendingSince = &pendingSince
+	return status
+}
+
+func TestClaimBurst(t *testing.T) {
+	previousSpec := v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("500m"),
+		v1Core.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	burstSpec := v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("1"),
+		v1Core.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	expired := metav1.NewTime(testEvaluationTime.Add(-time.Hour))
+
+	t.Run("burst claim should be kept with its expiry and the previous quota", func(t *testing.T) {
 		f := newFixture(t)
 		// Nodes
 		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
@@ -898,38 +1247,1472 @@ func TestClaimPending(t *testing.T) {
 			v1Core.ResourceMemory: resource.MustParse("16Gi"),
 		})
 		// Existing Quota
-		managedQuota := &v1Core.ResourceQuota{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      utils.ResourceQuotaName,
-				Namespace: metav1.NamespaceDefault,
-			},
-			Spec: v1Core.ResourceQuotaSpec{
-				Hard: v1Core.ResourceList{
-					v1Core.ResourceCPU:    resource.MustParse("800m"),
-					v1Core.ResourceMemory: resource.MustParse("6Gi"),
-				},
-			},
-		}
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &previousSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &burstSpec)
+		claim.Annotations = map[string]string{cagipv1.AnnotationDuration: "48h"}
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(claim))
+		acceptedClaim := claim.DeepCopy()
+		acceptedClaim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg,
+			metav1.NewTime(testEvaluationTime.Add(48*time.Hour)), previousSpec)
+		f.expectUpdateStatusResourceQuotaClaimAction(acceptedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("burst claim with an invalid expiry should be rejected", func(t *testing.T) {
+		f := newFixture(t)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &burstSpec)
+		claim.Annotations = map[string]string{cagipv1.AnnotationExpiresAt: "tomorrow"}
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInvalidExpiry,
+			"Invalid cagip.github.com/expires-at annotation tomorrow", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("active burst claim should wait for its expiry", func(t *testing.T) {
+		f := newFixture(t)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &burstSpec)
+		claim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg,
+			metav1.NewTime(testEvaluationTime.Add(time.Hour)), previousSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("expired burst claim should restore the previous quota and be removed", func(t *testing.T) {
+		f := newFixture(t)
+		// Existing Quota
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &burstSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &burstSpec)
+		claim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, expired, previousSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(newTestResourceQuotaClaim("test", &previousSpec)))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("expired burst claim should be kept as expired in GitOps mode", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		// Existing Quota
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &burstSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &burstSpec)
+		claim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, expired, previousSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(newTestResourceQuotaClaim("test", &previousSpec)))
+		expiredClaim := claim.DeepCopy()
+		expiredClaim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseExpired, cagipv1.ReasonExpired,
+			"Expired at 2020-01-24T07:31:32Z, previous quota restored", expired, previousSpec)
+		f.expectUpdateStatusResourceQuotaClaimAction(expiredClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("expired burst claim should be pending while the requests are higher than the previous quota", func(t *testing.T) {
+		f := newFixture(t)
+		// Existing Quota
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &burstSpec)
 		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
-		f.rqobjects = append(f.rqcobjects, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
 		// Scheduled Pods
-		pods := newTestPods(3, &v1Core.ResourceList{
-			v1Core.ResourceCPU:    resource.MustParse("250m"),
-			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		f.podLister = newTestPods(2, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("200m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
 		}, &v1Core.PodStatus{
 			Phase: "Running",
 		})
-		f.podLister = pods
 		// Test against claim
-		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
-			v1Core.ResourceCPU:    resource.MustParse("600m"),
+		claim := newTestResourceQuotaClaim("test", &burstSpec)
+		claim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, expired, previousSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		pendingClaim := claim.DeepCopy()
+		pendingClaim.Status = newTestBurstClaimStatus(claim, cagipv1.PhasePending, cagipv1.ReasonAwaitingLowerUsage,
+			"Awaiting lower Memory consumption claiming 1Gi but current total of request is 2Gi", expired, previousSpec)
+		f.expectUpdateStatusResourceQuotaClaimAction(pendingClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("expired burst claim should be pending while the previous quota it raises does not fit", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// The burst lowered the quota meanwhile another namespace took the capacity
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &previousSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, newTestResourceQuota("otherns", utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("200m"),
+			v1Core.ResourceMemory: resource.MustParse("6Gi"),
+		}))
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &previousSpec)
+		claim.Status = newTestBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, expired, burstSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		pendingClaim := claim.DeepCopy()
+		pendingClaim.Status = newTestBurstClaimStatus(claim, cagipv1.PhasePending, cagipv1.ReasonInsufficientCapacity,
+			"Not enough Memory claiming 4Gi but 2Gi currently available", expired, burstSpec)
+		f.expectUpdateStatusResourceQuotaClaimAction(pendingClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("expired burst claim pending its revert should be enqueued when capacity is released", func(t *testing.T) {
+		f := newFixture(t)
+		otherQuota := newTestResourceQuota("otherns", utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("200m"),
 			v1Core.ResourceMemory: resource.MustParse("6Gi"),
 		})
+		otherQuota.ResourceVersion = "1"
+		claim := newTestResourceQuotaClaim("test", &previousSpec)
+		claim.Status = newTestBurstClaimStatus(claim, cagipv1.PhasePending, cagipv1.ReasonInsufficientCapacity,
+			"Not enough Memory claiming 4Gi but 2Gi currently available", expired, burstSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		c, _, _, _, _, _ := f.newController()
+
+		lowered := otherQuota.DeepCopy()
+		lowered.ResourceVersion = "2"
+		lowered.Spec.Hard[v1Core.ResourceMemory] = resource.MustParse("4Gi")
+		c.handleResourceQuotaUpdate(otherQuota, lowered)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 1)
+	})
+}
+
+func newTestScheduledQuotaClaim(name string) *cagipv1.ScheduledQuotaClaim {
+	return &cagipv1.ScheduledQuotaClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         metav1.NamespaceDefault,
+			CreationTimestamp: metav1.NewTime(time.Date(2020, time.January, 24, 6, 0, 0, 0, time.UTC)),
+		},
+		Spec: cagipv1.ScheduledQuotaClaimSpec{
+			TimeZone: "Europe/Paris",
+			Schedules: []cagipv1.QuotaSchedule{
+				{
+					Name: "business-hours",
+					Cron: "0 8 * * 1-5",
+					Spec: v1Core.ResourceList{
+						v1Core.ResourceCPU:    resource.MustParse("4"),
+						v1Core.ResourceMemory: resource.MustParse("8Gi"),
+					},
+				},
+				{
+					Name: "night",
+					Cron: "0 20 * * 1-5",
+					Spec: v1Core.ResourceList{
+						v1Core.ResourceCPU:    resource.MustParse("1"),
+						v1Core.ResourceMemory: resource.MustParse("2Gi"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestScheduledClaim(t *testing.T) {
+	// 08:00 in Paris on Friday 24 January 2020
+	businessHours := metav1.NewTime(time.Date(2020, time.January, 24, 7, 0, 0, 0, time.UTC))
+	// 20:00 in Paris on Friday 24 January 2020
+	night := metav1.NewTime(time.Date(2020, time.January, 24, 19, 0, 0, 0, time.UTC))
+
+	t.Run("due schedule should emit a claim", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Expected Actions
+		updated := scheduled.DeepCopy()
+		updated.Status = cagipv1.ScheduledQuotaClaimStatus{
+			LastExecutionTime: &businessHours,
+			NextExecutionTime: &night,
+			NextSchedule:      "night",
+			Runs: []cagipv1.ScheduledRun{
+				{Schedule: "business-hours", ExecutionTime: businessHours, Claim: "office-26330820"},
+			},
+		}
+		f.expectCreateResourceQuotaClaimAction(newScheduledResourceQuotaClaim(scheduled, "office-26330820", scheduled.Spec.Schedules[0].Spec))
+		f.expectUpdateStatusScheduledQuotaClaimAction(updated)
+
+		f.runSchedule(getScheduledKey(scheduled, t))
+	})
+
+	t.Run("emitted claim should replace the previous one not yet applied", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		scheduled.Status.LastExecutionTime = &metav1.Time{Time: businessHours.Add(-12 * time.Hour)}
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Pending claim of the previous run
+		previousClaim := newScheduledResourceQuotaClaim(scheduled, "office-26330100", scheduled.Spec.Schedules[1].Spec)
+		previousClaim.Status.Phase = cagipv1.PhasePending
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, previousClaim)
+		f.rqcobjects = append(f.rqcobjects, previousClaim)
+		// Expected Actions
+		updated := scheduled.DeepCopy()
+		updated.Status = cagipv1.ScheduledQuotaClaimStatus{
+			LastExecutionTime: &businessHours,
+			NextExecutionTime: &night,
+			NextSchedule:      "night",
+			Runs: []cagipv1.ScheduledRun{
+				{Schedule: "business-hours", ExecutionTime: businessHours, Claim: "office-26330820"},
+			},
+		}
+		f.expectDeleteResourceQuotaClaimAction(previousClaim)
+		f.expectCreateResourceQuotaClaimAction(newScheduledResourceQuotaClaim(scheduled, "office-26330820", scheduled.Spec.Schedules[0].Spec))
+		f.expectUpdateStatusScheduledQuotaClaimAction(updated)
+
+		f.runSchedule(getScheduledKey(scheduled, t))
+	})
+
+	t.Run("schedule not due should only report the next execution", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		scheduled.Status = cagipv1.ScheduledQuotaClaimStatus{
+			LastExecutionTime: &businessHours,
+			Runs: []cagipv1.ScheduledRun{
+				{Schedule: "business-hours", ExecutionTime: businessHours, Claim: "office-26330820", Phase: cagipv1.PhaseAccepted, Reason: cagipv1.ReasonAccepted},
+			},
+		}
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Expected Actions
+		updated := scheduled.DeepCopy()
+		updated.Status.NextExecutionTime = &night
+		updated.Status.NextSchedule = "night"
+		f.expectUpdateStatusScheduledQuotaClaimAction(updated)
+
+		f.runSchedule(getScheduledKey(scheduled, t))
+	})
+
+	t.Run("run not evaluated yet should not be emitted again", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		scheduled.Status = cagipv1.ScheduledQuotaClaimStatus{
+			LastExecutionTime: &businessHours,
+			Runs: []cagipv1.ScheduledRun{
+				{Schedule: "business-hours", ExecutionTime: businessHours, Claim: "office-26330820"},
+			},
+		}
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Expected Actions
+		updated := scheduled.DeepCopy()
+		updated.Status.NextExecutionTime = &night
+		updated.Status.NextSchedule = "night"
+		f.expectUpdateStatusScheduledQuotaClaimAction(updated)
+
+		f.runSchedule(getScheduledKey(scheduled, t))
+	})
+
+	t.Run("invalid schedule should be reported", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		scheduled.Spec.Schedules[1].Cron = "0 20 * *"
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Expected Actions
+		updated := scheduled.DeepCopy()
+		updated.Status.Details = "Invalid cron expression of schedule night: expected exactly 5 fields, found 4: [0 20 * *]"
+		f.expectUpdateStatusScheduledQuotaClaimAction(updated)
+
+		f.runSchedule(getScheduledKey(scheduled, t))
+	})
+
+	t.Run("emitted claim should report its outcome", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		scheduled.Status = cagipv1.ScheduledQuotaClaimStatus{
+			LastExecutionTime: &businessHours,
+			Runs: []cagipv1.ScheduledRun{
+				{Schedule: "business-hours", ExecutionTime: businessHours, Claim: "office-26330820"},
+			},
+		}
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Emitted claim, no nodes it will be rejected
+		claim := newScheduledResourceQuotaClaim(scheduled, "office-26330820", scheduled.Spec.Schedules[0].Spec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded Memory allocation limit claiming 8Gi but limited to 0", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+		f.expectGetScheduledQuotaClaimAction(scheduled)
+		updated := scheduled.DeepCopy()
+		updated.Status.Runs[0].Phase = cagipv1.PhaseRejected
+		updated.Status.Runs[0].Reason = cagipv1.ReasonAllocationLimitExceeded
+		updated.Status.Runs[0].Details = "Exceeded Memory allocation limit claiming 8Gi but limited to 0"
+		f.expectUpdateStatusScheduledQuotaClaimAction(updated)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim of a run not recorded yet should be requeued", func(t *testing.T) {
+		f := newFixture(t)
+		scheduled := newTestScheduledQuotaClaim("office")
+		scheduled.Status.LastExecutionTime = &metav1.Time{Time: businessHours.Add(-12 * time.Hour)}
+		f.scheduledQuotaClaimLister = append(f.scheduledQuotaClaimLister, scheduled)
+		f.rqcobjects = append(f.rqcobjects, scheduled)
+		// Claim emitted before the status of the schedule is updated, no nodes it will be rejected
+		claim := newScheduledResourceQuotaClaim(scheduled, "office-26330820", scheduled.Spec.Schedules[0].Spec)
+		claim.CreationTimestamp = businessHours
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded Memory allocation limit claiming 8Gi but limited to 0", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+		f.expectGetScheduledQuotaClaimAction(scheduled)
+
+		f.runClaimExpectError(getClaimKey(claim, t))
+	})
+}
+
+func newTestQuotaPolicy(name string) *cagipv1.QuotaPolicy {
+	ratioOverCommitCPU := 1.5
+	return &cagipv1.QuotaPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Generation: 2,
+		},
+		Spec: cagipv1.QuotaPolicySpec{
+			DefaultClaimSpec: v1Core.ResourceList{
+				v1Core.ResourceCPU:    resource.MustParse("1"),
+				v1Core.ResourceMemory: resource.MustParse("2Gi"),
+			},
+			RatioOverCommitCPU: &ratioOverCommitCPU,
+		},
+	}
+}
+
+func TestQuotaPolicy(t *testing.T) {
+
+	t.Run("valid policy should be reported", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy(cagipv1.QuotaPolicyName)
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+		// Expected Actions
+		updated := policy.DeepCopy()
+		updated.Status = *newQuotaPolicyStatus(policy, nil, testEvaluationTime)
+		assert.Equal(t, updated.Status.Conditions[0].Status, metav1.ConditionTrue)
+		f.expectUpdateStatusQuotaPolicyAction(updated)
+
+		f.runPolicy(policy.Name)
+	})
+
+	t.Run("invalid policy should report its errors", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy(cagipv1.QuotaPolicyName)
+		ratio := float64(0)
+		policy.Spec.RatioMaxAllocationMemory = &ratio
+		policy.Spec.DefaultClaimSpec[v1Core.ResourceCPU] = resource.MustParse("-1")
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+		// Expected Actions
+		errs := []string{
+			"defaultClaimSpec.cpu must not be negative but is -1",
+			"ratioMaxAllocationMemory must be greater than 0 but is 0",
+		}
+		updated := policy.DeepCopy()
+		updated.Status = *newQuotaPolicyStatus(policy, errs, testEvaluationTime)
+		assert.Equal(t, updated.Status.Conditions[0].Reason, cagipv1.ReasonInvalidPolicy)
+		f.expectUpdateStatusQuotaPolicyAction(updated)
+
+		f.runPolicy(policy.Name)
+	})
+
+	t.Run("policy with another name should be ignored", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy("custom")
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+		// Expected Actions
+		updated := policy.DeepCopy()
+		updated.Status = *newQuotaPolicyStatus(policy, []string{"Only the QuotaPolicy named default is read by the controller"}, testEvaluationTime)
+		assert.Equal(t, updated.Status.Conditions[0].Reason, cagipv1.ReasonIgnoredPolicy)
+		f.expectUpdateStatusQuotaPolicyAction(updated)
+
+		f.runPolicy(policy.Name)
+	})
+
+	t.Run("status up to date should not be updated", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy(cagipv1.QuotaPolicyName)
+		policy.Status = *newQuotaPolicyStatus(policy, nil, metav1.NewTime(testEvaluationTime.Add(-time.Hour)))
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+
+		f.runPolicy(policy.Name)
+	})
+}
+
+func TestReloadSettings(t *testing.T) {
+
+	t.Run("valid policy should be applied", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy(cagipv1.QuotaPolicyName)
+		policy.Status = *newQuotaPolicyStatus(policy, nil, testEvaluationTime)
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+
+		c := f.runPolicy(policy.Name)
+
+		settings := c.withSettings().settings
+		assert.Equal(t, settings.RatioOverCommitCPU, 1.5)
+		assert.Equal(t, settings.DefaultClaimSpec.Memory().String(), "2Gi")
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 0)
+	})
+
+	t.Run("invalid policy should fall back on the configmap", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy(cagipv1.QuotaPolicyName)
+		ratio := float64(0)
+		policy.Spec.RatioOverCommitCPU = &ratio
+		policy.Status = *newQuotaPolicyStatus(policy, []string{"ratioOverCommitCPU must be greater than 0 but is 0"}, testEvaluationTime)
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+		f.configMapLister = append(f.configMapLister, &v1Core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: utils.ConfigMapName, Namespace: metav1.NamespaceSystem},
+			Data:       map[string]string{"ratioOverCommitCPU": "1.2"},
+		})
+
+		c := f.runPolicy(policy.Name)
+
+		assert.Equal(t, c.withSettings().settings.RatioOverCommitCPU, 1.2)
+	})
+
+	t.Run("looser policy should requeue the rejected, pending and awaiting approval claims", func(t *testing.T) {
+		f := newFixture(t)
+		policy := newTestQuotaPolicy(cagipv1.QuotaPolicyName)
+		policy.Spec.RequeueOnLooserPolicy = true
+		policy.Status = *newQuotaPolicyStatus(policy, nil, testEvaluationTime)
+		f.quotaPolicyLister = append(f.quotaPolicyLister, policy)
+		f.rqcobjects = append(f.rqcobjects, policy)
+		for _, phase := range []string{cagipv1.PhaseRejected, cagipv1.PhasePending, cagipv1.PhaseAwaitingApproval, cagipv1.PhaseAccepted} {
+			claim := newTestResourceQuotaClaim(strings.ToLower(phase), &v1Core.ResourceList{})
+			claim.Status.Phase = phase
+			f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		}
+
+		c := f.runPolicy(policy.Name)
+
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 3)
+	})
+
+	t.Run("claim sync should keep its snapshot of the settings", func(t *testing.T) {
+		f := newFixture(t)
+		c, _, _, _, _, _ := f.newController()
+
+		snapshot := c.withSettings()
+		reloaded := f.settings
+		reloaded.RatioOverCommitCPU = 2
+		c.currentSettings.Store(&reloaded)
+
+		assert.Equal(t, snapshot.settings.RatioOverCommitCPU, float64(1))
+		assert.Equal(t, c.withSettings().settings.RatioOverCommitCPU, float64(2))
+	})
+}
+
+func TestConcurrentClaims(t *testing.T) {
+
+	t.Run("1 Node 8Gi 1CPU - 10 concurrent Claims 1Gi 300m - Capacity never exceeded", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Claims in distinct namespaces, evaluated before the informer observes any quota
+		var keys []string
+		for i := 0; i < 10; i++ {
+			claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+				v1Core.ResourceCPU:    resource.MustParse("300m"),
+				v1Core.ResourceMemory: resource.MustParse("1Gi"),
+			})
+			claim.Namespace = fmt.Sprintf("team-%d", i)
+			f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+			f.rqcobjects = append(f.rqcobjects, claim)
+			keys = append(keys, getClaimKey(claim, t))
+		}
+		c, _, _, _, _, _ := f.newController()
+
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				assert.NilError(t, c.syncHandlerClaim(key))
+			}(key)
+		}
+		wg.Wait()
+
+		// Only the claims fitting in the capacity have been granted a quota
+		quotas, err := f.resourcequotaclientset.CoreV1().ResourceQuotas(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		total := v1Core.ResourceList{}
+		for _, resourceQuota := range quotas.Items {
+			total = quota.Add(total, resourceQuota.Spec.Hard)
+		}
+		assert.Equal(t, len(quotas.Items), 3)
+		assert.Assert(t, total.Cpu().Cmp(resource.MustParse("1")) <= 0, "granted %s CPU", total.Cpu().String())
+		assert.Assert(t, total.Memory().Cmp(resource.MustParse("8Gi")) <= 0, "granted %s Memory", total.Memory().String())
+	})
+
+	t.Run("reservation should be dropped once the informer observed it", func(t *testing.T) {
+		ledger := newReservationLedger()
+		now := testEvaluationTime.Time
+		spec := v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("300m")}
+		ledger.record("team-1", spec, now)
+		ledger.record("team-2", spec, now)
+
+		cached := []*v1Core.ResourceQuota{newTestResourceQuota("team-1", utils.ResourceQuotaName, &spec)}
+		assert.DeepEqual(t, ledger.pending(cached, now), map[string]v1Core.ResourceList{"team-2": spec})
+		assert.Equal(t, len(ledger.pending(nil, now.Add(reservationTTL+time.Second))), 0)
+	})
+}
+
+func TestClaimPending(t *testing.T) {
+	t.Run("1 Node 16Gi 4CPU - Claim 5Gi 600m - Request 8Gi 750m - Should be Pending Memory", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+		})
+		// Existing Quota
+		managedQuota := &v1Core.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      utils.ResourceQuotaName,
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: v1Core.ResourceQuotaSpec{
+				Hard: v1Core.ResourceList{
+					v1Core.ResourceCPU:    resource.MustParse("800m"),
+					v1Core.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		}
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+
+		// Scheduled Pods
+		pods := newTestPods(4, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("250m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		}, &v1Core.PodStatus{
+			Phase: "Running",
+		})
+		// Scheduled Pods that should be filtered out, because they are not in "Running or Pending" state
+		stoppedPods := newTestPods(5, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("250m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		}, &v1Core.PodStatus{
+			Phase: "Stopped",
+		})
+
+		for _, stoppedPod := range stoppedPods {
+			pods = append(pods, stoppedPod)
+		}
+
+		// Keep only pods in "Running or Pending" state
+		pods = utils.FilterRunningPods(pods)
+
+		f.podLister = pods
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("600m"),
+			v1Core.ResourceMemory: resource.MustParse("5Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newTestPendingClaimStatus(claim, "Awaiting lower Memory consumption claiming 5Gi but current total of request is 8Gi", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("1 Node 16Gi 4CPU - Claim 6Gi 600m - Request 6Gi 750m - Should be Pending CPU", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+		})
+		// Existing Quota
+		managedQuota := &v1Core.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      utils.ResourceQuotaName,
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: v1Core.ResourceQuotaSpec{
+				Hard: v1Core.ResourceList{
+					v1Core.ResourceCPU:    resource.MustParse("800m"),
+					v1Core.ResourceMemory: resource.MustParse("6Gi"),
+				},
+			},
+		}
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// Scheduled Pods
+		pods := newTestPods(3, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("250m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		}, &v1Core.PodStatus{
+			Phase: "Running",
+		})
+		f.podLister = pods
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("600m"),
+			v1Core.ResourceMemory: resource.MustParse("6Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newTestPendingClaimStatus(claim, "Awaiting lower CPU consumption claiming 600m but current total of CPU request is 750m", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("1 Node 16Gi 4CPU - Claim 4Gi 2CPU - Pending Pod with init container 3Gi and Running Pod 1Gi - Should be Pending Memory", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+		})
+		// Existing Quota
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("2"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// A Pending Pod is charged as soon as it is created, with its largest init container
+		pods := newTestPods(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("250m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		}, &v1Core.PodStatus{
+			Phase: v1Core.PodPending,
+		})
+		pods[0].Spec.InitContainers = []v1Core.Container{{
+			Name: "init",
+			Resources: v1Core.ResourceRequirements{
+				Requests: v1Core.ResourceList{v1Core.ResourceMemory: resource.MustParse("3Gi")},
+			},
+		}}
+		running := newTestPods(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("250m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		}, &v1Core.PodStatus{
+			Phase: v1Core.PodRunning,
+		})
+		running[0].Name = "pod-running"
+		f.podLister = append(pods, running...)
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("2"),
+			v1Core.ResourceMemory: resource.MustParse("3Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newTestPendingClaimStatus(claim, "Awaiting lower Memory consumption claiming 3Gi but current total of request is 4Gi", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("1 Node 16Gi 4CPU - Claim 4Gi 2CPU - Running Pod 1Gi resized to 5Gi - Should be Pending Memory", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+		})
+		// Existing Quota
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("2"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		// The requests actually allocated to the container are reported in its status
+		pods := newTestPods(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("250m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		}, &v1Core.PodStatus{
+			Phase: v1Core.PodRunning,
+			ContainerStatuses: []v1Core.ContainerStatus{{
+				Resources: &v1Core.ResourceRequirements{
+					Requests: v1Core.ResourceList{
+						v1Core.ResourceCPU:    resource.MustParse("250m"),
+						v1Core.ResourceMemory: resource.MustParse("5Gi"),
+					},
+				},
+			}},
+		})
+		f.podLister = pods
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("2"),
+			v1Core.ResourceMemory: resource.MustParse("4Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newTestPendingClaimStatus(claim, "Awaiting lower Memory consumption claiming 4Gi but current total of request is 5Gi", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+}
+
+func TestPendingClaimRequeue(t *testing.T) {
+	managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("2"),
+		v1Core.ResourceMemory: resource.MustParse("8Gi"),
+	})
+	requests := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("250m"),
+		v1Core.ResourceMemory: resource.MustParse("2Gi"),
+	}
+	claimSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("2"),
+		v1Core.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	pendingMsg := "Awaiting lower Memory consumption claiming 1Gi but current total of request is 2Gi"
+
+	t.Run("claim pending for longer than the timeout should be rejected", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.PendingTimeout = time.Hour
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+		})
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.podLister = newTestPods(1, requests, &v1Core.PodStatus{Phase: v1Core.PodRunning})
+		// Pending claim
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestPendingClaimStatus(claim, pendingMsg, metav1.NewTime(testEvaluationTime.Add(-2*time.Hour)))
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonPendingTimeout,
+			"Still pending after 1h0m0s: "+pendingMsg, testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim still pending should keep the time it started to wait", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.PendingTimeout = 3 * time.Hour
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("16Gi"),
+		})
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.podLister = newTestPods(1, requests, &v1Core.PodStatus{Phase: v1Core.PodRunning})
+		// Pending claim
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		pendingSince := metav1.NewTime(testEvaluationTime.Add(-2 * time.Hour))
+		claim.Status = newTestPendingClaimStatus(claim, pendingMsg, pendingSince)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		pendingClaim := claim.DeepCopy()
+		pendingClaim.Status = newTestPendingClaimStatus(claim, pendingMsg, pendingSince)
+		f.expectUpdateStatusResourceQuotaClaimAction(pendingClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("pending claim should be requeued after the interval or when it times out", func(t *testing.T) {
+		f := newFixture(t)
+		c, _, _, _, _, _ := f.newController()
+
+		// Only pod events evaluate the claim again
+		assert.Equal(t, c.pendingRequeueDelay(testEvaluationTime.Time), time.Duration(0))
+
+		c.settings.PendingRequeueInterval = 5 * time.Minute
+		assert.Equal(t, c.pendingRequeueDelay(testEvaluationTime.Time), 5*time.Minute)
+
+		c.settings.PendingTimeout = time.Hour
+		assert.Equal(t, c.pendingRequeueDelay(testEvaluationTime.Add(-10*time.Minute)), 5*time.Minute)
+		assert.Equal(t, c.pendingRequeueDelay(testEvaluationTime.Add(-58*time.Minute)), 2*time.Minute)
+
+		c.settings.PendingRequeueInterval = 0
+		assert.Equal(t, c.pendingRequeueDelay(testEvaluationTime.Add(-10*time.Minute)), 50*time.Minute)
+	})
+
+	t.Run("pod update lowering the usage should enqueue the pending claims", func(t *testing.T) {
+		f := newFixture(t)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestPendingClaimStatus(claim, pendingMsg, testEvaluationTime)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		c, _, _, _, _, _ := f.newController()
+
+		running := newTestPods(1, requests, &v1Core.PodStatus{Phase: v1Core.PodRunning})[0]
+		running.ResourceVersion = "1"
+
+		// Labels do not change the usage
+		relabeled := running.DeepCopy()
+		relabeled.ResourceVersion = "2"
+		relabeled.Labels = map[string]string{"app": "test"}
+		c.handlePodUpdate(running, relabeled)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 0)
+
+		// A completed pod is not charged anymore
+		succeeded := running.DeepCopy()
+		succeeded.ResourceVersion = "3"
+		succeeded.Status.Phase = v1Core.PodSucceeded
+		c.handlePodUpdate(running, succeeded)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 1)
+	})
+}
+
+// Status of a claim waiting for capacity since a given time
+func newTestWaitingClaimStatus(claim *cagipv1.ResourceQuotaClaim, details string, waitingSince metav1.Time, position int32) cagipv1.ResourceQuotaClaimStatus {
+	status := newResourceQuotaClaimStatus(claim, cagipv1.PhaseWaiting, cagipv1.ReasonAwaitingCapacity, details, testEvaluationTime)
+	status.WaitingSince = &waitingSince
+	status.QueuePosition = position
+	return status
+}
+
+func TestClaimWaiting(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("1"),
+		v1Core.ResourceMemory: resource.MustParse("8Gi"),
+	}
+	otherQuota := newTestResourceQuota("otherns", "managed", &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("800m"),
+		v1Core.ResourceMemory: resource.MustParse("6Gi"),
+	})
+	claimSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("100m"),
+		v1Core.ResourceMemory: resource.MustParse("2.50Gi"),
+	}
+	waitingMsg := "Waiting for capacity at position 1 of the queue: Not enough Memory claiming 2560Mi but 2Gi currently available"
+
+	t.Run("claim that does not fit should wait for capacity when the queue is enabled", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.WaitForCapacity = true
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, otherQuota)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		waitingClaim := claim.DeepCopy()
+		waitingClaim.Status = newTestWaitingClaimStatus(claim, waitingMsg, testEvaluationTime, 1)
+		f.expectUpdateStatusResourceQuotaClaimAction(waitingClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim still waiting should keep its place in the queue", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.WaitForCapacity = true
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, otherQuota)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		waitingSince := metav1.NewTime(testEvaluationTime.Add(-time.Hour))
+		claim.Status = newTestWaitingClaimStatus(claim, waitingMsg, waitingSince, 1)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		waitingClaim := claim.DeepCopy()
+		waitingClaim.Status = newTestWaitingClaimStatus(claim, waitingMsg, waitingSince, 1)
+		f.expectUpdateStatusResourceQuotaClaimAction(waitingClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim that fits should wait behind the claims that waited longer", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.WaitForCapacity = true
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		// Claim of another namespace waiting for more than the free capacity
+		head := newTestResourceQuotaClaim("head", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("20Gi"),
+		})
+		head.Namespace = "otherns"
+		head.Status = newTestWaitingClaimStatus(head, waitingMsg, metav1.NewTime(testEvaluationTime.Add(-time.Hour)), 1)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, head, claim)
+		f.rqcobjects = append(f.rqcobjects, head, claim)
+		// Expected Status
+		waitingClaim := claim.DeepCopy()
+		waitingClaim.Status = newTestWaitingClaimStatus(claim, "Waiting for capacity at position 2 of the queue behind claim otherns/head", testEvaluationTime, 2)
+		f.expectUpdateStatusResourceQuotaClaimAction(waitingClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim at the head of the queue should be accepted once it fits", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.WaitForCapacity = true
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestWaitingClaimStatus(claim, waitingMsg, metav1.NewTime(testEvaluationTime.Add(-time.Hour)), 1)
+		// Claim that joined the queue after it
+		next := newTestResourceQuotaClaim("next", claimSpec)
+		next.Namespace = "otherns"
+		next.Status = newTestWaitingClaimStatus(next, waitingMsg, testEvaluationTime, 2)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim, next)
+		f.rqcobjects = append(f.rqcobjects, claim, next)
+		// Expected Actions
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim that does not fit should be rejected when the queue is disabled", func(t *testing.T) {
+		f := newFixture(t)
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, otherQuota)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestWaitingClaimStatus(claim, waitingMsg, testEvaluationTime, 1)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough Memory claiming 2560Mi but 2Gi currently available", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("released capacity should enqueue the waiting claims", func(t *testing.T) {
+		f := newFixture(t)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestWaitingClaimStatus(claim, waitingMsg, testEvaluationTime, 1)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		c, _, _, _, _, _ := f.newController()
+
+		// A raised quota does not release capacity
+		raised := otherQuota.DeepCopy()
+		raised.ResourceVersion = "2"
+		raised.Spec.Hard[v1Core.ResourceMemory] = resource.MustParse("7Gi")
+		c.handleResourceQuotaUpdate(otherQuota, raised)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 0)
+
+		// A lowered quota does
+		lowered := otherQuota.DeepCopy()
+		lowered.ResourceVersion = "3"
+		lowered.Spec.Hard[v1Core.ResourceMemory] = resource.MustParse("4Gi")
+		c.handleResourceQuotaUpdate(otherQuota, lowered)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 1)
+	})
+
+	t.Run("node providing more capacity should enqueue the waiting claims", func(t *testing.T) {
+		f := newFixture(t)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestWaitingClaimStatus(claim, waitingMsg, testEvaluationTime, 1)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		c, _, _, _, _, _ := f.newController()
+
+		node := newTestNodes(1, nodeSpec)[0]
+		node.ResourceVersion = "1"
+
+		// A node that is not ready does not provide capacity
+		notReady := node.DeepCopy()
+		notReady.ResourceVersion = "2"
+		notReady.Status.Conditions[0].Status = v1Core.ConditionFalse
+		c.handleNodeUpdate(node, notReady)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 0)
+
+		// It does once it is ready again
+		ready := node.DeepCopy()
+		ready.ResourceVersion = "3"
+		c.handleNodeUpdate(notReady, ready)
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 1)
+	})
+
+	t.Run("deleted waiting claim should enqueue the claims behind it", func(t *testing.T) {
+		f := newFixture(t)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		claim.Status = newTestWaitingClaimStatus(claim, waitingMsg, testEvaluationTime, 2)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		c, _, _, _, _, _ := f.newController()
+
+		c.handleClaimDelete(newTestResourceQuotaClaim("rejected", claimSpec))
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 0)
+
+		head := newTestResourceQuotaClaim("head", claimSpec)
+		head.Status = newTestWaitingClaimStatus(head, waitingMsg, testEvaluationTime, 1)
+		c.handleClaimDelete(cache.DeletedFinalStateUnknown{Key: "default/head", Obj: head})
+		assert.Equal(t, c.resourceQuotaClaimWorkQueue.Len(), 1)
+	})
+}
+
+func TestClaimPriority(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("1"),
+		v1Core.ResourceMemory: resource.MustParse("8Gi"),
+	}
+	claimSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("100m"),
+		v1Core.ResourceMemory: resource.MustParse("5Gi"),
+	}
+	newTestPriorityClaim := func(namespace string, name string, spec *v1Core.ResourceList, priority string) *cagipv1.ResourceQuotaClaim {
+		claim := newTestResourceQuotaClaim(name, spec)
+		claim.Namespace = namespace
+		if priority != "" {
+			claim.Annotations = map[string]string{cagipv1.AnnotationPriority: priority}
+		}
+		return claim
+	}
+
+	t.Run("priority should come from the claim, the namespace label or the tier", func(t *testing.T) {
+		f := newFixture(t)
+		f.namespaceLister = append(f.namespaceLister,
+			newTestNamespace("labeled", map[string]string{cagipv1.AnnotationPriority: "20", "environment": "production"}),
+			newTestNamespace("production", map[string]string{"environment": "production"}),
+			newTestNamespace("invalid", map[string]string{cagipv1.AnnotationPriority: "high"}))
+		f.settings.Tiers = []utils.Tier{{
+			Name:              "production",
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+			Settings:          utils.Config{Priority: 5},
+		}}
+		c, _, _, _, _, _ := f.newController()
+
+		priority, msg := c.claimPriority(newTestPriorityClaim("labeled", "test", claimSpec, "-3"))
+		assert.Equal(t, priority, int32(-3))
+		assert.Equal(t, msg, utils.EmptyMsg)
+		priority, _ = c.claimPriority(newTestPriorityClaim("labeled", "test", claimSpec, ""))
+		assert.Equal(t, priority, int32(20))
+		priority, _ = c.claimPriority(newTestPriorityClaim("production", "test", claimSpec, ""))
+		assert.Equal(t, priority, int32(5))
+		priority, _ = c.claimPriority(newTestPriorityClaim("invalid", "test", claimSpec, ""))
+		assert.Equal(t, priority, int32(0))
+		_, msg = c.claimPriority(newTestPriorityClaim("labeled", "test", claimSpec, "high"))
+		assert.Equal(t, msg, "Invalid cagip.github.com/priority annotation high, an integer is expected")
+	})
+
+	t.Run("claim with an invalid priority should be rejected", func(t *testing.T) {
+		f := newFixture(t)
+		claim := newTestPriorityClaim(metav1.NamespaceDefault, "test", claimSpec, "high")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInvalidPriority,
+			"Invalid cagip.github.com/priority annotation high, an integer is expected", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim should be collected during the batching window", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.AdmissionBatchWindow = time.Second
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status, the quota is not written before the batch is admitted
+		batchedClaim := claim.DeepCopy()
+		batchedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhasePending, cagipv1.ReasonAwaitingAdmission,
+			"Collected for admission, the claims received within 1s are admitted by priority", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(batchedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim evaluated by a worker should join the next batch", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.AdmissionBatchWindow = time.Second
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		claim := newTestResourceQuotaClaim("test", claimSpec)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		c, _, _, _, _, _ := f.newController()
+		key := getClaimKey(claim, t)
+		c.batch.add(key)
+
+		assert.Assert(t, c.evaluating.acquire(key))
+		assert.Equal(t, c.syncHandlerClaim(key), errClaimInProgress)
+		assert.NilError(t, c.syncHandlerClaim(admissionBatchKey))
+		assert.DeepEqual(t, c.batch.take(), []string{key})
+
+		c.evaluating.release(key)
+		assert.Assert(t, c.evaluating.acquire(key))
+	})
+
+	t.Run("batch should admit the claims of higher priority first", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.AdmissionBatchWindow = time.Second
+		f.settings.RatioMaxAllocationMemory = 1
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		low := newTestPriorityClaim(metav1.NamespaceDefault, "low", claimSpec, "")
+		high := newTestPriorityClaim("highns", "high", claimSpec, "10")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, low, high)
+		f.rqcobjects = append(f.rqcobjects, low, high)
+		f.batchedClaims = []string{getClaimKey(low, t), getClaimKey(high, t)}
+		// Expected Actions
+		f.expectCreateResourceQuotaAction(newResourceQuota(high))
+		f.expectDeleteResourceQuotaClaimAction(high)
+		rejectedClaim := low.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(low, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough Memory claiming 5Gi but 3Gi currently available", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+
+		f.runClaim(admissionBatchKey)
+	})
+
+	t.Run("claim of higher priority should not wait behind the claims of lower priority", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.WaitForCapacity = true
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		waiting := newTestPriorityClaim("otherns", "waiting", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("20Gi"),
+		}, "")
+		waiting.Status = newTestWaitingClaimStatus(waiting, "Waiting for capacity", metav1.NewTime(testEvaluationTime.Add(-time.Hour)), 1)
+		claim := newTestPriorityClaim(metav1.NamespaceDefault, "test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		}, "10")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, waiting, claim)
+		f.rqcobjects = append(f.rqcobjects, waiting, claim)
+		// Expected Actions
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("unused quota of a namespace of lower priority should be reclaimed", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.ReclaimUnusedQuota = true
+		f.settings.RatioMaxAllocationMemory = 1
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		otherQuota := newTestResourceQuota("otherns", utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("800m"),
+			v1Core.ResourceMemory: resource.MustParse("6Gi"),
+		})
+		f.resourceQuotaLister = append(f.resourceQuotaLister, otherQuota)
+		f.rqobjects = append(f.rqobjects, otherQuota)
+		f.podLister = newTestPods(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		}, &v1Core.PodStatus{Phase: v1Core.PodRunning})
+		f.podLister[0].Namespace = "otherns"
+		claim := newTestPriorityClaim(metav1.NamespaceDefault, "test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("4Gi"),
+		}, "10")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		reclaimedQuota := otherQuota.DeepCopy()
+		lowered := resource.MustParse("6Gi")
+		lowered.Sub(resource.MustParse("2Gi"))
+		reclaimedQuota.Spec.Hard[v1Core.ResourceMemory] = lowered
+		f.expectUpdateResourceQuotaAction(reclaimedQuota)
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("quota should not be reclaimed from a namespace of the same priority", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.ReclaimUnusedQuota = true
+		f.settings.RatioMaxAllocationMemory = 1
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, newTestResourceQuota("otherns", utils.ResourceQuotaName, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("800m"),
+			v1Core.ResourceMemory: resource.MustParse("6Gi"),
+		}))
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("100m"),
+			v1Core.ResourceMemory: resource.MustParse("4Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough Memory claiming 4Gi but 2Gi currently available", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+}
+
+func TestClaimApproval(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("4"),
+		v1Core.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	managedSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("1"),
+		v1Core.ResourceMemory: resource.MustParse("2Gi"),
+	}
+	newApprovalFixture := func(t *testing.T) *fixture {
+		f := newFixture(t)
+		f.settings.RatioMaxAllocationCPU = 1
+		f.settings.RatioMaxAllocationMemory = 1
+		f.settings.ApprovalGrowthRatio = 0.5
+		f.settings.ApprovalCPUThreshold = resource.MustParse("2")
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, managedSpec)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, managedQuota)
+		f.rqobjects = append(f.rqobjects, managedQuota)
+		return f
+	}
+	newTestApprovalClaim := func(spec *v1Core.ResourceList, decision string, approver string) *cagipv1.ResourceQuotaClaim {
+		claim := newTestResourceQuotaClaim("test", spec)
+		if decision != "" {
+			claim.Annotations = map[string]string{
+				cagipv1.AnnotationApproval:          decision,
+				cagipv1.AnnotationApprover:          approver,
+				cagipv1.AnnotationApprovalSignature: utils.SignApproval(testApprovalKey, claim, decision, approver),
+			}
+		}
+		return claim
+	}
+	largeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("1"),
+		v1Core.ResourceMemory: resource.MustParse("4Gi"),
+	}
+
+	t.Run("claim growing above the ratio should await approval", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(largeSpec, "", "")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAwaitingApproval, cagipv1.ReasonAwaitingApproval,
+			"Awaiting approval, memory grows from 2Gi to 4Gi, more than 50% without approval", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim awaiting approval without the claim webhook should report it", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		f.approvalWebhookDisabled = true
+		claim := newTestApprovalClaim(largeSpec, "", "")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAwaitingApproval, cagipv1.ReasonAwaitingApproval,
+			"Awaiting approval, memory grows from 2Gi to 4Gi, more than 50% without approval, no approver can decide while the claim webhook is disabled",
+			testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim growing the CPU above the threshold should await approval", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		f.settings.ApprovalGrowthRatio = 0
+		claim := newTestApprovalClaim(&v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("3500m"),
+			v1Core.ResourceMemory: resource.MustParse("2Gi"),
+		}, "", "")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAwaitingApproval, cagipv1.ReasonAwaitingApproval,
+			"Awaiting approval, CPU grows by 2500m, more than the 2 allowed without approval", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim that does not fit should be rejected before awaiting approval", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(&v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("20Gi"),
+		}, "", "")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded Memory allocation limit claiming 20Gi but limited to 16Gi", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("approval should only be required by the claims growing the quota", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		f.settings.RequireApproval = true
+		f.namespaceLister = append(f.namespaceLister,
+			newTestNamespace("production", map[string]string{"environment": "production"}))
+		f.settings.Tiers = []utils.Tier{{
+			Name:              "production",
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+			Settings:          utils.Config{RequireApproval: true},
+		}}
+		c, _, _, _, _, _ := f.newController()
+
+		msg, err := c.approvalRequirement(newTestApprovalClaim(managedSpec, "", ""))
+		assert.NilError(t, err)
+		assert.Equal(t, msg, utils.EmptyMsg)
+
+		claim := newTestApprovalClaim(largeSpec, "", "")
+		msg, err = c.approvalRequirement(claim)
+		assert.NilError(t, err)
+		assert.Equal(t, msg, "Awaiting approval, the claims of tier global require an approval")
+
+		// Namespaces without managed quota are compared to their default claim
+		claim.Namespace = "production"
+		msg, err = c.approvalRequirement(claim)
+		assert.NilError(t, err)
+		assert.Equal(t, msg, "Awaiting approval, the claims of tier production require an approval")
+	})
+
+	t.Run("approved claim should be accepted", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(largeSpec, cagipv1.ApprovalApproved, "alice")
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAwaitingApproval, cagipv1.ReasonAwaitingApproval,
+			"Awaiting approval, memory grows from 2Gi to 4Gi, more than 50% without approval", testEvaluationTime)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("approved claim should report its approver in GitOps mode", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		f.settings.KeepAcceptedClaims = true
+		claim := newTestApprovalClaim(largeSpec, cagipv1.ApprovalApproved, "alice")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Actions
+		f.expectUpdateResourceQuotaAction(newResourceQuota(claim))
+		acceptedClaim := claim.DeepCopy()
+		acceptedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, testEvaluationTime)
+		acceptedClaim.Status.Approval = &cagipv1.ClaimApproval{Decision: cagipv1.ApprovalApproved, Approver: "alice", Time: testEvaluationTime}
+		f.expectUpdateStatusResourceQuotaClaimAction(acceptedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("denied claim should be rejected", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(largeSpec, cagipv1.ApprovalDenied, "bob")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		deniedAt := metav1.NewTime(testEvaluationTime.Add(-time.Hour))
+		claim.Status.Approval = &cagipv1.ClaimApproval{Decision: cagipv1.ApprovalDenied, Approver: "bob", Time: deniedAt}
+		rejectedClaim := claim.DeepCopy()
+		rejectedClaim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonApprovalDenied, "Denied by bob", testEvaluationTime)
+		rejectedClaim.Status.Approval = &cagipv1.ClaimApproval{Decision: cagipv1.ApprovalDenied, Approver: "bob", Time: deniedAt}
+		f.expectUpdateStatusResourceQuotaClaimAction(rejectedClaim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim with an invalid approval should be rejected", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(largeSpec, "yes", "alice")
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInvalidApproval,
+			"Invalid cagip.github.com/approval annotation yes, approved or denied is expected along with the cagip.github.com/approver annotation", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("approval not recorded by the webhook should be rejected", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(largeSpec, cagipv1.ApprovalApproved, "alice")
+		delete(claim.Annotations, cagipv1.AnnotationApprovalSignature)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInvalidApproval,
+			"Decision approved of alice was not recorded by the claim webhook, an approver must set it again", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("approval signed for another spec should be rejected", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		claim := newTestApprovalClaim(managedSpec, cagipv1.ApprovalApproved, "alice")
+		claim.Spec = *largeSpec
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInvalidApproval,
+			"Decision approved of alice was not recorded by the claim webhook, an approver must set it again", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("approval copied onto a claim created again should be rejected", func(t *testing.T) {
+		f := newApprovalFixture(t)
+		deleted := newTestResourceQuotaClaim("test", largeSpec)
+		deleted.UID = "deleted-claim"
+		claim := newTestApprovalClaim(largeSpec, "", "")
+		claim.UID = "created-claim"
+		claim.Annotations = map[string]string{
+			cagipv1.AnnotationApproval:          cagipv1.ApprovalApproved,
+			cagipv1.AnnotationApprover:          "alice",
+			cagipv1.AnnotationApprovalSignature: utils.SignApproval(testApprovalKey, deleted, cagipv1.ApprovalApproved, "alice"),
+		}
 		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
 		f.rqcobjects = append(f.rqcobjects, claim)
 		// Expected Status
-		claim.Status.Phase = cagipv1.PhasePending
-		claim.Status.Details = "Awaiting lower CPU consumption claiming 600m but current total of CPU request is 750m"
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInvalidApproval,
+			"Decision approved of alice was not recorded by the claim webhook, an approver must set it again", testEvaluationTime)
 		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
 		f.runClaim(getClaimKey(claim, t))
@@ -954,8 +2737,8 @@ func TestClaimRejected(t *testing.T) {
 		f.rqcobjects = append(f.rqcobjects, claim)
 
 		// Expected Status
-		claim.Status.Phase = cagipv1.PhaseRejected
-		claim.Status.Details = "Exceeded Memory allocation limit claiming 10Gi but limited to 2.64Gi"
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded Memory allocation limit claiming 10Gi but limited to 2.64Gi", testEvaluationTime)
 		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
 		f.runClaim(getClaimKey(claim, t))
@@ -977,8 +2760,8 @@ func TestClaimRejected(t *testing.T) {
 		f.rqcobjects = append(f.rqcobjects, claim)
 
 		// Expected Status
-		claim.Status.Phase = cagipv1.PhaseRejected
-		claim.Status.Details = "Exceeded CPU allocation limit claiming 500m but limited to 330m"
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded CPU allocation limit claiming 500m but limited to 330m", testEvaluationTime)
 		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
 		f.runClaim(getClaimKey(claim, t))
@@ -1005,8 +2788,8 @@ func TestClaimRejected(t *testing.T) {
 		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
 		f.rqcobjects = append(f.rqcobjects, claim)
 		// Expected Status
-		claim.Status.Phase = cagipv1.PhaseRejected
-		claim.Status.Details = "Not enough Memory claiming 2560Mi but 2Gi currently available"
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough Memory claiming 2560Mi but 2Gi currently available", testEvaluationTime)
 		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
 		f.runClaim(getClaimKey(claim, t))
@@ -1033,8 +2816,35 @@ func TestClaimRejected(t *testing.T) {
 		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
 		f.rqcobjects = append(f.rqcobjects, claim)
 		// Expected Status
-		claim.Status.Phase = cagipv1.PhaseRejected
-		claim.Status.Details = "Not enough CPU claiming 300m but 200m currently available"
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough CPU claiming 300m but 200m currently available", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("1 Node 8Gi 1CPU - Claim 1.8Gi 300m - Not Enough CPU because of pods without quota", func(t *testing.T) {
+		f := newFixture(t)
+		// Nodes
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		// Pods of a namespace without quota (already reserved resource)
+		f.podLister = newTestUnquotedPods("kube-system", 2, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("400m"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		// Test against claim
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("300m"),
+			v1Core.ResourceMemory: resource.MustParse("1.8Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+		// Expected Status
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough CPU claiming 300m but 200m currently available", testEvaluationTime)
 		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
 		f.runClaim(getClaimKey(claim, t))
@@ -1059,8 +2869,8 @@ func TestClaimRejected(t *testing.T) {
 		f.rqcerrors = append(f.rqcerrors, reactorErr{verb: "update"})
 
 		// Expected Status
-		claim.Status.Phase = cagipv1.PhaseRejected
-		claim.Status.Details = "Exceeded Memory allocation limit claiming 10Gi but limited to 2.64Gi"
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded Memory allocation limit claiming 10Gi but limited to 2.64Gi", testEvaluationTime)
 		f.expectUpdateStatusResourceQuotaClaimAction(claim)
 
 		f.runClaimExpectError(getClaimKey(claim, t))
@@ -1093,6 +2903,41 @@ func TestAddDefaultClaimToNS(t *testing.T) {
 		f.runNS(getNSKey(ns, t))
 	})
 
+	t.Run("blank namespace in a tier should generate the default claim of the tier", func(t *testing.T) {
+		f := newFixture(t)
+		// Tier with a larger default claim
+		large := f.settings
+		large.DefaultClaimSpec = v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("12Gi"),
+		}
+		f.settings.Tiers = []utils.Tier{{
+			Name:              "large",
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "large"}},
+			Settings:          large,
+		}}
+		// Test against NS
+		ns := &v1Core.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: metav1.NamespaceDefault,
+				Labels: map[string]string{
+					"quota": "managed",
+					"tier":  "large",
+				},
+			},
+		}
+		f.namespaceLister = append(f.namespaceLister, ns)
+		f.nsobjects = append(f.nsobjects, ns)
+		// Expect Claim
+		expectedClaim := newTestResourceQuotaClaim("default", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("4"),
+			v1Core.ResourceMemory: resource.MustParse("12Gi"),
+		})
+		f.expectCreateResourceQuotaClaimAction(expectedClaim)
+
+		f.runNS(getNSKey(ns, t))
+	})
+
 	t.Run("blank namespace without target annotation should not generate default claim", func(t *testing.T) {
 		f := newFixture(t)
 		// Test against NS
@@ -1225,4 +3070,750 @@ func TestAddDefaultClaimToNS(t *testing.T) {
 		f.runNS(getNSKey(ns, t))
 	})
 
+	t.Run("namespace selected by the configured selector should generate default claim", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.NamespaceSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tenant", Operator: metav1.LabelSelectorOpExists},
+			},
+		}
+		// Test against NS
+		ns := &v1Core.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: metav1.NamespaceDefault,
+				Labels: map[string]string{
+					"tenant": "team-1",
+				},
+			},
+		}
+		f.namespaceLister = append(f.namespaceLister, ns)
+		f.nsobjects = append(f.nsobjects, ns)
+		// Expect Claim
+		expectedClaim := newTestResourceQuotaClaim("default", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("2"),
+			v1Core.ResourceMemory: resource.MustParse("6Gi"),
+		})
+		f.expectCreateResourceQuotaClaimAction(expectedClaim)
+
+		f.runNS(getNSKey(ns, t))
+	})
+
+	t.Run("excluded namespace with target annotation should not generate default claim", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.ExcludedNamespaces = []string{"def*"}
+		// Test against NS
+		ns := &v1Core.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: metav1.NamespaceDefault,
+				Labels: map[string]string{
+					"quota": "managed",
+				},
+			},
+		}
+		f.namespaceLister = append(f.namespaceLister, ns)
+		f.nsobjects = append(f.nsobjects, ns)
+
+		f.runNS(getNSKey(ns, t))
+	})
+
+}
+
+func TestQuotaDrift(t *testing.T) {
+	managedNS := &v1Core.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   metav1.NamespaceDefault,
+			Labels: map[string]string{"quota": "managed"},
+		},
+	}
+	acceptedSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("2"),
+		v1Core.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	acceptedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, acceptedSpec)
+	editedQuota := acceptedQuota.DeepCopy()
+	editedQuota.ResourceVersion = "2"
+	editedQuota.Spec.Hard[v1Core.ResourceMemory] = resource.MustParse("64Gi")
+
+	t.Run("only managed quotas drifting from their accepted spec should be enqueued", func(t *testing.T) {
+		f := newFixture(t)
+		c, _, _, _, _, _ := f.newController()
+
+		// The quotas the controller did not write since it started are adopted as they are
+		c.enqueueQuotaDrift(acceptedQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 0)
+		unknownQuota := newTestResourceQuota("team-a", utils.ResourceQuotaName, acceptedSpec)
+		c.enqueueQuotaDeletion(unknownQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 0)
+
+		otherQuota := editedQuota.DeepCopy()
+		otherQuota.Name = "other-quota"
+		c.enqueueQuotaDrift(otherQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 0)
+
+		c.enqueueQuotaDrift(editedQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 1)
+		c.enqueueQuotaDeletion(cache.DeletedFinalStateUnknown{Key: "default/managed-quota", Obj: acceptedQuota})
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 1)
+	})
+
+	t.Run("edit of the quota and of its annotations should not change the accepted spec", func(t *testing.T) {
+		f := newFixture(t)
+		c, _, _, _, _, _ := f.newController()
+		c.acceptedSpecs.record(metav1.NamespaceDefault, *acceptedSpec)
+
+		forgedQuota := editedQuota.DeepCopy()
+		forgedQuota.Annotations = map[string]string{"cagip.github.com/accepted-spec": `{"cpu":"2","memory":"64Gi"}`}
+		c.enqueueQuotaDrift(forgedQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 1)
+		accepted, found := c.acceptedSpecs.get(metav1.NamespaceDefault)
+		assert.Assert(t, found)
+		assert.Assert(t, quota.Equals(accepted, *acceptedSpec))
+	})
+
+	t.Run("edit made while the controller was down should be detected from the signed spec", func(t *testing.T) {
+		f := newFixture(t)
+		c, _, _, _, _, _ := f.newController()
+
+		signedQuota := editedQuota.DeepCopy()
+		signedQuota.Annotations = withAcceptedSpec(acceptedQuota).Annotations
+		c.enqueueQuotaDrift(signedQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 1)
+		accepted, found := c.acceptedSpecs.get(metav1.NamespaceDefault)
+		assert.Assert(t, found)
+		assert.Assert(t, quota.Equals(accepted, *acceptedSpec))
+	})
+
+	t.Run("standby replica should only repair the drifts once leading", func(t *testing.T) {
+		f := newFixture(t)
+		f.standby = true
+		// The leader accepted a spec, then the quota has been edited
+		signedQuota := editedQuota.DeepCopy()
+		signedQuota.Annotations = withAcceptedSpec(acceptedQuota).Annotations
+		f.resourceQuotaLister = append(f.resourceQuotaLister, signedQuota)
+		c, _, _, _, _, _ := f.newController()
+
+		c.enqueueQuotaDrift(withAcceptedSpec(acceptedQuota))
+		c.enqueueQuotaDrift(signedQuota)
+		c.enqueueQuotaDeletion(signedQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 0)
+		accepted, found := c.acceptedSpecs.get(metav1.NamespaceDefault)
+		assert.Assert(t, found)
+		assert.Assert(t, quota.Equals(accepted, *acceptedSpec))
+
+		c.leading.Store(true)
+		assert.NilError(t, c.requeueQuotaDrifts())
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 1)
+	})
+
+	t.Run("accepted claim should hold the accepted spec in GitOps mode", func(t *testing.T) {
+		f := newFixture(t)
+		claim := newTestResourceQuotaClaim("test", acceptedSpec)
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, testEvaluationTime)
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		c, _, _, _, _, _ := f.newController()
+
+		c.enqueueQuotaDrift(editedQuota)
+		assert.Equal(t, c.resourceQuotaWorkQueue.Len(), 1)
+	})
+
+	t.Run("edited managed quota should be restored", func(t *testing.T) {
+		f := newFixture(t)
+		f.namespaceLister = append(f.namespaceLister, managedNS)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, editedQuota)
+		f.rqobjects = append(f.rqobjects, editedQuota)
+
+		restoredQuota := editedQuota.DeepCopy()
+		restoredQuota.Spec.Hard = *acceptedSpec
+		f.expectUpdateResourceQuotaAction(restoredQuota)
+
+		f.runQuota(quotaDrift{namespace: metav1.NamespaceDefault}, acceptedSpec)
+	})
+
+	t.Run("managed quota of a namespace without the targeted label should be restored", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"quota": "managed"}}
+		unlabeled := managedNS.DeepCopy()
+		unlabeled.Labels = nil
+		f.namespaceLister = append(f.namespaceLister, unlabeled)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, editedQuota)
+		f.rqobjects = append(f.rqobjects, editedQuota)
+
+		restoredQuota := editedQuota.DeepCopy()
+		restoredQuota.Spec.Hard = *acceptedSpec
+		f.expectUpdateResourceQuotaAction(restoredQuota)
+
+		f.runQuota(quotaDrift{namespace: metav1.NamespaceDefault}, acceptedSpec)
+	})
+
+	t.Run("deleted managed quota should be recreated", func(t *testing.T) {
+		f := newFixture(t)
+		f.namespaceLister = append(f.namespaceLister, managedNS)
+
+		f.expectCreateResourceQuotaAction(acceptedQuota)
+
+		f.runQuota(quotaDrift{namespace: metav1.NamespaceDefault}, acceptedSpec)
+	})
+
+	t.Run("quota already restored should be left as is", func(t *testing.T) {
+		f := newFixture(t)
+		f.namespaceLister = append(f.namespaceLister, managedNS)
+		f.resourceQuotaLister = append(f.resourceQuotaLister, acceptedQuota)
+		f.rqobjects = append(f.rqobjects, acceptedQuota)
+
+		f.runQuota(quotaDrift{namespace: metav1.NamespaceDefault}, acceptedSpec)
+	})
+
+	t.Run("quota of a namespace being deleted should not be recreated", func(t *testing.T) {
+		f := newFixture(t)
+		terminating := managedNS.DeepCopy()
+		terminating.DeletionTimestamp = &testEvaluationTime
+		f.namespaceLister = append(f.namespaceLister, terminating)
+
+		f.runQuota(quotaDrift{namespace: metav1.NamespaceDefault}, acceptedSpec)
+	})
+}
+
+// Build a Karpenter NodePool as the dynamic client returns it
+func newTestNodePool(name string, limits map[string]interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	if limits != nil {
+		spec["limits"] = limits
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "karpenter.sh/v1",
+		"kind":       "NodePool",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       spec,
+	}}
+}
+
+func TestCapacityProviders(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("4"),
+		v1Core.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	staticBudget := cagipv1.CapacityProviderSpec{
+		Type: cagipv1.CapacityProviderStatic,
+		Resources: v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("10"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		},
+	}
+
+	t.Run("worker nodes should provide the capacity by default", func(t *testing.T) {
+		f := newFixture(t)
+		f.nodeLister = newTestNodes(2, nodeSpec)
+		c, _, _, _, _, _ := f.newController()
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "8")
+		assert.Equal(t, total.Memory().String(), "32Gi")
+	})
+
+	t.Run("capacities should be combined with their maximum or their sum", func(t *testing.T) {
+		f := newFixture(t)
+		f.nodeLister = newTestNodes(2, nodeSpec)
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderNodes}, staticBudget}
+		c, _, _, _, _, _ := f.newController()
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "10")
+		assert.Equal(t, total.Memory().String(), "32Gi")
+
+		c.settings.CapacityCombination = cagipv1.CapacityCombinationSum
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "18")
+		assert.Equal(t, total.Memory().String(), "40Gi")
+	})
+
+	t.Run("limits of the Karpenter NodePools should be summed", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderKarpenter}}
+		f.dynamicobjects = append(f.dynamicobjects,
+			newTestNodePool("general", map[string]interface{}{"cpu": int64(100), "memory": "400Gi"}),
+			newTestNodePool("gpu", map[string]interface{}{"cpu": "20", "nvidia.com/gpu": "8"}),
+			newTestNodePool("unbounded", nil))
+		c, _, _, _, _, _ := f.newController()
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "120")
+		assert.Equal(t, total.Memory().String(), "400Gi")
+		gpu := (*total)["nvidia.com/gpu"]
+		assert.Equal(t, gpu.String(), "8")
+	})
+
+	t.Run("cluster-autoscaler node groups should provide their maximum size", func(t *testing.T) {
+		for format, status := range map[string]string{
+			"yaml": `time: 2026-10-17 09:00:00.000000000 +0000 UTC
+autoscalerStatus: Running
+nodeGroups:
+- name: general
+  health:
+    status: Healthy
+    cloudProviderTarget: 2
+    minSize: 1
+    maxSize: 5
+- name: empty
+  health:
+    status: Healthy
+    cloudProviderTarget: 0
+    minSize: 0
+    maxSize: 10
+`,
+			"text": `Cluster-autoscaler status at 2026-10-17 09:00:00.000000000 +0000 UTC:
+Cluster-wide:
+  Health:      Healthy (ready=2 unready=0 notStarted=0 longNotStarted=0 registered=2 longUnregistered=0)
+
+NodeGroups:
+  Name:        general
+  Health:      Healthy (ready=2 unready=0 notStarted=0 longNotStarted=0 registered=2 longUnregistered=0 cloudProviderTarget=2 (minSize=1, maxSize=5))
+  Name:        empty
+  Health:      Healthy (ready=0 unready=0 notStarted=0 longNotStarted=0 registered=0 longUnregistered=0 cloudProviderTarget=0 (minSize=0, maxSize=10))
+`,
+		} {
+			t.Run(format, func(t *testing.T) {
+				f := newFixture(t)
+				f.nodeLister = newTestNodes(2, nodeSpec)
+				for _, node := range f.nodeLister {
+					node.Labels = map[string]string{"node-group": "general"}
+				}
+				f.nodeobjects = append(f.nodeobjects, &v1Core.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster-autoscaler-status", Namespace: "kube-system"},
+					Data:       map[string]string{"status": status},
+				})
+				f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderClusterAutoscaler, NodeGroupLabel: "node-group"}}
+				c, _, _, _, _, _ := f.newController()
+
+				total, err := c.totalCapacity()
+				assert.NilError(t, err)
+				assert.Equal(t, total.Cpu().String(), "20")
+				assert.Equal(t, total.Memory().String(), "80Gi")
+			})
+		}
+	})
+
+	t.Run("missing cluster-autoscaler status should fall back on the nodes", func(t *testing.T) {
+		f := newFixture(t)
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderClusterAutoscaler, NodeGroupLabel: "node-group"}}
+		c, _, _, _, _, _ := f.newController()
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "4")
+		assert.DeepEqual(t, c.capacityProviderFailures(), []string{
+			`Capacity of provider clusterAutoscaler unavailable (configmaps "cluster-autoscaler-status" not found), the capacity of the nodes is used`,
+		})
+	})
+
+	t.Run("live provider should be cached and keep its last known capacity when it fails", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderKarpenter}}
+		f.dynamicobjects = append(f.dynamicobjects, newTestNodePool("general", map[string]interface{}{"cpu": "100", "memory": "400Gi"}))
+		c, _, _, _, _, _ := f.newController()
+		fakeClock := c.clock.(*testingclock.FakeClock)
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "100")
+
+		f.dynamicclientset.PrependReactor("list", "nodepools", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+			return true, nil, fmt.Errorf("connection refused")
+		})
+		fakeClock.Step(capacityCacheTTL / 2)
+		_, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, len(f.dynamicclientset.Actions()), 1)
+
+		fakeClock.Step(capacityCacheTTL)
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "100")
+		assert.Equal(t, len(f.dynamicclientset.Actions()), 2)
+		assert.DeepEqual(t, c.capacityProviderFailures(), []string{
+			"Capacity of provider karpenter unavailable (connection refused), its capacity known at " + testEvaluationTime.UTC().Format(time.RFC3339) + " is used",
+		})
+	})
+
+	t.Run("claim beyond the nodes should be accepted within the NodePool limits", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.RatioMaxAllocationCPU = 1
+		f.settings.RatioMaxAllocationMemory = 1
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderNodes}, {Type: cagipv1.CapacityProviderKarpenter}}
+		f.nodeLister = newTestNodes(1, nodeSpec)
+		f.dynamicobjects = append(f.dynamicobjects, newTestNodePool("general", map[string]interface{}{"cpu": "100", "memory": "400Gi"}))
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("40"),
+			v1Core.ResourceMemory: resource.MustParse("100Gi"),
+		})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("nodes excluded by the eligibility settings should not provide capacity", func(t *testing.T) {
+		f := newFixture(t)
+		f.nodeLister = newTestNodes(4, nodeSpec)
+		f.nodeLister[1].Spec.Taints = []v1Core.Taint{{Key: "nvidia.com/gpu", Effect: v1Core.TaintEffectNoSchedule}}
+		f.nodeLister[2].Labels = map[string]string{"node-role.kubernetes.io/infra": ""}
+		f.nodeLister[3].Spec.Taints = []v1Core.Taint{{Key: "dedicated", Value: "ingress", Effect: v1Core.TaintEffectNoExecute}}
+		f.settings.NodeEligibility = cagipv1.NodeEligibilitySpec{
+			ExcludedTaints:           []v1Core.Taint{{Key: "nvidia.com/gpu"}},
+			ExcludedRoleLabels:       []string{"node-role.kubernetes.io/infra"},
+			ExcludeUntoleratedTaints: true,
+		}
+		c, _, _, _, _, _ := f.newController()
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "4")
+		assert.Equal(t, total.Memory().String(), "16Gi")
+		assert.Equal(t, testutil.ToFloat64(utils.CapacityNodesGauge.WithLabelValues("worker-0", utils.NodeEligible)), float64(1))
+		assert.Equal(t, testutil.ToFloat64(utils.CapacityNodesGauge.WithLabelValues("worker-1", utils.NodeExcludedTaint)), float64(1))
+		assert.Equal(t, testutil.ToFloat64(utils.CapacityNodesGauge.WithLabelValues("worker-2", utils.NodeExcludedRole)), float64(1))
+		assert.Equal(t, testutil.ToFloat64(utils.CapacityNodesGauge.WithLabelValues("worker-3", utils.NodeUntoleratedTaint)), float64(1))
+
+		// Tolerating the taint brings the node back
+		c.settings.NodeEligibility.Tolerations = []v1Core.Toleration{{Key: "dedicated", Operator: v1Core.TolerationOpEqual, Value: "ingress"}}
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "8")
+	})
+}
+
+func TestNodePools(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("4"),
+		v1Core.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	highmem := &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}}
+	newPoolFixture := func(t *testing.T) *fixture {
+		f := newFixture(t)
+		f.settings.RatioMaxAllocationCPU = 1
+		f.settings.RatioMaxAllocationMemory = 1
+		f.settings.NodePools = []utils.NodePool{{Name: "highmem", NodeSelector: highmem, NamespaceSelector: highmem}}
+		f.nodeLister = newTestNodes(4, nodeSpec)
+		f.nodeLister[0].Labels = map[string]string{"pool": "general"}
+		f.nodeLister[1].Labels = map[string]string{"pool": "general"}
+		f.nodeLister[2].Labels = map[string]string{"pool": "highmem"}
+		f.nodeLister[3].Labels = map[string]string{"pool": "highmem"}
+		f.namespaceLister = append(f.namespaceLister,
+			newTestNamespace(metav1.NamespaceDefault, map[string]string{"pool": "highmem"}),
+			newTestNamespace("analytics", map[string]string{"pool": "highmem"}),
+			newTestNamespace("batch", nil))
+		f.resourceQuotaLister = append(f.resourceQuotaLister,
+			newTestResourceQuota("analytics", utils.ResourceQuotaName, &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("4")}),
+			newTestResourceQuota("batch", utils.ResourceQuotaName, &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("6")}))
+		return f
+	}
+
+	t.Run("claim should be limited by the capacity of the node pool", func(t *testing.T) {
+		f := newPoolFixture(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("10")})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonAllocationLimitExceeded,
+			"Exceeded CPU allocation limit claiming 10 but limited to 8", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim should only be reserved against the namespaces of its node pool", func(t *testing.T) {
+		f := newPoolFixture(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("4")})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		f.expectCreateResourceQuotaAction(newResourceQuota(claim))
+		f.expectDeleteResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim exceeding the free capacity of its node pool should be rejected", func(t *testing.T) {
+		f := newPoolFixture(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("5")})
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough CPU claiming 5 but 4 currently available", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+
+	t.Run("claim of a namespace bound to no pool should be evaluated against the whole cluster", func(t *testing.T) {
+		f := newPoolFixture(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("13")})
+		claim.Namespace = "batch"
+		f.resourceQuotaClaimLister = append(f.resourceQuotaClaimLister, claim)
+		f.rqcobjects = append(f.rqcobjects, claim)
+
+		claim.Status = newResourceQuotaClaimStatus(claim, cagipv1.PhaseRejected, cagipv1.ReasonInsufficientCapacity,
+			"Not enough CPU claiming 13 but 12 currently available", testEvaluationTime)
+		f.expectUpdateStatusResourceQuotaClaimAction(claim)
+
+		f.runClaim(getClaimKey(claim, t))
+	})
+}
+
+func TestCapacitySmoothing(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("4"),
+		v1Core.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	maintenanceAnnotation := "cagip.github.com/maintenance"
+
+	t.Run("cordoned node in maintenance should keep providing capacity", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.MaintenanceAnnotation = maintenanceAnnotation
+		f.nodeLister = newTestNodes(3, nodeSpec)
+		f.nodeLister[1].Spec.Unschedulable = true
+		f.nodeLister[1].Annotations = map[string]string{maintenanceAnnotation: "upgrade"}
+		f.nodeLister[2].Spec.Unschedulable = true
+		c, _, _, _, _, _ := f.newController()
+		smoothed := testutil.ToFloat64(utils.CapacitySmoothedCounter.WithLabelValues("cluster"))
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "8")
+		assert.Equal(t, testutil.ToFloat64(utils.CapacityNodesGauge.WithLabelValues("worker-1", utils.NodeMaintenance)), float64(1))
+		assert.Equal(t, testutil.ToFloat64(utils.CapacityNodesGauge.WithLabelValues("worker-2", utils.NodeUnschedulable)), float64(1))
+		assert.Equal(t, testutil.ToFloat64(utils.SmoothedCapacityGauge.WithLabelValues("cluster", "cpu", "raw")), float64(4))
+		assert.Equal(t, testutil.ToFloat64(utils.CapacitySmoothedCounter.WithLabelValues("cluster")), smoothed+1)
+	})
+
+	t.Run("largest capacity seen over the window should be used", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.CapacitySmoothingWindow = 10 * time.Minute
+		f.nodeLister = newTestNodes(2, nodeSpec)
+		c, _, nodeI, _, _, _ := f.newController()
+		clock := c.clock.(*testingclock.FakeClock)
+
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "8")
+
+		// The node is drained during the window
+		cordoned := f.nodeLister[1].DeepCopy()
+		cordoned.Spec.Unschedulable = true
+		assert.NilError(t, nodeI.Core().V1().Nodes().Informer().GetIndexer().Update(cordoned))
+		clock.Step(5 * time.Minute)
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "8")
+		assert.Equal(t, testutil.ToFloat64(utils.SmoothedCapacityGauge.WithLabelValues("cluster", "cpu", "raw")), float64(4))
+
+		// The capacity seen before the window is forgotten
+		clock.Step(6 * time.Minute)
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "4")
+	})
+
+	t.Run("capacity seen before a drain should be kept without any evaluation", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.CapacitySmoothingWindow = 10 * time.Minute
+		f.nodeLister = newTestNodes(3, nodeSpec)
+		c, _, nodeI, _, _, _ := f.newController()
+		clock := c.clock.(*testingclock.FakeClock)
+		indexer := nodeI.Core().V1().Nodes().Informer().GetIndexer()
+
+		// The node change records the capacity seen before it
+		cordoned := f.nodeLister[1].DeepCopy()
+		cordoned.Spec.Unschedulable = true
+		assert.NilError(t, indexer.Update(cordoned))
+		c.handleNodeChange(f.nodeLister[1], cordoned)
+
+		// The periodic sample records the capacity seen before the node is deleted without any event
+		clock.Step(time.Minute)
+		c.withSettings().recordCapacitySamples()
+		assert.NilError(t, indexer.Delete(f.nodeLister[2]))
+
+		clock.Step(time.Minute)
+		total, err := c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "12")
+
+		// The samples are forgotten after the window
+		clock.Step(8 * time.Minute)
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "8")
+		clock.Step(time.Minute)
+		total, err = c.totalCapacity()
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "4")
+	})
+
+	t.Run("node pools should be smoothed on their own", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.CapacitySmoothingWindow = 10 * time.Minute
+		highmem := &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}}
+		f.settings.NodePools = []utils.NodePool{{Name: "highmem", NodeSelector: highmem, NamespaceSelector: highmem}}
+		f.nodeLister = newTestNodes(2, nodeSpec)
+		f.nodeLister[1].Labels = map[string]string{"pool": "highmem"}
+		f.namespaceLister = append(f.namespaceLister, newTestNamespace(metav1.NamespaceDefault, map[string]string{"pool": "highmem"}))
+		c, _, _, _, _, _ := f.newController()
+		claim := newTestResourceQuotaClaim("test", nodeSpec)
+
+		_, err := c.totalCapacity()
+		assert.NilError(t, err)
+		total, err := c.claimCapacity(claim)
+		assert.NilError(t, err)
+		assert.Equal(t, total.Cpu().String(), "4")
+	})
+}
+
+func TestCapacityStatus(t *testing.T) {
+	nodeSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("4"),
+		v1Core.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	newCapacityFixture := func(t *testing.T) *fixture {
+		f := newFixture(t)
+		f.settings.RatioMaxAllocationCPU = 0.5
+		f.settings.RatioOverCommitCPU = 1.5
+		f.nodeLister = newTestNodes(3, nodeSpec)
+		f.namespaceLister = append(f.namespaceLister, newTestNamespace("analytics", nil), newTestNamespace("batch", nil))
+		f.resourceQuotaLister = append(f.resourceQuotaLister,
+			newTestResourceQuota("analytics", utils.ResourceQuotaName, &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("4")}))
+		f.podLister = append(f.podLister, newTestUnquotedPods("batch", 2, &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("500m")})...)
+		return f
+	}
+	getCapacity := func(t *testing.T, f *fixture) *cagipv1.QuotaCapacity {
+		capacity, err := f.resourcequotaclaimclientset.CagipV1().QuotaCapacities().Get(context.TODO(), cagipv1.QuotaCapacityName, metav1.GetOptions{})
+		assert.NilError(t, err)
+		return capacity
+	}
+
+	t.Run("status should report the capacity of the cluster", func(t *testing.T) {
+		f := newCapacityFixture(t)
+		c, _, _, _, _, _ := f.newController()
+
+		assert.NilError(t, c.syncHandlerCapacity())
+
+		status := getCapacity(t, f).Status
+		assert.Equal(t, status.Allocatable.Cpu().String(), "12")
+		assert.Equal(t, status.OverCommitted.Cpu().String(), "18")
+		assert.Equal(t, status.ReservedByQuotas.Cpu().String(), "4")
+		assert.Equal(t, status.ReservedByUnquoted.Cpu().String(), "1")
+		assert.Equal(t, status.Free.Cpu().String(), "13")
+		assert.Equal(t, status.MaxClaimable.Cpu().String(), "6")
+		assert.Equal(t, status.LastUpdateTime, testEvaluationTime)
+		condition := meta.FindStatusCondition(status.Conditions, cagipv1.ConditionOverCommitted)
+		assert.Equal(t, condition.Status, metav1.ConditionFalse)
+		assert.Equal(t, condition.Reason, cagipv1.ReasonReservedWithinAllocatable)
+	})
+
+	t.Run("unavailable provider should set the CapacityDegraded condition", func(t *testing.T) {
+		f := newCapacityFixture(t)
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderNodes}, {Type: cagipv1.CapacityProviderClusterAutoscaler, NodeGroupLabel: "node-group"}}
+		c, _, _, _, _, _ := f.newController()
+
+		assert.NilError(t, c.syncHandlerCapacity())
+
+		status := getCapacity(t, f).Status
+		assert.Equal(t, status.Allocatable.Cpu().String(), "12")
+		condition := meta.FindStatusCondition(status.Conditions, cagipv1.ConditionCapacityDegraded)
+		assert.Equal(t, condition.Status, metav1.ConditionTrue)
+		assert.Equal(t, condition.Reason, cagipv1.ReasonProviderUnavailable)
+		assert.Equal(t, condition.Message, `Capacity of provider clusterAutoscaler unavailable (configmaps "cluster-autoscaler-status" not found), the capacity of the nodes is used`)
+	})
+
+	t.Run("quotas reserved over the allocatable should set the OverCommitted condition", func(t *testing.T) {
+		f := newCapacityFixture(t)
+		f.resourceQuotaLister[0].Spec.Hard = v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("14")}
+		c, _, _, _, _, _ := f.newController()
+
+		assert.NilError(t, c.syncHandlerCapacity())
+
+		status := getCapacity(t, f).Status
+		assert.Equal(t, status.Free.Cpu().String(), "3")
+		assert.Equal(t, status.MaxClaimable.Cpu().String(), "3")
+		condition := meta.FindStatusCondition(status.Conditions, cagipv1.ConditionOverCommitted)
+		assert.Equal(t, condition.Status, metav1.ConditionTrue)
+		assert.Equal(t, condition.Reason, cagipv1.ReasonReservedOverAllocatable)
+		assert.Equal(t, condition.Message, "15 cpu reserved over the 12 allocatable on cluster")
+	})
+
+	t.Run("tiers should report what their namespaces can claim", func(t *testing.T) {
+		f := newCapacityFixture(t)
+		restricted := f.settings
+		restricted.RatioMaxAllocationCPU = 0.2
+		generous := f.settings
+		generous.RatioMaxAllocationCPU = 1
+		generous.RatioOverCommitCPU = 1
+		f.settings.Tiers = []utils.Tier{
+			{Name: "restricted", NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "restricted"}}, Settings: restricted},
+			{Name: "generous", NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "generous"}}, Settings: generous},
+		}
+		c, _, _, _, _, _ := f.newController()
+
+		assert.NilError(t, c.syncHandlerCapacity())
+
+		status := getCapacity(t, f).Status
+		assert.Equal(t, status.MaxClaimable.Cpu().String(), "6")
+		assert.Equal(t, len(status.Tiers), 2)
+		assert.Equal(t, status.Tiers[0].Name, "restricted")
+		assert.Equal(t, status.Tiers[0].MaxClaimable.Cpu().String(), "2400m")
+		// Without over-commit the tier only has the allocatable left
+		assert.Equal(t, status.Tiers[1].Name, "generous")
+		assert.Equal(t, status.Tiers[1].MaxClaimable.Cpu().String(), "7")
+	})
+
+	t.Run("node pools should be reported on their own", func(t *testing.T) {
+		f := newCapacityFixture(t)
+		highmem := &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}}
+		ratio := 1.0
+		f.settings.NodePools = []utils.NodePool{{Name: "highmem", NodeSelector: highmem, NamespaceSelector: highmem, RatioOverCommitCPU: &ratio}}
+		f.nodeLister[2].Labels = map[string]string{"pool": "highmem"}
+		f.namespaceLister[0].Labels = map[string]string{"pool": "highmem"}
+		c, _, _, _, _, _ := f.newController()
+
+		assert.NilError(t, c.syncHandlerCapacity())
+
+		status := getCapacity(t, f).Status
+		assert.Equal(t, status.Allocatable.Cpu().String(), "12")
+		assert.Equal(t, len(status.NodePools), 1)
+		pool := status.NodePools[0]
+		assert.Equal(t, pool.Name, "highmem")
+		assert.Equal(t, pool.Allocatable.Cpu().String(), "4")
+		assert.Equal(t, pool.OverCommitted.Cpu().String(), "4")
+		assert.Equal(t, pool.ReservedByQuotas.Cpu().String(), "4")
+		assert.Equal(t, pool.ReservedByUnquoted.Cpu().String(), "0")
+		assert.Equal(t, pool.Free.Cpu().String(), "0")
+		assert.Equal(t, pool.MaxClaimable.Cpu().String(), "0")
+	})
+
+	t.Run("status should only be updated when the capacity changes", func(t *testing.T) {
+		f := newCapacityFixture(t)
+		c, _, nodeI, _, _, rqcI := f.newController()
+		clock := c.clock.(*testingclock.FakeClock)
+		assert.NilError(t, c.syncHandlerCapacity())
+		assert.NilError(t, rqcI.Cagip().V1().QuotaCapacities().Informer().GetIndexer().Add(getCapacity(t, f)))
+
+		clock.Step(time.Minute)
+		assert.NilError(t, c.syncHandlerCapacity())
+		assert.Equal(t, getCapacity(t, f).Status.LastUpdateTime, testEvaluationTime)
+
+		cordoned := f.nodeLister[1].DeepCopy()
+		cordoned.Spec.Unschedulable = true
+		assert.NilError(t, nodeI.Core().V1().Nodes().Informer().GetIndexer().Update(cordoned))
+		assert.NilError(t, c.syncHandlerCapacity())
+		status := getCapacity(t, f).Status
+		assert.Equal(t, status.Allocatable.Cpu().String(), "8")
+		assert.Equal(t, status.LastUpdateTime, metav1.NewTime(clock.Now()))
+	})
 }
@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// Reason of the events recorded on a managed quota restored by the controller
+const reasonQuotaDriftRepaired = "DriftRepaired"
+
+// Labels of the drift metric
+const (
+	driftEdited  = "edited"
+	driftDeleted = "deleted"
+)
+
+// Work item of a managed quota to check against its accepted spec
+type quotaDrift struct {
+	namespace string
+}
+
+// Specs of the managed quotas accepted by the controller, keyed by namespace
+// They are persisted on the quotas with a signature, anyone able to edit a quota could forge them otherwise
+// The ledger keeps the last signed spec observed, a quota deleted or stripped of its annotations is still restored
+type acceptedSpecLedger struct {
+	mutex sync.Mutex
+	specs map[string]v1Core.ResourceList
+}
+
+// Create an empty ledger
+func newAcceptedSpecLedger() *acceptedSpecLedger {
+	return &acceptedSpecLedger{specs: map[string]v1Core.ResourceList{}}
+}
+
+// Record the spec the controller wrote on the managed quota of a namespace
+func (l *acceptedSpecLedger) record(namespace string, hard v1Core.ResourceList) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.specs[namespace] = hard.DeepCopy()
+}
+
+// Record the spec of a managed quota the controller did not write since it started, unless one is known already
+// Return the accepted spec of the namespace
+func (l *acceptedSpecLedger) adopt(namespace string, hard v1Core.ResourceList) v1Core.ResourceList {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if accepted, found := l.specs[namespace]; found {
+		return accepted.DeepCopy()
+	}
+	l.specs[namespace] = hard.DeepCopy()
+	return hard
+}
+
+// Return the accepted spec of the managed quota of a namespace
+func (l *acceptedSpecLedger) get(namespace string) (v1Core.ResourceList, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	accepted, found := l.specs[namespace]
+	return accepted.DeepCopy(), found
+}
+
+// Forget the accepted spec of a namespace that is deleted
+func (l *acceptedSpecLedger) forget(namespace string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.specs, namespace)
+}
+
+// Return the spec a managed quota is expected to have when the controller did not write it since it started
+// In GitOps mode the accepted claim of the namespace holds it, the quota observed is trusted otherwise
+func (c *Controller) initialAcceptedSpec(resourceQuota *v1Core.ResourceQuota) v1Core.ResourceList {
+	claims, err := c.resourceQuotaClaimLister.ResourceQuotaClaims(resourceQuota.Namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return resourceQuota.Spec.Hard
+	}
+
+	var accepted *cagipv1.ResourceQuotaClaim
+	for _, claim := range claims {
+		if claim.Status.Phase != cagipv1.PhaseAccepted || claim.Status.ObservedGeneration != claim.Generation || claim.Status.LastEvaluationTime == nil {
+			continue
+		}
+		if accepted == nil || accepted.Status.LastEvaluationTime.Before(claim.Status.LastEvaluationTime) {
+			accepted = claim
+		}
+	}
+	if accepted == nil {
+		return resourceQuota.Spec.Hard
+	}
+	return quota.Add(v1Core.ResourceList{}, accepted.Spec)
+}
+
+// Record on a managed quota about to be written the spec accepted by the controller
+func (c *Controller) annotateAcceptedSpec(resourceQuota *v1Core.ResourceQuota) {
+	spec, signature := utils.SignAcceptedSpec(c.approvalKey, resourceQuota.Namespace, resourceQuota.Spec.Hard)
+	if resourceQuota.Annotations == nil {
+		resourceQuota.Annotations = map[string]string{}
+	}
+	resourceQuota.Annotations[cagipv1.AnnotationAcceptedSpec] = spec
+	resourceQuota.Annotations[cagipv1.AnnotationAcceptedSpecSignature] = signature
+}
+
+// Return the managed quota of an informer event, nil for the other quotas
+func managedQuotaOf(obj interface{}) *v1Core.ResourceQuota {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	resourceQuota, ok := obj.(*v1Core.ResourceQuota)
+	if !ok || resourceQuota.Name != utils.ResourceQuotaName {
+		return nil
+	}
+	return resourceQuota
+}
+
+// enqueueQuotaDrift puts a managed quota on the work queue when its spec differs from the accepted one
+// Every replica records the accepted specs signed on the quotas, only the leader repairs the drifts
+func (c *Controller) enqueueQuotaDrift(obj interface{}) {
+	resourceQuota := managedQuotaOf(obj)
+	if resourceQuota == nil {
+		return
+	}
+	if signed, found := utils.VerifyAcceptedSpec(c.approvalKey, resourceQuota); found {
+		c.acceptedSpecs.record(resourceQuota.Namespace, signed)
+	}
+	if !c.leading.Load() {
+		return
+	}
+
+	accepted, found := c.acceptedSpecs.get(resourceQuota.Namespace)
+	if !found {
+		accepted = c.acceptedSpecs.adopt(resourceQuota.Namespace, c.initialAcceptedSpec(resourceQuota))
+	}
+	if quota.Equals(accepted, resourceQuota.Spec.Hard) {
+		return
+	}
+	c.resourceQuotaWorkQueue.Add(quotaDrift{namespace: resourceQuota.Namespace})
+}
+
+// enqueueQuotaDeletion puts a deleted managed quota on the work queue when its accepted spec is known
+func (c *Controller) enqueueQuotaDeletion(obj interface{}) {
+	resourceQuota := managedQuotaOf(obj)
+	if resourceQuota == nil || !c.leading.Load() {
+		return
+	}
+	if _, found := c.acceptedSpecs.get(resourceQuota.Namespace); !found {
+		return
+	}
+	c.resourceQuotaWorkQueue.Add(quotaDrift{namespace: resourceQuota.Namespace})
+}
+
+// Check the managed quotas against their accepted spec once the replica leads
+// The specs signed on the quotas are recorded first, the other quotas are adopted as the previous leader left them
+func (c *Controller) requeueQuotaDrifts() error {
+	resourceQuotas, err := c.resourceQuotaLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	for _, resourceQuota := range resourceQuotas {
+		c.enqueueQuotaDrift(resourceQuota)
+	}
+	return nil
+}
+
+// syncHandlerQuota restores the accepted spec of a managed quota edited or deleted outside of the controller
+// Any managed quota whose spec the controller accepted is repaired, whatever the labels of its namespace
+func (c *Controller) syncHandlerQuota(item quotaDrift) error {
+	ns, err := c.namespaceLister.Get(item.namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// The quota of a namespace being deleted is left as it is, a namespace created again with the same name starts over
+	if errors.IsNotFound(err) || ns.DeletionTimestamp != nil {
+		c.acceptedSpecs.forget(item.namespace)
+		return nil
+	}
+
+	accepted, found := c.acceptedSpecs.get(item.namespace)
+	if !found {
+		return nil
+	}
+
+	resourceQuota, err := c.resourceQuotaLister.ResourceQuotas(item.namespace).Get(utils.ResourceQuotaName)
+	if errors.IsNotFound(err) {
+		return c.recreateResourceQuota(item.namespace, accepted)
+	} else if err != nil {
+		return err
+	}
+	return c.restoreResourceQuota(resourceQuota, accepted)
+}
+
+// Write back the accepted spec of an edited managed quota
+// The update is made on the cached version, a quota written meanwhile by a claim is not overwritten
+func (c *Controller) restoreResourceQuota(resourceQuota *v1Core.ResourceQuota, accepted v1Core.ResourceList) error {
+	if quota.Equals(accepted, resourceQuota.Spec.Hard) {
+		return nil
+	}
+
+	restored := resourceQuota.DeepCopy()
+	restored.Spec.Hard = accepted
+	c.annotateAcceptedSpec(restored)
+	if _, err := c.resourcequotaclientset.CoreV1().ResourceQuotas(restored.Namespace).Update(context.TODO(), restored, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Could not restore the ResourceQuota of ns %s : %s", restored.Namespace, err)
+		return err
+	}
+	c.reservations.record(restored.Namespace, accepted, c.clock.Now())
+
+	msg := fmt.Sprintf(utils.MessageQuotaDriftRestored, formatResourceList(accepted), formatResourceList(resourceQuota.Spec.Hard))
+	c.recordQuotaDrift(restored, driftEdited, msg)
+	return nil
+}
+
+// Create again a deleted managed quota with its accepted spec
+func (c *Controller) recreateResourceQuota(namespace string, accepted v1Core.ResourceList) error {
+	resourceQuota := &v1Core.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.ResourceQuotaName,
+			Namespace: namespace,
+			Labels:    map[string]string{"creator": utils.ControllerName},
+		},
+		Spec: v1Core.ResourceQuotaSpec{Hard: accepted},
+	}
+	c.annotateAcceptedSpec(resourceQuota)
+
+	created, err := c.resourcequotaclientset.CoreV1().ResourceQuotas(namespace).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+	// A claim has been accepted meanwhile, its quota takes precedence
+	if errors.IsAlreadyExists(err) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Could not recreate the ResourceQuota of ns %s : %s", namespace, err)
+		return err
+	}
+	c.reservations.record(namespace, accepted, c.clock.Now())
+
+	c.recordQuotaDrift(created, driftDeleted, fmt.Sprintf(utils.MessageQuotaDriftRecreated, formatResourceList(accepted)))
+	return nil
+}
+
+// Notify the namespace of a repaired managed quota via an event and count it
+func (c *Controller) recordQuotaDrift(resourceQuota *v1Core.ResourceQuota, drift string, msg string) {
+	klog.Infof("< ResourceQuota of ns %s : %s >", resourceQuota.Namespace, msg)
+	c.recorder.Event(resourceQuota, v1Core.EventTypeWarning, reasonQuotaDriftRepaired, msg)
+	utils.QuotaDriftCounter.WithLabelValues(resourceQuota.Namespace, drift).Inc()
+}
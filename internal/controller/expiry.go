@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// Delay before a blocked revert is evaluated again when the pending claims are not requeued periodically
+const revertRequeueInterval = time.Minute
+
+// Handle a burst claim that has been accepted
+// Once expired the quota in place before the claim is restored, going through the downscale and capacity checks
+func (c *Controller) syncExpiringClaim(claim *cagipv1.ResourceQuotaClaim) error {
+
+	// Superseded and expired claims have nothing left to restore
+	if claim.Status.Phase != cagipv1.PhaseAccepted && claim.Status.Phase != cagipv1.PhasePending {
+		return nil
+	}
+
+	// The claim is evaluated again when it expires
+	if remaining := claim.Status.ExpiresAt.Sub(c.clock.Now()); remaining > 0 {
+		c.enqueueResourceQuotaClaimAfter(claim, remaining)
+		if c.settings.KeepAcceptedClaims && claim.Status.Phase == cagipv1.PhaseAccepted {
+			return c.updateResourceQuota(claim)
+		}
+		return nil
+	}
+
+	// The previous quota goes through the same checks as any downscale
+	revert := claim.DeepCopy()
+	revert.Spec = claim.Status.RevertTo
+
+	msg, err := c.checkDownscale(revert)
+	if err != nil {
+		return err
+	} else if msg != utils.EmptyMsg {
+		return c.claimRevertPending(claim, cagipv1.ReasonAwaitingLowerUsage, msg)
+	}
+
+	if msg, err = c.reserveRevert(revert); err != nil {
+		return err
+	} else if msg != utils.EmptyMsg {
+		return c.claimRevertPending(claim, cagipv1.ReasonInsufficientCapacity, msg)
+	}
+
+	klog.Infof("< RequestQuotaClaim '%s' EXPIRED >", claim.Name)
+	utils.ClaimCounter.WithLabelValues("expired").Inc()
+
+	if !c.settings.KeepAcceptedClaims {
+		// The claim is removed
+		return c.deleteResourceQuotaClaim(claim)
+	}
+
+	// The claim is kept to record the expiry
+	_, err = c.setResourceQuotaClaimStatus(claim, c.newBurstClaimStatus(claim, cagipv1.PhaseExpired, cagipv1.ReasonExpired,
+		fmt.Sprintf(utils.MessageExpired, claim.Status.ExpiresAt.Format(time.RFC3339)), claim.Status.ExpiresAt, claim.Status.RevertTo))
+	return err
+}
+
+// Restore the quota in place before a burst claim, serialized with the capacity decisions of the other claims
+// The resources it raises above the current managed quota must fit in the capacity left by the other namespaces
+// Return the msg of a previous quota that does not fit, an empty msg when the managed quota has been updated
+func (c *Controller) reserveRevert(revert *cagipv1.ResourceQuotaClaim) (string, error) {
+	c.reservations.decision.Lock()
+	defer c.reservations.decision.Unlock()
+
+	growth, err := c.quotaGrowth(revert)
+	if err != nil {
+		return utils.EmptyMsg, err
+	}
+
+	if len(growth.Spec) > 0 {
+		availableResources, err := c.claimCapacity(revert)
+		if err != nil {
+			return utils.EmptyMsg, err
+		}
+		quotaResources, err := c.totalResourceQuota(revert)
+		if err != nil {
+			return utils.EmptyMsg, err
+		}
+		unquotedResources, err := c.unquotedRequests(revert)
+		if err != nil {
+			return utils.EmptyMsg, err
+		}
+		reservedResources := quota.Add(*quotaResources, *unquotedResources)
+		if msg := c.checkResourceFit(growth, availableResources, &reservedResources); msg != utils.EmptyMsg {
+			return msg, nil
+		}
+	}
+
+	return utils.EmptyMsg, c.updateResourceQuota(revert)
+}
+
+// Return a copy of a claim only claiming the resources it raises above the current managed quota
+// Every resource is raised when the namespace does not have a managed quota
+func (c *Controller) quotaGrowth(claim *cagipv1.ResourceQuotaClaim) (*cagipv1.ResourceQuotaClaim, error) {
+	growth := claim.DeepCopy()
+	managedQuota, err := c.resourceQuotaLister.ResourceQuotas(claim.Namespace).Get(utils.ResourceQuotaName)
+	if errors.IsNotFound(err) {
+		return growth, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for name, claimed := range claim.Spec {
+		if current, found := managedQuota.Spec.Hard[name]; found && claimed.Cmp(current) <= 0 {
+			delete(growth.Spec, name)
+		}
+	}
+	return growth, nil
+}
+
+// Compute the expiry of a burst claim from its annotations
+// Return nil when the claim does not expire and a msg when the annotations are invalid
+func claimExpiry(claim *cagipv1.ResourceQuotaClaim, now time.Time) (*metav1.Time, string) {
+	var expiresAt time.Time
+
+	if value, found := claim.Annotations[cagipv1.AnnotationExpiresAt]; found {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Sprintf(utils.MessageInvalidExpiry, cagipv1.AnnotationExpiresAt, value)
+		}
+		expiresAt = parsed
+	} else if value, found := claim.Annotations[cagipv1.AnnotationDuration]; found {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Sprintf(utils.MessageInvalidExpiry, cagipv1.AnnotationDuration, value)
+		}
+		expiresAt = now.Add(duration)
+	} else {
+		return nil, utils.EmptyMsg
+	}
+
+	if !expiresAt.After(now) {
+		return nil, fmt.Sprintf(utils.MessageAlreadyExpired, expiresAt.Format(time.RFC3339))
+	}
+
+	expiry := metav1.NewTime(expiresAt)
+	return &expiry, utils.EmptyMsg
+}
+
+// Quota to restore once a burst claim of the namespace expires
+// A burst claim replacing an active one keeps the quota in place before the first burst
+func (c *Controller) burstRevertTo(claim *cagipv1.ResourceQuotaClaim) (v1Core.ResourceList, error) {
+	claims, err := c.resourceQuotaClaimLister.ResourceQuotaClaims(claim.Namespace).List(utils.DefaultLabelSelector())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range claims {
+		if other.Status.Phase == cagipv1.PhaseAccepted && other.Status.RevertTo != nil {
+			return other.Status.RevertTo.DeepCopy(), nil
+		}
+	}
+
+	managedQuota, err := c.resourceQuotaLister.ResourceQuotas(claim.Namespace).Get(utils.ResourceQuotaName)
+	if errors.IsNotFound(err) {
+		// Without managed-quota the namespace goes back to the default claim of its tier
+		settings, _ := c.namespaceSettings(claim.Namespace)
+		return settings.DefaultClaimSpec.DeepCopy(), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return managedQuota.Spec.Hard.DeepCopy(), nil
+}
+
+// Update burst claim phase to Accepted and requeue it when it expires
+func (c *Controller) claimAcceptedUntil(claim *cagipv1.ResourceQuotaClaim, expiresAt *metav1.Time, revertTo v1Core.ResourceList) (err error) {
+	status := c.newBurstClaimStatus(claim, cagipv1.PhaseAccepted, cagipv1.ReasonAccepted, utils.EmptyMsg, expiresAt, revertTo)
+	if _, err = c.setResourceQuotaClaimStatus(claim, status); err != nil {
+		return err
+	}
+
+	if err = c.supersedeAcceptedClaims(claim); err != nil {
+		return err
+	}
+
+	c.enqueueResourceQuotaClaimAfter(claim, expiresAt.Sub(c.clock.Now()))
+	return nil
+}
+
+// Update expired burst claim phase to Pending until the namespace requests or the capacity allow to restore the quota
+func (c *Controller) claimRevertPending(claim *cagipv1.ResourceQuotaClaim, reason string, msg string) (err error) {
+	klog.Infof("< RequestQuotaClaim '%s' set to PENDING >", claim.Name)
+	// Notify via an event
+	c.recorder.Event(claim, v1Core.EventTypeWarning, cagipv1.PhasePending, msg)
+	// Update ResourceQuotaClaim Status to Pending Phase, keeping the expiry
+	_, err = c.setResourceQuotaClaimStatus(claim, c.newBurstClaimStatus(claim, cagipv1.PhasePending, reason, msg, claim.Status.ExpiresAt, claim.Status.RevertTo))
+	utils.ClaimCounter.WithLabelValues("pending").Inc()
+	if err != nil {
+		return err
+	}
+
+	// The previous quota is restored whatever the time it takes, the claim is only requeued
+	// It is also requeued with the waiting claims when capacity is released
+	interval := c.settings.PendingRequeueInterval
+	if interval <= 0 {
+		interval = revertRequeueInterval
+	}
+	c.enqueueResourceQuotaClaimAfter(claim, interval)
+	return nil
+}
+
+// Check if a claim is an expired burst claim whose previous quota could not be restored yet
+func isRevertPending(claim *cagipv1.ResourceQuotaClaim) bool {
+	return claim.Status.Phase == cagipv1.PhasePending && claim.Status.ExpiresAt != nil
+}
+
+// Build the status of a burst claim, with its expiry and the quota it reverts to
+func (c *Controller) newBurstClaimStatus(claim *cagipv1.ResourceQuotaClaim, phase string, reason string, details string, expiresAt *metav1.Time, revertTo v1Core.ResourceList) cagipv1.ResourceQuotaClaimStatus {
+	status := newResourceQuotaClaimStatus(claim, phase, reason, details, metav1.NewTime(c.clock.Now()))
+	status.ExpiresAt = expiresAt.DeepCopy()
+	status.RevertTo = revertTo.DeepCopy()
+	return status
+}
+
+// Put a resourceQuotaClaim back on the work queue after a delay
+func (c *Controller) enqueueResourceQuotaClaimAfter(obj interface{}, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.resourceQuotaClaimWorkQueue.AddAfter(key, duration)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	"gotest.tools/v3/assert"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClaimExpiry(t *testing.T) {
+	now := time.Date(2020, time.January, 24, 8, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expiresAt   *metav1.Time
+		msg         string
+	}{
+		"no annotation should not expire": {
+			annotations: nil,
+			expiresAt:   nil,
+			msg:         utils.EmptyMsg,
+		},
+		"duration should expire from now": {
+			annotations: map[string]string{cagipv1.AnnotationDuration: "48h"},
+			expiresAt:   &metav1.Time{Time: now.Add(48 * time.Hour)},
+			msg:         utils.EmptyMsg,
+		},
+		"expires-at should take precedence over duration": {
+			annotations: map[string]string{cagipv1.AnnotationDuration: "48h", cagipv1.AnnotationExpiresAt: "2020-01-25T18:00:00Z"},
+			expiresAt:   &metav1.Time{Time: time.Date(2020, time.January, 25, 18, 0, 0, 0, time.UTC)},
+			msg:         utils.EmptyMsg,
+		},
+		"invalid duration should be rejected": {
+			annotations: map[string]string{cagipv1.AnnotationDuration: "2 days"},
+			expiresAt:   nil,
+			msg:         "Invalid cagip.github.com/duration annotation 2 days",
+		},
+		"negative duration should be rejected": {
+			annotations: map[string]string{cagipv1.AnnotationDuration: "-1h"},
+			expiresAt:   nil,
+			msg:         "Invalid cagip.github.com/duration annotation -1h",
+		},
+		"past expires-at should be rejected": {
+			annotations: map[string]string{cagipv1.AnnotationExpiresAt: "2020-01-23T18:00:00Z"},
+			expiresAt:   nil,
+			msg:         "Claim already expired at 2020-01-23T18:00:00Z",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{})
+			claim.Annotations = testCase.annotations
+
+			expiresAt, msg := claimExpiry(claim, now)
+
+			assert.Equal(t, msg, testCase.msg)
+			if testCase.expiresAt == nil {
+				assert.Assert(t, expiresAt == nil)
+			} else {
+				assert.Assert(t, expiresAt.Equal(testCase.expiresAt))
+			}
+		})
+	}
+}
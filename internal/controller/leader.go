@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// LeaderElection tracks the Lease shared by the replicas of the controller
+// Every replica keeps its informers and work queues warm, only the leader runs the workers
+type LeaderElection struct {
+	identity string
+
+	mutex   sync.RWMutex
+	leader  string
+	leading bool
+}
+
+// LeaderStatus is the view of the election reported by a replica
+type LeaderStatus struct {
+	Identity string `json:"identity"`
+	Leader   string `json:"leader"`
+	Leading  bool   `json:"leading"`
+}
+
+// NewLeaderElection returns the election state of the replica identified by identity
+func NewLeaderElection(identity string) *LeaderElection {
+	utils.LeaderGauge.WithLabelValues(identity).Set(0)
+	return &LeaderElection{identity: identity}
+}
+
+// Run campaigns for the lock of the config and runs the controller while the replica is the leader
+// It returns once the leadership is lost or stopCh is closed, the replica must then exit
+// as the work queues of the controller are shut down
+func (l *LeaderElection) Run(config leaderelection.LeaderElectionConfig, controller *Controller, threadiness int, stopCh <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var runErr error
+	config.ReleaseOnCancel = true
+	config.Callbacks = l.callbacks(func(ctx context.Context) {
+		runErr = controller.Run(threadiness, ctx.Done())
+	})
+	elector, err := leaderelection.NewLeaderElector(config)
+	if err != nil {
+		return err
+	}
+
+	elector.Run(ctx)
+	return runErr
+}
+
+// Callbacks of the elector, run is started when the replica acquires the lease
+func (l *LeaderElection) callbacks(run func(ctx context.Context)) leaderelection.LeaderCallbacks {
+	return leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context) {
+			klog.Infof("< Replica '%s' acquired the lease, starting workers >", l.identity)
+			l.setLeading(true)
+			run(ctx)
+		},
+		OnStoppedLeading: func() {
+			klog.Infof("< Replica '%s' is not leading anymore >", l.identity)
+			l.setLeading(false)
+		},
+		OnNewLeader: l.observeLeader,
+	}
+}
+
+// Record the replica holding the lease
+func (l *LeaderElection) observeLeader(identity string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if identity != l.leader {
+		klog.Infof("< New leader elected '%s' >", identity)
+	}
+	l.leader = identity
+}
+
+// Record whether the replica runs the workers
+func (l *LeaderElection) setLeading(leading bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.leading = leading
+	if leading {
+		l.leader = l.identity
+		utils.LeaderGauge.WithLabelValues(l.identity).Set(1)
+	} else {
+		utils.LeaderGauge.WithLabelValues(l.identity).Set(0)
+	}
+}
+
+// Status returns the leader known by the replica
+func (l *LeaderElection) Status() LeaderStatus {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return LeaderStatus{Identity: l.identity, Leader: l.leader, Leading: l.leading}
+}
+
+// Check that a leader has been observed, use for readiness probe
+// A standby replica is ready as soon as it knows the leader, it can take over at any time
+func (l *LeaderElection) Check() error {
+	if status := l.Status(); status.Leader == "" {
+		return fmt.Errorf(utils.MessageNoLeader, status.Identity)
+	}
+	return nil
+}
+
+// ServeHTTP reports the leader known by the replica
+func (l *LeaderElection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(l.Status())
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestLeaderElection(t *testing.T) {
+
+	t.Run("replica without leader should not be ready", func(t *testing.T) {
+		election := NewLeaderElection("replica-1")
+		assert.ErrorContains(t, election.Check(), "replica-1")
+	})
+
+	t.Run("standby replica should report the leader it observed", func(t *testing.T) {
+		election := NewLeaderElection("replica-1")
+		election.observeLeader("replica-2")
+		assert.NilError(t, election.Check())
+
+		recorder := httptest.NewRecorder()
+		election.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/leader", nil))
+		assert.Equal(t, recorder.Code, http.StatusOK)
+
+		var status LeaderStatus
+		assert.NilError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+		assert.DeepEqual(t, status, LeaderStatus{Identity: "replica-1", Leader: "replica-2", Leading: false})
+		assert.Equal(t, testutil.ToFloat64(utils.LeaderGauge.WithLabelValues("replica-1")), float64(0))
+	})
+
+	t.Run("replica holding the lease should run the workers until it stops leading", func(t *testing.T) {
+		election := NewLeaderElection("replica-3")
+		lock := &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Namespace: "kube-system", Name: "kotary"},
+			Client:     k8sfake.NewSimpleClientset().CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: "replica-3"},
+		}
+
+		started := make(chan struct{})
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   time.Second,
+			RenewDeadline:   500 * time.Millisecond,
+			RetryPeriod:     100 * time.Millisecond,
+			ReleaseOnCancel: true,
+			Callbacks: election.callbacks(func(ctx context.Context) {
+				close(started)
+				<-ctx.Done()
+			}),
+		})
+		assert.NilError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			elector.Run(ctx)
+			close(stopped)
+		}()
+
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("replica did not acquire the lease")
+		}
+		assert.DeepEqual(t, election.Status(), LeaderStatus{Identity: "replica-3", Leader: "replica-3", Leading: true})
+		assert.Equal(t, testutil.ToFloat64(utils.LeaderGauge.WithLabelValues("replica-3")), float64(1))
+
+		cancel()
+		<-stopped
+		assert.Equal(t, election.Status().Leading, false)
+		assert.Equal(t, testutil.ToFloat64(utils.LeaderGauge.WithLabelValues("replica-3")), float64(0))
+	})
+}
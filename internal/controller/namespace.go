@@ -28,7 +28,7 @@ func (c *Controller) syncHandlerNS(key string) error {
 	}
 
 	// Check if the namespace should be treated by this controller
-	if !hasTargetedLabel(ns) {
+	if !c.hasTargetedLabel(ns) {
 		return nil
 	}
 
@@ -107,11 +107,9 @@ func (c *Controller) hasUnevaluatedClaim(ns *v1.Namespace) (bool, error) {
 		return false, err
 	}
 
-	// Return true if a claim is not Rejected
+	// Return true if a claim is not evaluated yet
 	for _, claim := range claims {
-		notReject := claim.Status.Phase != cagipv1.PhaseRejected
-		notAccepted := claim.Status.Phase != cagipv1.PhaseAccepted
-		if notReject && notAccepted {
+		if !isClaimEvaluated(claim) {
 			return true, nil
 		}
 	}
@@ -120,25 +118,55 @@ func (c *Controller) hasUnevaluatedClaim(ns *v1.Namespace) (bool, error) {
 
 }
 
-// Check if the namespace show be watch based on the condition that it
-// contains the annotation
-func hasTargetedLabel(namespace *v1.Namespace) bool {
-	// Create label to be checked
-	labelToFind := map[string]string{
-		"quota": "managed",
+// Check if the namespace should be watched based on the condition that it
+// is selected by the namespaceSelector and not excluded by name
+func (c *Controller) hasTargetedLabel(namespace *v1.Namespace) bool {
+	return c.settings.ManagesNamespace(namespace)
+}
+
+// Return the settings applied to a namespace and the name of its tier
+// The global settings apply when the namespace is not in the cache
+func (c *Controller) namespaceSettings(namespace string) (utils.Config, string) {
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		return c.settings, ""
 	}
+	return c.settings.ForNamespace(ns)
+}
 
-	// Check if the Namespace contains the label
-	return utils.MapIntersects(namespace.Labels, labelToFind)
+// Return the node pool a namespace is bound to, nil when it is evaluated against the whole cluster
+func (c *Controller) namespacePool(namespace string) *utils.NodePool {
+	if len(c.settings.NodePools) == 0 {
+		return nil
+	}
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		return nil
+	}
+	return c.settings.NodePoolOf(ns)
+}
+
+// Check if a namespace shares the capacity of a node pool, every namespace shares the whole cluster
+func (c *Controller) sharesPool(pool *utils.NodePool, namespace string) bool {
+	return pool == nil || poolName(c.namespacePool(namespace)) == pool.Name
+}
+
+// Return the name of a node pool, empty for the whole cluster
+func poolName(pool *utils.NodePool) string {
+	if pool == nil {
+		return ""
+	}
+	return pool.Name
 }
 
-// Return a default quota
+// Return a default quota, using the default claim spec of the namespace tier
 func (c *Controller) newDefaultResourceQuotaClaim(namespace string) *cagipv1.ResourceQuotaClaim {
+	settings, _ := c.namespaceSettings(namespace)
 	return &cagipv1.ResourceQuotaClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "default",
 			Namespace: namespace,
 		},
-		Spec: c.settings.DefaultClaimSpec.DeepCopy(),
+		Spec: settings.DefaultClaimSpec.DeepCopy(),
 	}
 }
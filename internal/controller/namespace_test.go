@@ -132,9 +132,12 @@ func TestHasTargetedLabel(t *testing.T) {
 		},
 	}
 
+	f := newFixture(t)
+	c, _, _, _, _, _ := f.newController()
+
 	for testName, testCase := range TestCases {
 		t.Run(testName, func(t *testing.T) {
-			result := hasTargetedLabel(&testCase.namespace)
+			result := c.hasTargetedLabel(&testCase.namespace)
 			assert.Equal(t, result, testCase.expect)
 		})
 	}
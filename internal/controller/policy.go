@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// syncHandlerPolicy validates a QuotaPolicy and reports the errors in its status
+func (c *Controller) syncHandlerPolicy(key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	policy, err := c.quotaPolicyLister.Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Without QuotaPolicy the settings fall back on the ConfigMap
+			if name == cagipv1.QuotaPolicyName {
+				return c.reloadSettings()
+			}
+			utilruntime.HandleError(fmt.Errorf("QuotaPolicy '%s' in work queue no longer exists", key))
+			return nil
+		}
+		return err
+	}
+
+	var errs []string
+	if policy.Name != cagipv1.QuotaPolicyName {
+		errs = []string{fmt.Sprintf(utils.MessagePolicyIgnored, cagipv1.QuotaPolicyName)}
+	} else {
+		_, errs = utils.ParseQuotaPolicy(policy.Spec)
+	}
+
+	status := newQuotaPolicyStatus(policy, errs, metav1.NewTime(c.clock.Now()))
+	if !reflect.DeepEqual(*status, policy.Status) {
+		if len(errs) > 0 {
+			klog.Errorf("< QuotaPolicy '%s' is invalid : %s >", policy.Name, strings.Join(errs, ", "))
+			c.recorder.Event(policy, v1.EventTypeWarning, status.Conditions[0].Reason, strings.Join(errs, ", "))
+		}
+		if err = c.updateQuotaPolicyStatus(policy, status); err != nil {
+			return err
+		}
+	}
+
+	if policy.Name != cagipv1.QuotaPolicyName {
+		return nil
+	}
+
+	return c.reloadSettings()
+}
+
+// Resolve the settings from the QuotaPolicy and the ConfigMap, and swap them when they changed
+// The claims evaluated meanwhile keep the settings they started with
+func (c *Controller) reloadSettings() error {
+	policy, err := c.quotaPolicyLister.Get(cagipv1.QuotaPolicyName)
+	if errors.IsNotFound(err) {
+		policy = nil
+	} else if err != nil {
+		return err
+	}
+
+	var configMap *v1.ConfigMap
+	if c.configMapLister != nil {
+		configMaps, err := c.configMapLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, candidate := range configMaps {
+			if candidate.Name == utils.ConfigMapName {
+				configMap = candidate
+			}
+		}
+	}
+
+	settings := utils.ResolveConfig(policy, configMap)
+	previous := c.currentSettings.Load()
+	if reflect.DeepEqual(settings, previous) {
+		return nil
+	}
+
+	c.currentSettings.Store(settings)
+	utils.SetKotaryMetrics(settings)
+	klog.Infof("< Settings reloaded : %+v >", *settings)
+	c.checkApprovalWebhook(settings)
+
+	// The ratios and the node pools of the capacity status may have changed
+	c.enqueueCapacityStatus()
+
+	// The settings are already swapped, every requeue is attempted before an error is returned
+	var requeueErr error
+
+	// Namespaces newly selected receive their default claim
+	if !reflect.DeepEqual(settings.NamespaceSelector, previous.NamespaceSelector) || !reflect.DeepEqual(settings.ExcludedNamespaces, previous.ExcludedNamespaces) {
+		if err = c.requeueNamespaces(); err != nil {
+			klog.Errorf("Could not requeue the namespaces after the settings reload : %s", err)
+			requeueErr = err
+		}
+	}
+
+	// Claims that did not fit may be accepted with the new ratios
+	if settings.RequeueOnLooserPolicy && settings.LooserThan(*previous) {
+		if err = c.requeueUnacceptedClaims(); err != nil {
+			klog.Errorf("Could not requeue the unaccepted claims after the settings reload : %s", err)
+			requeueErr = err
+		}
+	}
+
+	// Claims awaiting approval may not require one with the new policy
+	c.requeueAwaitingApprovalClaims()
+
+	// Claims waiting for capacity are rejected once the queue is disabled
+	if previous.WaitForCapacity && !settings.WaitForCapacity {
+		c.requeueWaitingClaims()
+	}
+
+	return requeueErr
+}
+
+// Put all the namespaces back on the work queue
+func (c *Controller) requeueNamespaces() error {
+	namespaces, err := c.namespaceLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		c.enqueueNamespace(ns)
+	}
+
+	return nil
+}
+
+// Put the rejected, pending and waiting claims of all the namespaces back on the work queue
+func (c *Controller) requeueUnacceptedClaims() error {
+	claims, err := c.resourceQuotaClaimLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims {
+		switch claim.Status.Phase {
+		case cagipv1.PhaseRejected, cagipv1.PhasePending, cagipv1.PhaseWaiting:
+			klog.Infof("< RequestQuotaClaim '%s' requeued after a looser policy >", claim.Name)
+			c.enqueueResourceQuotaClaim(claim)
+		}
+	}
+
+	return nil
+}
+
+// Build the status of a policy from its validation errors
+// The Valid condition keeps its transition time when its status does not change
+func newQuotaPolicyStatus(policy *cagipv1.QuotaPolicy, errs []string, now metav1.Time) *cagipv1.QuotaPolicyStatus {
+	status := &cagipv1.QuotaPolicyStatus{
+		ObservedGeneration: policy.Generation,
+		Errors:             errs,
+		Conditions:         policy.Status.DeepCopy().Conditions,
+	}
+
+	condition := metav1.Condition{
+		Type:               cagipv1.ConditionValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: policy.Generation,
+		LastTransitionTime: now,
+		Reason:             cagipv1.ReasonValidPolicy,
+		Message:            utils.MessagePolicyValid,
+	}
+	if len(errs) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = cagipv1.ReasonInvalidPolicy
+		condition.Message = strings.Join(errs, ", ")
+		if policy.Name != cagipv1.QuotaPolicyName {
+			condition.Reason = cagipv1.ReasonIgnoredPolicy
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	return status
+}
+
+// Update the QuotaPolicyStatus
+func (c *Controller) updateQuotaPolicyStatus(policy *cagipv1.QuotaPolicy, status *cagipv1.QuotaPolicyStatus) error {
+	// DeepCopy of the original object, very important has we area dealing with a SharedInformer
+	policyCopy := policy.DeepCopy()
+	policyCopy.Status = *status
+
+	_, err := c.resourcequotaclaimclientset.CagipV1().QuotaPolicies().UpdateStatus(context.TODO(), policyCopy, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("Could not update status on QuotaPolicy %s ", policy.Name)
+		return err
+	}
+
+	return nil
+}
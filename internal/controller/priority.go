@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// Key of the work queue item admitting the claims collected during the batching window
+// It can not be mistaken for a claim, the namespaces and names can not contain @
+const admissionBatchKey = "@admission-batch"
+
+// admissionBatch collects the claims to admit once the batching window is over
+type admissionBatch struct {
+	mutex sync.Mutex
+	keys  map[string]bool
+}
+
+// Create an empty batch
+func newAdmissionBatch() *admissionBatch {
+	return &admissionBatch{keys: map[string]bool{}}
+}
+
+// Add a claim to the batch, return true when it opens a new batching window
+func (b *admissionBatch) add(key string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.keys[key] = true
+	return len(b.keys) == 1
+}
+
+// Return the claims of the batch and start a new one
+func (b *admissionBatch) take() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	keys := make([]string, 0, len(b.keys))
+	for key := range b.keys {
+		keys = append(keys, key)
+	}
+	b.keys = map[string]bool{}
+	return keys
+}
+
+// Collect a claim until the batching window is over
+func (c *Controller) batchClaim(key string) {
+	if c.batch.add(key) {
+		c.resourceQuotaClaimWorkQueue.AddAfter(admissionBatchKey, c.settings.AdmissionBatchWindow)
+	}
+	klog.Infof("< RequestQuotaClaim '%s' collected for admission >", key)
+}
+
+// Report a claim collected for admission as pending until its batch is admitted
+func (c *Controller) claimBatched(claim *cagipv1.ResourceQuotaClaim) error {
+	msg := fmt.Sprintf(utils.MessageAwaitingAdmission, c.settings.AdmissionBatchWindow)
+	if isStillPending(claim, cagipv1.ReasonAwaitingAdmission, msg) {
+		return nil
+	}
+	status := newResourceQuotaClaimStatus(claim, cagipv1.PhasePending, cagipv1.ReasonAwaitingAdmission, msg, metav1.NewTime(c.clock.Now()))
+	_, err := c.setResourceQuotaClaimStatus(claim, status)
+	return err
+}
+
+// Admit the claims collected during the batching window in priority order
+// They are evaluated one after the other, a claim only gets the capacity left by the claims of higher priority
+// A claim a worker is evaluating meanwhile joins the next batch
+func (c *Controller) admitBatch() error {
+	var claims []*cagipv1.ResourceQuotaClaim
+	for _, key := range c.batch.take() {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		claim, err := c.resourceQuotaClaimLister.ResourceQuotaClaims(namespace).Get(name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			utilruntime.HandleError(err)
+			c.batchClaim(key)
+			continue
+		}
+		claims = append(claims, claim)
+	}
+
+	sort.SliceStable(claims, func(i, j int) bool {
+		return c.admissionOrder(claims[i], claims[i].CreationTimestamp.Time).before(c.admissionOrder(claims[j], claims[j].CreationTimestamp.Time))
+	})
+
+	// The controller may be shared with other workers, only a copy is marked as admitting
+	admitter := *c
+	admitter.admitting = true
+	for _, claim := range claims {
+		key := claimKey(claim)
+		if err := admitter.syncHandlerClaim(key); err == errClaimInProgress {
+			c.batchClaim(key)
+		} else if err != nil {
+			// Only the claim is put back on the work queue, it joins the next batch
+			utilruntime.HandleError(fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error()))
+			c.resourceQuotaClaimWorkQueue.AddRateLimited(key)
+		}
+	}
+	return nil
+}
+
+// Error of a claim evaluated by another worker, it is evaluated again afterwards
+var errClaimInProgress = fmt.Errorf("claim is being evaluated by another worker")
+
+// claimGuard tracks the claims being evaluated
+// The work queue never hands a key to two workers, the claims of a batch are evaluated outside of it
+type claimGuard struct {
+	mutex sync.Mutex
+	keys  map[string]bool
+}
+
+// Create a guard without any claim
+func newClaimGuard() *claimGuard {
+	return &claimGuard{keys: map[string]bool{}}
+}
+
+// Mark a claim as being evaluated, return false when it already is
+func (g *claimGuard) acquire(key string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.keys[key] {
+		return false
+	}
+	g.keys[key] = true
+	return true
+}
+
+// Mark a claim as evaluated
+func (g *claimGuard) release(key string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.keys, key)
+}
+
+// Order in which the claims competing for the capacity are admitted
+type admissionOrder struct {
+	priority int32
+	since    time.Time
+	key      string
+}
+
+// Check if a claim is admitted before another one
+// The higher priority comes first, then the claim that has been waiting the longest
+func (o admissionOrder) before(other admissionOrder) bool {
+	if o.priority != other.priority {
+		return o.priority > other.priority
+	}
+	if !o.since.Equal(other.since) {
+		return o.since.Before(other.since)
+	}
+	return o.key < other.key
+}
+
+// Return the admission order of a claim competing since a given time
+// A claim with an invalid priority competes with the priority of its namespace until it is rejected
+func (c *Controller) admissionOrder(claim *cagipv1.ResourceQuotaClaim, since time.Time) admissionOrder {
+	priority, msg := c.claimPriority(claim)
+	if msg != utils.EmptyMsg {
+		priority = c.namespacePriority(claim.Namespace)
+	}
+	return admissionOrder{priority: priority, since: since, key: claimKey(claim)}
+}
+
+// Return the priority of a claim, from its annotation or from its namespace
+// Return a msg when the annotation is invalid
+func (c *Controller) claimPriority(claim *cagipv1.ResourceQuotaClaim) (int32, string) {
+	if value, found := claim.Annotations[cagipv1.AnnotationPriority]; found {
+		priority, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return 0, fmt.Sprintf(utils.MessageInvalidPriority, cagipv1.AnnotationPriority, value)
+		}
+		return int32(priority), utils.EmptyMsg
+	}
+	return c.namespacePriority(claim.Namespace), utils.EmptyMsg
+}
+
+// Return the priority of a namespace, from its label or from its tier
+// An invalid label is ignored
+func (c *Controller) namespacePriority(namespace string) int32 {
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		return c.settings.Priority
+	}
+	if value, found := ns.Labels[cagipv1.AnnotationPriority]; found {
+		if priority, err := strconv.ParseInt(value, 10, 32); err == nil {
+			return int32(priority)
+		}
+		klog.Warningf("Ignoring invalid %s label %s of namespace %s", cagipv1.AnnotationPriority, value, namespace)
+	}
+	settings, _ := c.settings.ForNamespace(ns)
+	return settings.Priority
+}
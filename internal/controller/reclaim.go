@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Event reason of a managed quota lowered for a claim of higher priority
+const reasonQuotaReclaimed = "Reclaimed"
+
+// Managed quota lowered down to the usage of its namespace
+type reclaim struct {
+	resourceQuota *v1Core.ResourceQuota
+	hard          v1Core.ResourceList
+	reclaimed     v1Core.ResourceList
+}
+
+// Lower the managed quotas of the namespaces of lower priority down to their usage to cover the shortfall of a claim
+// The namespaces of lowest priority are reclaimed first, nothing is lowered when the shortfall can not be covered
+// Only the namespaces sharing the node pool of the claim release capacity it can use
+// Return true when the quotas have been lowered
+func (c *Controller) reclaimUnusedQuota(claim *cagipv1.ResourceQuotaClaim, shortfall v1Core.ResourceList) (bool, error) {
+	priority, _ := c.claimPriority(claim)
+	pool := c.namespacePool(claim.Namespace)
+	now := c.clock.Now()
+
+	resourceQuotas, err := c.resourceQuotaLister.List(utils.DefaultLabelSelector())
+	if err != nil {
+		return false, err
+	}
+	pending := c.reservations.pending(resourceQuotas, now)
+
+	// Managed quotas of the namespaces of lower priority, the lowest first
+	var candidates []*v1Core.ResourceQuota
+	priorities := map[string]int32{}
+	for _, resourceQuota := range resourceQuotas {
+		if resourceQuota.Name != utils.ResourceQuotaName || resourceQuota.Namespace == claim.Namespace || !c.sharesPool(pool, resourceQuota.Namespace) {
+			continue
+		}
+		namespacePriority := c.namespacePriority(resourceQuota.Namespace)
+		if namespacePriority < priority {
+			priorities[resourceQuota.Namespace] = namespacePriority
+			candidates = append(candidates, resourceQuota)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if priorities[candidates[i].Namespace] != priorities[candidates[j].Namespace] {
+			return priorities[candidates[i].Namespace] < priorities[candidates[j].Namespace]
+		}
+		return candidates[i].Namespace < candidates[j].Namespace
+	})
+
+	remaining := shortfall.DeepCopy()
+	var reclaims []reclaim
+	for _, resourceQuota := range candidates {
+		hard := resourceQuota.Spec.Hard.DeepCopy()
+		if written, found := pending[resourceQuota.Namespace]; found {
+			hard = written
+		}
+		pods, err := c.podsLister.Pods(resourceQuota.Namespace).List(labels.Everything())
+		if err != nil {
+			return false, err
+		}
+		usage := utils.PodsQuotaUsage(pods, now)
+
+		reclaimed := v1Core.ResourceList{}
+		for _, name := range utils.SortedResourceNames(hard) {
+			capacityName := utils.CapacityResourceName(name)
+			missing, found := remaining[capacityName]
+			if !found || missing.Sign() <= 0 {
+				continue
+			}
+
+			// Only the quota that is not requested by the pods of the namespace is reclaimed
+			unused := hard[name].DeepCopy()
+			used, found := (*usage)[name]
+			if !found {
+				used = (*usage)[capacityName]
+			}
+			unused.Sub(used)
+			if unused.Sign() <= 0 {
+				continue
+			}
+			if unused.Cmp(missing) > 0 {
+				unused = missing.DeepCopy()
+			}
+
+			lowered := hard[name].DeepCopy()
+			lowered.Sub(unused)
+			hard[name] = lowered
+			reclaimed[name] = unused
+			missing.Sub(unused)
+			remaining[capacityName] = missing
+		}
+		if len(reclaimed) > 0 {
+			reclaims = append(reclaims, reclaim{resourceQuota: resourceQuota, hard: hard, reclaimed: reclaimed})
+		}
+	}
+
+	for _, missing := range remaining {
+		if missing.Sign() > 0 {
+			return false, nil
+		}
+	}
+
+	for _, lowered := range reclaims {
+		if err = c.applyReclaim(claim, lowered); err != nil {
+			return false, err
+		}
+	}
+	return len(reclaims) > 0, nil
+}
+
+// Write a lowered managed quota and notify the namespace via an event
+func (c *Controller) applyReclaim(claim *cagipv1.ResourceQuotaClaim, lowered reclaim) error {
+	resourceQuota := lowered.resourceQuota.DeepCopy()
+	resourceQuota.Spec.Hard = lowered.hard
+	c.annotateAcceptedSpec(resourceQuota)
+	if _, err := c.resourcequotaclientset.CoreV1().ResourceQuotas(resourceQuota.Namespace).Update(context.TODO(), resourceQuota, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Could not reclaim the ResourceQuota of ns %s : %s", resourceQuota.Namespace, err)
+		return err
+	}
+	c.reservations.record(resourceQuota.Namespace, lowered.hard, c.clock.Now())
+	c.acceptedSpecs.record(resourceQuota.Namespace, lowered.hard)
+
+	msg := fmt.Sprintf(utils.MessageQuotaReclaimed, formatResourceList(lowered.reclaimed), claimKey(claim))
+	klog.Infof("< ResourceQuota of ns %s : %s >", resourceQuota.Namespace, msg)
+	c.recorder.Event(resourceQuota, v1Core.EventTypeWarning, reasonQuotaReclaimed, msg)
+	return nil
+}
+
+// Format a resource list as name=quantity pairs sorted by name
+func formatResourceList(resources v1Core.ResourceList) string {
+	pairs := make([]string, 0, len(resources))
+	for _, name := range utils.SortedResourceNames(resources) {
+		quantity := resources[name]
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+
+	v1Core "k8s.io/api/core/v1"
+)
+
+// Duration after which a reservation is dropped even if the informer never reported it
+const reservationTTL = 2 * time.Minute
+
+// reservationLedger records the managed quotas written by the controller until the informer observes them
+// The capacity decisions hold its decision lock from the evaluation of a claim until its quota is written,
+// so concurrent claims are evaluated one after the other against the quotas already granted
+type reservationLedger struct {
+	// Serialize the capacity decisions
+	decision sync.Mutex
+
+	// Protect the reservations, they are also recorded outside of the capacity decisions
+	mutex        sync.Mutex
+	reservations map[string]reservation
+}
+
+// Managed quota written on a namespace
+type reservation struct {
+	hard       v1Core.ResourceList
+	recordedAt time.Time
+}
+
+// Create an empty ledger
+func newReservationLedger() *reservationLedger {
+	return &reservationLedger{reservations: map[string]reservation{}}
+}
+
+// Record the managed quota written on a namespace
+func (l *reservationLedger) record(namespace string, hard v1Core.ResourceList, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.reservations[namespace] = reservation{hard: hard.DeepCopy(), recordedAt: now}
+}
+
+// Return the managed quotas the informer has not observed yet, keyed by namespace
+// A reservation is dropped once the cached managed quota matches it, or when it expires
+func (l *reservationLedger) pending(cached []*v1Core.ResourceQuota, now time.Time) map[string]v1Core.ResourceList {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, resourceQuota := range cached {
+		if resourceQuota.Name != utils.ResourceQuotaName {
+			continue
+		}
+		if reserved, found := l.reservations[resourceQuota.Namespace]; found && quota.Equals(reserved.hard, resourceQuota.Spec.Hard) {
+			delete(l.reservations, resourceQuota.Namespace)
+		}
+	}
+
+	pending := make(map[string]v1Core.ResourceList, len(l.reservations))
+	for namespace, reserved := range l.reservations {
+		if now.Sub(reserved.recordedAt) > reservationTTL {
+			delete(l.reservations, namespace)
+			continue
+		}
+		pending[namespace] = reserved.hard.DeepCopy()
+	}
+	return pending
+}
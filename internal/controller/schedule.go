@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// Number of runs kept in the status of a ScheduledQuotaClaim
+const scheduledRunsHistoryLimit = 10
+
+// syncHandlerSchedule emits the claims of a ScheduledQuotaClaim when their schedule is due
+func (c *Controller) syncHandlerSchedule(key string) error {
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	scheduled, err := c.scheduledQuotaClaimLister.ScheduledQuotaClaims(namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("ScheduledQuotaClaim '%s' in work queue no longer exists", key))
+			return nil
+		}
+		return err
+	}
+
+	status := scheduled.Status.DeepCopy()
+	status.ObservedGeneration = scheduled.Generation
+
+	// An invalid definition is reported and not retried until the spec changes
+	schedules, err := parseSchedules(scheduled.Spec)
+	if err != nil {
+		status.Details = err.Error()
+		status.NextExecutionTime = nil
+		status.NextSchedule = ""
+		if !reflect.DeepEqual(*status, scheduled.Status) {
+			c.recorder.Event(scheduled, v1.EventTypeWarning, cagipv1.ReasonInvalidSchedule, err.Error())
+		}
+		return c.updateScheduledQuotaClaimStatus(scheduled, status)
+	}
+	status.Details = utils.EmptyMsg
+
+	now := c.clock.Now()
+
+	// Missed occurrences since the last execution are collapsed into the most recent one
+	since := scheduled.CreationTimestamp.Time
+	if status.LastExecutionTime != nil {
+		since = status.LastExecutionTime.Time
+	} else if since.IsZero() {
+		since = now
+	}
+	// The claim of a due run is emitted before the run is recorded, a run is only emitted once
+	// When the status can not be updated the same run is due again and its claim already exists
+	if due, index := lastOccurrence(schedules, since, now); index >= 0 {
+		executionTime := metav1.NewTime(due.UTC())
+		run := cagipv1.ScheduledRun{
+			Schedule:      scheduled.Spec.Schedules[index].Name,
+			ExecutionTime: executionTime,
+			Claim:         scheduledClaimName(scheduled, due),
+		}
+		if err = c.emitScheduledClaim(scheduled, run); err != nil {
+			return err
+		}
+		status.LastExecutionTime = &executionTime
+		status.Runs = append([]cagipv1.ScheduledRun{run}, status.Runs...)
+		if len(status.Runs) > scheduledRunsHistoryLimit {
+			status.Runs = status.Runs[:scheduledRunsHistoryLimit]
+		}
+	}
+
+	if next, index := nextOccurrence(schedules, now); index >= 0 {
+		nextExecutionTime := metav1.NewTime(next.UTC())
+		status.NextExecutionTime = &nextExecutionTime
+		status.NextSchedule = scheduled.Spec.Schedules[index].Name
+		c.enqueueScheduledQuotaClaimAfter(scheduled, next.Sub(now))
+	} else {
+		status.NextExecutionTime = nil
+		status.NextSchedule = ""
+	}
+
+	return c.updateScheduledQuotaClaimStatus(scheduled, status)
+}
+
+// Parse the cron expressions of the schedules in their time zone
+func parseSchedules(spec cagipv1.ScheduledQuotaClaimSpec) ([]cron.Schedule, error) {
+	location, err := time.LoadLocation(spec.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf(utils.MessageInvalidTimeZone, spec.TimeZone)
+	}
+
+	schedules := make([]cron.Schedule, 0, len(spec.Schedules))
+	for _, quotaSchedule := range spec.Schedules {
+		schedule, err := cron.ParseStandard(quotaSchedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf(utils.MessageInvalidSchedule, quotaSchedule.Name, err.Error())
+		}
+		if specSchedule, ok := schedule.(*cron.SpecSchedule); ok {
+			specSchedule.Location = location
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// Most recent occurrence of the schedules in ]since, now]
+// Return the index of the schedule, or -1 if none is due
+func lastOccurrence(schedules []cron.Schedule, since time.Time, now time.Time) (due time.Time, index int) {
+	index = -1
+	for i, schedule := range schedules {
+		if next := lastScheduleOccurrence(schedule, since, now); !next.IsZero() && !next.Before(due) {
+			due, index = next, i
+		}
+	}
+	return due, index
+}
+
+// Most recent occurrence of a schedule in ]since, now], zero if none is due
+// The missed occurrences are not walked one by one, a long outage with a frequent schedule would never end:
+// the interval is halved instead, keeping an occurrence due after its start and none after its end
+func lastScheduleOccurrence(schedule cron.Schedule, since time.Time, now time.Time) time.Time {
+	if next := schedule.Next(since); next.IsZero() || next.After(now) {
+		return time.Time{}
+	}
+	// The schedules have a precision of one second, a single occurrence is left in ]since, until]
+	until := now
+	for until.Sub(since) > time.Second {
+		middle := since.Add(until.Sub(since) / 2)
+		if next := schedule.Next(middle); !next.IsZero() && !next.After(now) {
+			since = middle
+		} else {
+			until = middle
+		}
+	}
+	return schedule.Next(since)
+}
+
+// Next occurrence of the schedules after now
+// Return the index of the schedule, or -1 if none will happen
+func nextOccurrence(schedules []cron.Schedule, now time.Time) (next time.Time, index int) {
+	index = -1
+	for i, schedule := range schedules {
+		if candidate := schedule.Next(now); !candidate.IsZero() && (index < 0 || candidate.Before(next)) {
+			next, index = candidate, i
+		}
+	}
+	return next, index
+}
+
+// Name of the claim emitted at a given time, unique per minute like the jobs of a CronJob
+func scheduledClaimName(scheduled *cagipv1.ScheduledQuotaClaim, due time.Time) string {
+	return fmt.Sprintf("%s-%d", scheduled.Name, due.Unix()/60)
+}
+
+// Create the claim of a run
+// The claims previously emitted and not applied are removed, otherwise they could override it once evaluated
+func (c *Controller) emitScheduledClaim(scheduled *cagipv1.ScheduledQuotaClaim, run cagipv1.ScheduledRun) error {
+	selector := labels.SelectorFromSet(labels.Set{cagipv1.LabelScheduledQuotaClaim: scheduled.Name})
+	emitted, err := c.resourceQuotaClaimLister.ResourceQuotaClaims(scheduled.Namespace).List(selector)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range emitted {
+		if claim.Name == run.Claim || claim.Status.Phase == cagipv1.PhaseAccepted {
+			continue
+		}
+		err = c.resourcequotaclaimclientset.CagipV1().ResourceQuotaClaims(claim.Namespace).Delete(context.TODO(), claim.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	var spec v1.ResourceList
+	for _, quotaSchedule := range scheduled.Spec.Schedules {
+		if quotaSchedule.Name == run.Schedule {
+			spec = quotaSchedule.Spec
+		}
+	}
+
+	_, err = c.resourcequotaclaimclientset.CagipV1().ResourceQuotaClaims(scheduled.Namespace).Create(context.TODO(), newScheduledResourceQuotaClaim(scheduled, run.Claim, spec), metav1.CreateOptions{})
+
+	// The claim has already been emitted
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	if err != nil {
+		klog.Errorf("Could not emit claim %s for %s/%s ", run.Claim, scheduled.Namespace, scheduled.Name)
+		return err
+	}
+
+	klog.Infof("< ScheduledQuotaClaim '%s' emitted claim '%s' from schedule '%s' >", scheduled.Name, run.Claim, run.Schedule)
+	c.recorder.Eventf(scheduled, v1.EventTypeNormal, cagipv1.ReasonClaimEmitted, utils.MessageClaimEmitted, run.Claim, run.Schedule)
+
+	return nil
+}
+
+// Create a ResourceQuotaClaim owned by a ScheduledQuotaClaim
+func newScheduledResourceQuotaClaim(scheduled *cagipv1.ScheduledQuotaClaim, name string, spec v1.ResourceList) *cagipv1.ResourceQuotaClaim {
+	return &cagipv1.ResourceQuotaClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: scheduled.Namespace,
+			Labels: map[string]string{
+				cagipv1.LabelScheduledQuotaClaim: scheduled.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(scheduled, cagipv1.SchemeGroupVersion.WithKind("ScheduledQuotaClaim")),
+			},
+		},
+		Spec: spec.DeepCopy(),
+	}
+}
+
+// Report the outcome of a claim emitted by a ScheduledQuotaClaim in its runs
+// An error is returned when the outcome can not be reported yet, the claim is requeued to report it
+func (c *Controller) recordScheduledRun(claim *cagipv1.ResourceQuotaClaim, phase string, reason string, details string) error {
+	name, found := claim.Labels[cagipv1.LabelScheduledQuotaClaim]
+	if !found {
+		return nil
+	}
+
+	// Superseded and expired claims have already reported their outcome
+	switch phase {
+	case cagipv1.PhaseAccepted, cagipv1.PhaseRejected, cagipv1.PhasePending, cagipv1.PhaseWaiting, cagipv1.PhaseAwaitingApproval:
+	default:
+		return nil
+	}
+
+	// The run may have just been recorded, the lister could be outdated
+	scheduled, err := c.resourcequotaclaimclientset.CagipV1().ScheduledQuotaClaims(claim.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for i, run := range scheduled.Status.Runs {
+		if run.Claim != claim.Name {
+			continue
+		}
+		if run.Phase == phase && run.Reason == reason && run.Details == details {
+			return nil
+		}
+		status := scheduled.Status.DeepCopy()
+		status.Runs[i].Phase = phase
+		status.Runs[i].Reason = reason
+		status.Runs[i].Details = details
+		return c.updateScheduledQuotaClaimStatus(scheduled, status)
+	}
+
+	// The claim is emitted before its run is recorded, the runs older than the last execution are out of the history
+	if last := scheduled.Status.LastExecutionTime; last == nil || claim.CreationTimestamp.After(last.Time) {
+		return fmt.Errorf(utils.MessageScheduledRunNotRecorded, claim.Name, claim.Namespace, name)
+	}
+	return nil
+}
+
+// Update the ScheduledQuotaClaimStatus when it changed
+func (c *Controller) updateScheduledQuotaClaimStatus(scheduled *cagipv1.ScheduledQuotaClaim, status *cagipv1.ScheduledQuotaClaimStatus) error {
+	if reflect.DeepEqual(*status, scheduled.Status) {
+		return nil
+	}
+
+	// DeepCopy of the original object, very important has we area dealing with a SharedInformer
+	scheduledCopy := scheduled.DeepCopy()
+	scheduledCopy.Status = *status
+
+	_, err := c.resourcequotaclaimclientset.CagipV1().ScheduledQuotaClaims(scheduled.Namespace).UpdateStatus(context.TODO(), scheduledCopy, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("Could not update status on %s/%s ", scheduled.Name, scheduled.Namespace)
+		return err
+	}
+
+	return nil
+}
+
+// Put a ScheduledQuotaClaim back on the work queue after a delay
+func (c *Controller) enqueueScheduledQuotaClaimAfter(obj interface{}, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.scheduledQuotaClaimWorkQueue.AddAfter(key, duration)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseSchedules(t *testing.T) {
+	testCases := map[string]struct {
+		spec cagipv1.ScheduledQuotaClaimSpec
+		err  string
+	}{
+		"valid schedules": {
+			spec: cagipv1.ScheduledQuotaClaimSpec{
+				TimeZone:  "Europe/Paris",
+				Schedules: []cagipv1.QuotaSchedule{{Name: "day", Cron: "0 8 * * 1-5"}, {Name: "weekly", Cron: "@weekly"}},
+			},
+		},
+		"invalid time zone": {
+			spec: cagipv1.ScheduledQuotaClaimSpec{
+				TimeZone:  "Mars/Olympus",
+				Schedules: []cagipv1.QuotaSchedule{{Name: "day", Cron: "0 8 * * 1-5"}},
+			},
+			err: "Invalid time zone Mars/Olympus",
+		},
+		"invalid cron expression": {
+			spec: cagipv1.ScheduledQuotaClaimSpec{
+				Schedules: []cagipv1.QuotaSchedule{{Name: "day", Cron: "0 25 * * *"}},
+			},
+			err: "Invalid cron expression of schedule day: end of range (25) above maximum (23): 25",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			schedules, err := parseSchedules(testCase.spec)
+			if testCase.err != "" {
+				assert.Error(t, err, testCase.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, len(schedules), len(testCase.spec.Schedules))
+		})
+	}
+}
+
+func TestScheduleOccurrences(t *testing.T) {
+	schedules, err := parseSchedules(cagipv1.ScheduledQuotaClaimSpec{
+		TimeZone: "Europe/Paris",
+		Schedules: []cagipv1.QuotaSchedule{
+			{Name: "day", Cron: "0 8 * * 1-5"},
+			{Name: "night", Cron: "0 20 * * 1-5"},
+		},
+	})
+	assert.NilError(t, err)
+
+	// Monday 27 January 2020 at 10:00 in Paris
+	now := time.Date(2020, time.January, 27, 9, 0, 0, 0, time.UTC)
+
+	t.Run("missed occurrences are collapsed into the most recent one", func(t *testing.T) {
+		// Friday 24 January 2020 at 07:00 in Paris
+		since := time.Date(2020, time.January, 24, 6, 0, 0, 0, time.UTC)
+		due, index := lastOccurrence(schedules, since, now)
+		assert.Equal(t, index, 0)
+		assert.Assert(t, due.Equal(time.Date(2020, time.January, 27, 7, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("executed occurrence is not due again", func(t *testing.T) {
+		since := time.Date(2020, time.January, 27, 7, 0, 0, 0, time.UTC)
+		_, index := lastOccurrence(schedules, since, now)
+		assert.Equal(t, index, -1)
+	})
+
+	t.Run("missed occurrences of a frequent schedule are not walked one by one", func(t *testing.T) {
+		frequent, err := parseSchedules(cagipv1.ScheduledQuotaClaimSpec{
+			Schedules: []cagipv1.QuotaSchedule{{Name: "minute", Cron: "* * * * *"}},
+		})
+		assert.NilError(t, err)
+		since := now.AddDate(-100, 0, 0)
+		due, index := lastOccurrence(frequent, since, now.Add(30*time.Second))
+		assert.Equal(t, index, 0)
+		assert.Assert(t, due.Equal(now))
+	})
+
+	t.Run("next occurrence", func(t *testing.T) {
+		next, index := nextOccurrence(schedules, now)
+		assert.Equal(t, index, 1)
+		assert.Assert(t, next.Equal(time.Date(2020, time.January, 27, 19, 0, 0, 0, time.UTC)))
+	})
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/labels"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+)
+
+// Period of the samples recorded between the evaluations, the capacity before a drain is known even when no claim is evaluated
+const capacitySamplePeriod = 30 * time.Second
+
+// capacityHistory records the capacity of the nodes seen over the smoothing window, at each evaluation, at each node change
+// and periodically
+// The nodes cordoned one by one during an upgrade do not lower the capacity the claims are evaluated against
+type capacityHistory struct {
+	mutex sync.Mutex
+	// Samples of each node pool, the whole cluster is recorded under an empty name
+	samples map[string][]capacitySample
+}
+
+// Capacity of the nodes seen at a time
+type capacitySample struct {
+	capacity   v1Core.ResourceList
+	recordedAt time.Time
+}
+
+// Create an empty history
+func newCapacityHistory() *capacityHistory {
+	return &capacityHistory{samples: map[string][]capacitySample{}}
+}
+
+// Record the capacity seen now and return the largest capacity of each resource seen over the window
+// Nothing is recorded when there is no window
+func (h *capacityHistory) smooth(pool string, capacity v1Core.ResourceList, now time.Time, window time.Duration) v1Core.ResourceList {
+	h.record(pool, capacity, now, window)
+	return h.peek(pool, capacity, now, window)
+}
+
+// Record the capacity seen now and forget the samples older than the window
+// Nothing is recorded when there is no window
+func (h *capacityHistory) record(pool string, capacity v1Core.ResourceList, now time.Time, window time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if window <= 0 {
+		delete(h.samples, pool)
+		return
+	}
+
+	var kept []capacitySample
+	for _, sample := range h.samples[pool] {
+		if now.Sub(sample.recordedAt) < window {
+			kept = append(kept, sample)
+		}
+	}
+	h.samples[pool] = append(kept, capacitySample{capacity: capacity.DeepCopy(), recordedAt: now})
+}
+
+// Return the largest capacity of each resource seen over the window, the capacity seen now included, without recording it
+func (h *capacityHistory) peek(pool string, capacity v1Core.ResourceList, now time.Time, window time.Duration) v1Core.ResourceList {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	capacities := []v1Core.ResourceList{capacity}
+	for _, sample := range h.samples[pool] {
+		if window > 0 && now.Sub(sample.recordedAt) < window {
+			capacities = append(capacities, sample.capacity)
+		}
+	}
+	return combineCapacities(capacities, cagipv1.CapacityCombinationMax)
+}
+
+// Sum the allocatable of the nodes of a pool, smoothed as the settings require
+// The raw capacity leaves out the nodes in maintenance, the smoothed one keeps them and the largest capacity of the window
+// A read-only snapshot does not record the capacity seen now in the history
+func (c *Controller) smoothedCapacity(pool string, nodes []*v1Core.Node) *v1Core.ResourceList {
+	if c.capacityReadOnly {
+		smoothed := c.capacityHistory.peek(pool, *utils.NodesAllocatable(nodes), c.clock.Now(), c.settings.CapacitySmoothingWindow)
+		return &smoothed
+	}
+
+	var available []*v1Core.Node
+	for _, node := range nodes {
+		if !c.settings.InMaintenance(node) {
+			available = append(available, node)
+		}
+	}
+	raw := utils.NodesAllocatable(available)
+	smoothed := c.capacityHistory.smooth(pool, *utils.NodesAllocatable(nodes), c.clock.Now(), c.settings.CapacitySmoothingWindow)
+
+	reportSmoothedCapacity(pool, *raw, smoothed)
+	return &smoothed
+}
+
+// Record the capacity of the nodes of the whole cluster and of each node pool in the smoothing history
+// The nodes given replace the cached ones of the same name, a node change records the capacity seen before it
+func (c *Controller) recordCapacitySamples(previous ...*v1Core.Node) {
+	if c.settings.CapacitySmoothingWindow <= 0 {
+		return
+	}
+	cached, err := c.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Could not retrieve Nodes : %s", err)
+		return
+	}
+
+	byName := make(map[string]*v1Core.Node, len(cached)+len(previous))
+	for _, node := range cached {
+		byName[node.Name] = node
+	}
+	for _, node := range previous {
+		byName[node.Name] = node
+	}
+	var nodes []*v1Core.Node
+	for _, node := range byName {
+		if c.settings.IsEligibleNode(node) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	now := c.clock.Now()
+	c.capacityHistory.record("", *utils.NodesAllocatable(nodes), now, c.settings.CapacitySmoothingWindow)
+	for i := range c.settings.NodePools {
+		pool := &c.settings.NodePools[i]
+		var poolNodes []*v1Core.Node
+		for _, node := range nodes {
+			if pool.SelectsNode(node) {
+				poolNodes = append(poolNodes, node)
+			}
+		}
+		c.capacityHistory.record(pool.Name, *utils.NodesAllocatable(poolNodes), now, c.settings.CapacitySmoothingWindow)
+	}
+}
+
+// handleNodeChange records the capacity seen before a node is deleted, or updated when it changes the capacity it provides
+// A deleted node has no new version
+func (c *Controller) handleNodeChange(old, new interface{}) {
+	if tombstone, ok := old.(cache.DeletedFinalStateUnknown); ok {
+		old = tombstone.Obj
+	}
+	oldNode, ok := old.(*v1Core.Node)
+	if !ok {
+		return
+	}
+	snapshot := c.withSettings()
+	if newNode, ok := new.(*v1Core.Node); ok && snapshot.settings.IsEligibleNode(oldNode) == snapshot.settings.IsEligibleNode(newNode) &&
+		quota.Equals(oldNode.Status.Allocatable, newNode.Status.Allocatable) {
+		return
+	}
+	snapshot.recordCapacitySamples(oldNode)
+}
+
+// Log and expose the capacity of the nodes of a pool when the smoothed one differs from the raw one
+func reportSmoothedCapacity(pool string, raw v1Core.ResourceList, smoothed v1Core.ResourceList) {
+	poolLabel := utils.NodePoolLabel(pool)
+	utils.SmoothedCapacityGauge.DeletePartialMatch(map[string]string{"pool": poolLabel})
+	for name, quantity := range raw {
+		utils.SmoothedCapacityGauge.WithLabelValues(poolLabel, string(name), "raw").Set(quantity.AsApproximateFloat64())
+	}
+	for name, quantity := range smoothed {
+		utils.SmoothedCapacityGauge.WithLabelValues(poolLabel, string(name), "smoothed").Set(quantity.AsApproximateFloat64())
+	}
+
+	if !quota.Equals(raw, smoothed) {
+		klog.Infof("Smoothed capacity of %s : %s instead of %s", poolLabel, formatResourceList(smoothed), formatResourceList(raw))
+		utils.CapacitySmoothedCounter.WithLabelValues(poolLabel).Inc()
+	}
+}
@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Return the claims waiting for capacity in the order they are admitted
+// A claim whose spec has been edited since it started waiting is evaluated again and leaves the queue
+// The claims of higher priority come first, then the ones that joined the queue first
+// Each node pool has its own queue, the namespaces bound to no pool share the queue of the whole cluster
+func (c *Controller) waitingClaims(claim *cagipv1.ResourceQuotaClaim) ([]*cagipv1.ResourceQuotaClaim, error) {
+	claims, err := c.resourceQuotaClaimLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	pool := poolName(c.namespacePool(claim.Namespace))
+	var waiting []*cagipv1.ResourceQuotaClaim
+	for _, other := range claims {
+		if other.Status.Phase == cagipv1.PhaseWaiting && other.Status.WaitingSince != nil && other.Status.ObservedGeneration == other.Generation && poolName(c.namespacePool(other.Namespace)) == pool {
+			waiting = append(waiting, other)
+		}
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return c.waitingOrder(waiting[i]).before(c.waitingOrder(waiting[j]))
+	})
+	return waiting, nil
+}
+
+// Return the order of a waiting claim in the queue
+func (c *Controller) waitingOrder(claim *cagipv1.ResourceQuotaClaim) admissionOrder {
+	return c.admissionOrder(claim, claim.Status.WaitingSince.Time)
+}
+
+// Return the 1-based position of a claim in the queue and the claim ahead of it, if any
+// A claim that is not waiting yet joins the queue behind the claims of the same or higher priority
+func (c *Controller) queuePosition(claim *cagipv1.ResourceQuotaClaim, waiting []*cagipv1.ResourceQuotaClaim) (position int32, ahead *cagipv1.ResourceQuotaClaim) {
+	index := len(waiting)
+	for i, other := range waiting {
+		if other.Namespace == claim.Namespace && other.Name == claim.Name {
+			index = i
+			break
+		}
+	}
+	if index == len(waiting) {
+		order := c.admissionOrder(claim, c.clock.Now())
+		index = sort.Search(len(waiting), func(i int) bool {
+			return !c.waitingOrder(waiting[i]).before(order)
+		})
+	}
+	if index > 0 {
+		ahead = waiting[index-1]
+	}
+	return int32(index + 1), ahead
+}
+
+// Check if claims that waited longer must be admitted before this one
+// Claims that do not grow the managed quota do not take capacity from the queue
+func (c *Controller) checkWaitingQueue(claim *cagipv1.ResourceQuotaClaim) (string, error) {
+	managedQuota, err := c.resourceQuotaLister.ResourceQuotas(claim.Namespace).Get(utils.ResourceQuotaName)
+	if err != nil && !errors.IsNotFound(err) {
+		return utils.EmptyMsg, err
+	}
+	if err == nil && !isUpscaleQuota(claim, managedQuota) {
+		return utils.EmptyMsg, nil
+	}
+
+	waiting, err := c.waitingClaims(claim)
+	if err != nil {
+		return utils.EmptyMsg, err
+	}
+	if position, ahead := c.queuePosition(claim, waiting); ahead != nil {
+		return fmt.Sprintf(utils.MessageWaitingBehind, position, claimKey(ahead)), nil
+	}
+	return utils.EmptyMsg, nil
+}
+
+// Check if the claim raises the managed quota, or sets a resource it does not limit yet
+func isUpscaleQuota(claim *cagipv1.ResourceQuotaClaim, managedQuota *v1Core.ResourceQuota) bool {
+	for name, claimed := range claim.Spec {
+		if current, found := managedQuota.Spec.Hard[name]; !found || claimed.Cmp(current) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Update claim phase to Waiting with its position in the queue
+// The claim is evaluated again when capacity is released
+func (c *Controller) claimWaiting(claim *cagipv1.ResourceQuotaClaim, reason string, msg string) (err error) {
+	waiting, err := c.waitingClaims(claim)
+	if err != nil {
+		return err
+	}
+	position, _ := c.queuePosition(claim, waiting)
+	if reason == cagipv1.ReasonInsufficientCapacity {
+		msg = fmt.Sprintf(utils.MessageWaitingForCapacity, position, msg)
+	}
+
+	// A claim that is already waiting only reports its new position
+	now := c.clock.Now()
+	waitingSince := claimWaitingSince(claim, now)
+	if !isStillWaiting(claim) {
+		klog.Infof("< RequestQuotaClaim '%s' set to WAITING >", claim.Name)
+		// Notify via an event
+		c.recorder.Event(claim, v1Core.EventTypeWarning, cagipv1.PhaseWaiting, msg)
+		utils.ClaimCounter.WithLabelValues("waiting").Inc()
+		c.countTierClaim(claim, "waiting")
+
+		// A claim of higher priority moves the claims behind it
+		if int(position) <= len(waiting) {
+			defer c.requeueWaitingClaims()
+		}
+	}
+
+	// Update ResourceQuotaClaim Status to Waiting Phase
+	status := newResourceQuotaClaimStatus(claim, cagipv1.PhaseWaiting, cagipv1.ReasonAwaitingCapacity, msg, metav1.NewTime(now))
+	status.WaitingSince = &waitingSince
+	status.QueuePosition = position
+	_, err = c.setResourceQuotaClaimStatus(claim, status)
+	return err
+}
+
+// Time at which a claim joined the queue, a spec edit sends it to the end of the queue
+func claimWaitingSince(claim *cagipv1.ResourceQuotaClaim, now time.Time) metav1.Time {
+	if isStillWaiting(claim) && claim.Status.WaitingSince != nil {
+		return *claim.Status.WaitingSince.DeepCopy()
+	}
+	return metav1.NewTime(now)
+}
+
+// Check if a claim is already waiting for capacity with its current spec
+func isStillWaiting(claim *cagipv1.ResourceQuotaClaim) bool {
+	return claim.Status.Phase == cagipv1.PhaseWaiting && claim.Status.ObservedGeneration == claim.Generation
+}
+
+// Put the claims waiting for capacity back on the work queue, along with the expired burst claims whose revert is pending
+// They are evaluated again when capacity is released or when the queue moves
+func (c *Controller) requeueWaitingClaims() {
+	claims, err := c.resourceQuotaClaimLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Could not retrieve the waiting claims : %s", err)
+		return
+	}
+
+	for _, claim := range claims {
+		if claim.Status.Phase == cagipv1.PhaseWaiting || isRevertPending(claim) {
+			c.enqueueResourceQuotaClaim(claim)
+		}
+	}
+}
+
+// handleResourceQuotaUpdate requeues the waiting claims when a quota releases capacity
+func (c *Controller) handleResourceQuotaUpdate(old, new interface{}) {
+	oldQuota, ok := old.(*v1Core.ResourceQuota)
+	if !ok {
+		return
+	}
+	newQuota, ok := new.(*v1Core.ResourceQuota)
+	if !ok || newQuota.ResourceVersion == oldQuota.ResourceVersion {
+		return
+	}
+	if isLoweredResourceList(oldQuota.Spec.Hard, newQuota.Spec.Hard) {
+		c.requeueWaitingClaims()
+	}
+}
+
+// handleNodeUpdate requeues the waiting claims when a node starts providing capacity or provides more of it
+func (c *Controller) handleNodeUpdate(old, new interface{}) {
+	oldNode, ok := old.(*v1Core.Node)
+	if !ok {
+		return
+	}
+	newNode, ok := new.(*v1Core.Node)
+	if !ok || newNode.ResourceVersion == oldNode.ResourceVersion {
+		return
+	}
+	settings := c.currentSettings.Load()
+	if !settings.IsEligibleNode(newNode) {
+		return
+	}
+	if !settings.IsEligibleNode(oldNode) || isLoweredResourceList(newNode.Status.Allocatable, oldNode.Status.Allocatable) {
+		c.requeueWaitingClaims()
+	}
+}
+
+// handleClaimDelete requeues the other waiting claims when a waiting claim leaves the queue
+func (c *Controller) handleClaimDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if claim, ok := obj.(*cagipv1.ResourceQuotaClaim); ok && claim.Status.Phase == cagipv1.PhaseWaiting {
+		c.requeueWaitingClaims()
+	}
+}
+
+// Check if a resource list lowers or removes at least one resource of a previous one
+func isLoweredResourceList(previous v1Core.ResourceList, current v1Core.ResourceList) bool {
+	for name, quantity := range previous {
+		if lowered, found := current[name]; !found || lowered.Cmp(quantity) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the namespace/name key of a claim
+func claimKey(claim *cagipv1.ResourceQuotaClaim) string {
+	return claim.Namespace + "/" + claim.Name
+}
@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/klog/v2"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClaimWebhook validates the ResourceQuotaClaims when they are created or edited
+// It rejects the claims the controller would reject whatever the usage of the cluster
+type ClaimWebhook struct {
+	controller *Controller
+}
+
+// Create a webhook validating the claims with the settings and the caches of a controller
+func NewClaimWebhook(controller *Controller) *ClaimWebhook {
+	return &ClaimWebhook{controller: controller}
+}
+
+// ServeHTTP answers an AdmissionReview request
+func (w *ClaimWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	serveAdmission(rw, r, w.review)
+}
+
+// Decode an AdmissionReview request and answer it with the decision of a review
+// The review returns the msg of a request to deny, an empty msg to allow it
+func serveAdmission(rw http.ResponseWriter, r *http.Request, review func(*admissionv1.AdmissionRequest) string) {
+	serveMutation(rw, r, func(request *admissionv1.AdmissionRequest) ([]byte, string) {
+		return nil, review(request)
+	})
+}
+
+// Decode an AdmissionReview request and answer it with the decision of a mutating review
+// The review returns the JSON patch to apply on an allowed request, nil when it is left as is
+func serveMutation(rw http.ResponseWriter, r *http.Request, review func(*admissionv1.AdmissionRequest) ([]byte, string)) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	admissionReview := admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(&admissionReview); err != nil || admissionReview.Request == nil {
+		http.Error(rw, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	request := admissionReview.Request
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+	patch, msg := review(request)
+	if msg == utils.EmptyMsg && patch != nil {
+		patchType := admissionv1.PatchTypeJSONPatch
+		response.Patch = patch
+		response.PatchType = &patchType
+	}
+	if msg != utils.EmptyMsg {
+		klog.Infof("< %s '%s/%s' denied at admission : %s >", request.Kind.Kind, request.Namespace, request.Name, msg)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: msg,
+		}
+	}
+
+	admissionReview.Response = response
+	admissionReview.Request = nil
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(rw).Encode(admissionReview)
+}
+
+// Return the msg of a request to deny, an empty msg to allow it
+// An edit that keeps the spec only has the annotations it changes checked, the claim has already been admitted
+func (w *ClaimWebhook) review(request *admissionv1.AdmissionRequest) string {
+	claim := &cagipv1.ResourceQuotaClaim{}
+	if err := json.Unmarshal(request.Object.Raw, claim); err != nil {
+		return fmt.Sprintf(utils.MessageInvalidClaim, err)
+	}
+	if claim.Namespace == "" {
+		claim.Namespace = request.Namespace
+	}
+
+	if request.Operation == admissionv1.Update {
+		previous := &cagipv1.ResourceQuotaClaim{}
+		if err := json.Unmarshal(request.OldObject.Raw, previous); err == nil && equality.Semantic.DeepEqual(previous.Spec, claim.Spec) {
+			return w.controller.withSettings().validateClaimAnnotations(previous, claim)
+		}
+	}
+
+	return w.controller.withSettings().validateClaim(claim)
+}
+
+// Check the annotations of a claim changed since its previous version, all of them when there is none
+// Return the msg of the first error found, an empty msg when the annotations are valid
+func (c *Controller) validateClaimAnnotations(previous *cagipv1.ResourceQuotaClaim, claim *cagipv1.ResourceQuotaClaim) string {
+	if isAnnotationChanged(previous, claim, cagipv1.AnnotationExpiresAt, cagipv1.AnnotationDuration) {
+		if _, msg := claimExpiry(claim, c.clock.Now()); msg != utils.EmptyMsg {
+			return msg
+		}
+	}
+	if isAnnotationChanged(previous, claim, cagipv1.AnnotationPriority) {
+		if _, msg := c.claimPriority(claim); msg != utils.EmptyMsg {
+			return msg
+		}
+	}
+	if isApprovalChanged(previous, claim) {
+		if _, _, msg := parseClaimDecision(claim); msg != utils.EmptyMsg {
+			return msg
+		}
+	}
+	return utils.EmptyMsg
+}
+
+// Check if a request changes some annotations of a claim, any annotation is new when there is no previous version
+func isAnnotationChanged(previous *cagipv1.ResourceQuotaClaim, claim *cagipv1.ResourceQuotaClaim, keys ...string) bool {
+	if previous == nil {
+		return true
+	}
+	for _, key := range keys {
+		previousValue, previousFound := previous.Annotations[key]
+		value, found := claim.Annotations[key]
+		if previousFound != found || previousValue != value {
+			return true
+		}
+	}
+	return false
+}
+
+// Check a claim independently of the usage of the cluster
+// Return the msg of the first error found, an empty msg when the claim is valid
+func (c *Controller) validateClaim(claim *cagipv1.ResourceQuotaClaim) string {
+	for _, name := range utils.SortedResourceNames(claim.Spec) {
+		if !utils.IsQuotaResourceName(name) {
+			return fmt.Sprintf(utils.MessageUnknownResource, name)
+		}
+		if quantity := claim.Spec[name]; quantity.Sign() < 0 {
+			return fmt.Sprintf(utils.MessageNegativeClaim, name, quantity.String())
+		}
+	}
+
+	// The annotations are checked as the controller does
+	if msg := c.validateClaimAnnotations(nil, claim); msg != utils.EmptyMsg {
+		return msg
+	}
+
+	// The allocation limit only depends on the capacity, it is not checked until the nodes are known
+	if !c.nodesSynced() {
+		return utils.EmptyMsg
+	}
+	reader := *c
+	reader.capacityReadOnly = true
+	availableResources, err := reader.claimCapacity(claim)
+	if err != nil || len(*availableResources) == 0 {
+		return utils.EmptyMsg
+	}
+	return c.checkAllocationLimit(claim, availableResources)
+}
+
+// ApprovalWebhook records the approver deciding on a claim and signs the decision
+// The controller only trusts the signed decisions, the approval annotations can not be forged by the authors of the claims
+type ApprovalWebhook struct {
+	controller *Controller
+}
+
+// Create a webhook signing the approvals with the key of a controller
+func NewApprovalWebhook(controller *Controller) *ApprovalWebhook {
+	return &ApprovalWebhook{controller: controller}
+}
+
+// ServeHTTP answers an AdmissionReview request
+func (w *ApprovalWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	serveMutation(rw, r, w.review)
+}
+
+// Return the patch recording the approver and the signature of a new decision, or the msg of a request to deny
+// A request that does not change the decision is left as is
+func (w *ApprovalWebhook) review(request *admissionv1.AdmissionRequest) ([]byte, string) {
+	claim := &cagipv1.ResourceQuotaClaim{}
+	if err := json.Unmarshal(request.Object.Raw, claim); err != nil {
+		return nil, fmt.Sprintf(utils.MessageInvalidClaim, err)
+	}
+	if claim.Namespace == "" {
+		claim.Namespace = request.Namespace
+	}
+	if claim.Name == "" {
+		claim.Name = request.Name
+	}
+	previous := &cagipv1.ResourceQuotaClaim{}
+	if request.Operation == admissionv1.Update {
+		if err := json.Unmarshal(request.OldObject.Raw, previous); err != nil {
+			return nil, fmt.Sprintf(utils.MessageInvalidClaim, err)
+		}
+	}
+
+	decision, found := claim.Annotations[cagipv1.AnnotationApproval]
+	if !found {
+		return nil, utils.EmptyMsg
+	}
+	if decision != cagipv1.ApprovalApproved && decision != cagipv1.ApprovalDenied {
+		return nil, fmt.Sprintf(utils.MessageInvalidApproval, cagipv1.AnnotationApproval, decision, cagipv1.AnnotationApprover)
+	}
+
+	// The decision covers the spec it has been taken on
+	if !isApprovalChanged(previous, claim) {
+		if request.Operation == admissionv1.Update && !equality.Semantic.DeepEqual(previous.Spec, claim.Spec) {
+			return nil, utils.MessageApprovedSpecEdit
+		}
+		return nil, utils.EmptyMsg
+	}
+
+	// The UID the decision is signed with is only assigned once the claim is created
+	if request.Operation == admissionv1.Create {
+		return nil, utils.MessageApprovalOnCreate
+	}
+
+	user := request.UserInfo
+	if approver, found := claim.Annotations[cagipv1.AnnotationApprover]; found && approver != user.Username {
+		return nil, fmt.Sprintf(utils.MessageApproverMismatch, cagipv1.AnnotationApprover, approver, user.Username)
+	}
+	settings := w.controller.currentSettings.Load()
+	allowed, err := w.controller.isApprover(user, claim, settings.ApproverGroup)
+	if err != nil {
+		klog.Errorf("Could not check the approval right of %s : %s", user.Username, err)
+	}
+	if !allowed {
+		return nil, fmt.Sprintf(utils.MessageNotApprover, user.Username, settings.ApproverGroup)
+	}
+
+	signature := utils.SignApproval(w.controller.approvalKey, claim, decision, user.Username)
+	patch, err := json.Marshal([]jsonPatchOperation{
+		{Op: "add", Path: annotationPath(cagipv1.AnnotationApprover), Value: user.Username},
+		{Op: "add", Path: annotationPath(cagipv1.AnnotationApprovalSignature), Value: signature},
+	})
+	if err != nil {
+		return nil, fmt.Sprintf(utils.MessageInvalidClaim, err)
+	}
+	klog.Infof("< RequestQuotaClaim '%s/%s' %s by %s >", claim.Namespace, claim.Name, decision, user.Username)
+	return patch, utils.EmptyMsg
+}
+
+// Check if a request sets, changes or signs again the decision on a claim
+func isApprovalChanged(previous *cagipv1.ResourceQuotaClaim, claim *cagipv1.ResourceQuotaClaim) bool {
+	return isAnnotationChanged(previous, claim, cagipv1.AnnotationApproval, cagipv1.AnnotationApprover, cagipv1.AnnotationApprovalSignature)
+}
+
+// Check if a user can approve or deny a claim
+// The members of the approver group can decide on any claim, the other users need the update verb on resourcequotaclaims/approval
+func (c *Controller) isApprover(user authenticationv1.UserInfo, claim *cagipv1.ResourceQuotaClaim, approverGroup string) (bool, error) {
+	for _, group := range user.Groups {
+		if approverGroup != "" && group == approverGroup {
+			return true, nil
+		}
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review, err := c.namespaceclientset.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   claim.Namespace,
+				Verb:        "update",
+				Group:       cagipv1.SchemeGroupVersion.Group,
+				Resource:    "resourcequotaclaims",
+				Subresource: "approval",
+				Name:        claim.Name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+// Operation of a JSON patch
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// Return the JSON patch path of an annotation, the slashes of its key are escaped
+func annotationPath(key string) string {
+	return "/metadata/annotations/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// QuotaWebhook protects the managed quotas from the writers other than the controller
+// The managed quotas are only changed by the claims, their checks can not be bypassed
+type QuotaWebhook struct {
+	writers map[string]bool
+}
+
+// Create a webhook only allowing some users, the controller among them, to write the managed quotas
+func NewQuotaWebhook(writers []string) *QuotaWebhook {
+	webhook := &QuotaWebhook{writers: map[string]bool{}}
+	for _, writer := range writers {
+		webhook.writers[writer] = true
+	}
+	return webhook
+}
+
+// ServeHTTP answers an AdmissionReview request
+func (w *QuotaWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	serveAdmission(rw, r, w.review)
+}
+
+// Return the msg of a request to deny, an empty msg to allow it
+// An edit that keeps the spec is allowed, labels can still be managed
+func (w *QuotaWebhook) review(request *admissionv1.AdmissionRequest) string {
+	if request.Name != utils.ResourceQuotaName || w.writers[request.UserInfo.Username] {
+		return utils.EmptyMsg
+	}
+
+	if request.Operation == admissionv1.Update {
+		resourceQuota := &v1Core.ResourceQuota{}
+		previous := &v1Core.ResourceQuota{}
+		if json.Unmarshal(request.Object.Raw, resourceQuota) == nil && json.Unmarshal(request.OldObject.Raw, previous) == nil &&
+			quota.Equals(previous.Spec.Hard, resourceQuota.Spec.Hard) {
+			return utils.EmptyMsg
+		}
+	}
+
+	return fmt.Sprintf(utils.MessageQuotaProtected, request.Namespace+"/"+request.Name, utils.ControllerName)
+}
@@ -0,0 +1,365 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-gip/kotary/internal/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	core "k8s.io/client-go/testing"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1Core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Send an AdmissionReview to the webhook and return its response
+func reviewClaim(t *testing.T, server *httptest.Server, operation admissionv1.Operation, object []byte, oldObject []byte) *admissionv1.AdmissionResponse {
+	return sendReview(t, server, &admissionv1.AdmissionRequest{
+		UID:       types.UID("review-1"),
+		Namespace: metav1.NamespaceDefault,
+		Name:      "test",
+		Operation: operation,
+		Object:    runtime.RawExtension{Raw: object},
+		OldObject: runtime.RawExtension{Raw: oldObject},
+	})
+}
+
+// Send an AdmissionRequest to the webhook and return its response
+func sendReview(t *testing.T, server *httptest.Server, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  request,
+	}
+	body, err := json.Marshal(review)
+	assert.NilError(t, err)
+
+	response, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NilError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, response.StatusCode, http.StatusOK)
+
+	answer := admissionv1.AdmissionReview{}
+	assert.NilError(t, json.NewDecoder(response.Body).Decode(&answer))
+	assert.Equal(t, answer.Response.UID, request.UID)
+	return answer.Response
+}
+
+// Encode a claim as the API server sends it to the webhook
+func encodeClaim(t *testing.T, claim *cagipv1.ResourceQuotaClaim) []byte {
+	raw, err := json.Marshal(claim)
+	assert.NilError(t, err)
+	return raw
+}
+
+func TestClaimWebhook(t *testing.T) {
+	newWebhookServer := func(t *testing.T) *httptest.Server {
+		f := newFixture(t)
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		c, _, _, _, _, _ := f.newController()
+		server := httptest.NewServer(NewClaimWebhook(c))
+		t.Cleanup(server.Close)
+		return server
+	}
+	validSpec := &v1Core.ResourceList{
+		v1Core.ResourceCPU:    resource.MustParse("200m"),
+		v1Core.ResourceMemory: resource.MustParse("1Gi"),
+	}
+
+	t.Run("valid claim should be allowed", func(t *testing.T) {
+		server := newWebhookServer(t)
+		response := reviewClaim(t, server, admissionv1.Create, encodeClaim(t, newTestResourceQuotaClaim("test", validSpec)), nil)
+		assert.Equal(t, response.Allowed, true)
+	})
+
+	t.Run("claim breaking the allocation limit should be denied with the controller message", func(t *testing.T) {
+		server := newWebhookServer(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("14000"),
+			v1Core.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		response := reviewClaim(t, server, admissionv1.Create, encodeClaim(t, claim), nil)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Reason, metav1.StatusReasonInvalid)
+		assert.Equal(t, response.Result.Message, "Exceeded CPU allocation limit claiming 14k but limited to 330m")
+	})
+
+	t.Run("negative and unknown resources should be denied", func(t *testing.T) {
+		server := newWebhookServer(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{v1Core.ResourceMemory: resource.MustParse("-1Gi")})
+		response := reviewClaim(t, server, admissionv1.Create, encodeClaim(t, claim), nil)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "Invalid memory claiming -1Gi, a quantity must not be negative")
+
+		claim = newTestResourceQuotaClaim("test", &v1Core.ResourceList{"nvidia.com/gpu": resource.MustParse("1")})
+		response = reviewClaim(t, server, admissionv1.Create, encodeClaim(t, claim), nil)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "Unknown resource nvidia.com/gpu, it can not be limited by a ResourceQuota")
+
+		claim = newTestResourceQuotaClaim("test", &v1Core.ResourceList{"requests.nvidia.com/gpu": resource.MustParse("1")})
+		response = reviewClaim(t, server, admissionv1.Create, encodeClaim(t, claim), nil)
+		assert.Equal(t, response.Allowed, true)
+	})
+
+	t.Run("syntactically invalid claim should be denied", func(t *testing.T) {
+		server := newWebhookServer(t)
+		raw := []byte(`{"apiVersion":"cagip.github.com/v1","kind":"ResourceQuotaClaim","metadata":{"name":"test"},"spec":{"cpu":"fourteen"}}`)
+		response := reviewClaim(t, server, admissionv1.Create, raw, nil)
+		assert.Equal(t, response.Allowed, false)
+		assert.Assert(t, strings.HasPrefix(response.Result.Message, "Invalid claim: "), response.Result.Message)
+	})
+
+	t.Run("invalid annotations should be denied", func(t *testing.T) {
+		server := newWebhookServer(t)
+		claim := newTestResourceQuotaClaim("test", validSpec)
+		claim.Annotations = map[string]string{cagipv1.AnnotationPriority: "high"}
+		response := reviewClaim(t, server, admissionv1.Create, encodeClaim(t, claim), nil)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "Invalid cagip.github.com/priority annotation high, an integer is expected")
+	})
+
+	t.Run("edit keeping the spec should be allowed", func(t *testing.T) {
+		server := newWebhookServer(t)
+		claim := newTestResourceQuotaClaim("test", &v1Core.ResourceList{v1Core.ResourceCPU: resource.MustParse("14000")})
+		edited := claim.DeepCopy()
+		edited.Labels = map[string]string{"team": "a"}
+		response := reviewClaim(t, server, admissionv1.Update, encodeClaim(t, edited), encodeClaim(t, claim))
+		assert.Equal(t, response.Allowed, true)
+	})
+
+	t.Run("edit keeping the spec should have the annotations it changes checked", func(t *testing.T) {
+		server := newWebhookServer(t)
+		claim := newTestResourceQuotaClaim("test", validSpec)
+		claim.Annotations = map[string]string{cagipv1.AnnotationExpiresAt: "2000-01-01T00:00:00Z"}
+
+		edited := claim.DeepCopy()
+		edited.Annotations[cagipv1.AnnotationPriority] = "high"
+		response := reviewClaim(t, server, admissionv1.Update, encodeClaim(t, edited), encodeClaim(t, claim))
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "Invalid cagip.github.com/priority annotation high, an integer is expected")
+
+		edited = claim.DeepCopy()
+		edited.Annotations[cagipv1.AnnotationApproval] = "maybe"
+		response = reviewClaim(t, server, admissionv1.Update, encodeClaim(t, edited), encodeClaim(t, claim))
+		assert.Equal(t, response.Allowed, false)
+
+		edited = claim.DeepCopy()
+		edited.Annotations[cagipv1.AnnotationExpiresAt] = "tomorrow"
+		response = reviewClaim(t, server, admissionv1.Update, encodeClaim(t, edited), encodeClaim(t, claim))
+		assert.Equal(t, response.Allowed, false)
+
+		// The expiry already admitted is not checked again
+		edited = claim.DeepCopy()
+		edited.Annotations[cagipv1.AnnotationPriority] = "10"
+		response = reviewClaim(t, server, admissionv1.Update, encodeClaim(t, edited), encodeClaim(t, claim))
+		assert.Equal(t, response.Allowed, true)
+	})
+
+	t.Run("review should read the capacity without recording it", func(t *testing.T) {
+		f := newFixture(t)
+		f.settings.CapacitySmoothingWindow = 10 * time.Minute
+		f.settings.CapacityProviders = []cagipv1.CapacityProviderSpec{
+			{Type: cagipv1.CapacityProviderNodes},
+			{Type: cagipv1.CapacityProviderKarpenter},
+		}
+		f.nodeLister = newTestNodes(1, &v1Core.ResourceList{
+			v1Core.ResourceCPU:    resource.MustParse("1"),
+			v1Core.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		c, _, _, _, _, _ := f.newController()
+		server := httptest.NewServer(NewClaimWebhook(c))
+		t.Cleanup(server.Close)
+		utils.CapacityNodesGauge.Reset()
+
+		response := reviewClaim(t, server, admissionv1.Create, encodeClaim(t, newTestResourceQuotaClaim("test", validSpec)), nil)
+		assert.Equal(t, response.Allowed, true)
+		assert.Equal(t, testutil.CollectAndCount(utils.CapacityNodesGauge), 0)
+		assert.Equal(t, len(c.capacityHistory.samples), 0)
+		assert.Equal(t, len(f.dynamicclientset.Actions()), 0)
+	})
+
+	t.Run("request that is not an AdmissionReview should be refused", func(t *testing.T) {
+		server := newWebhookServer(t)
+		response, err := http.Post(server.URL, "application/json", bytes.NewReader([]byte("{")))
+		assert.NilError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, response.StatusCode, http.StatusBadRequest)
+	})
+}
+
+func TestQuotaWebhook(t *testing.T) {
+	const controllerUser = "system:serviceaccount:kube-system:kotary"
+	server := httptest.NewServer(NewQuotaWebhook([]string{controllerUser}))
+	t.Cleanup(server.Close)
+
+	managedQuota := newTestResourceQuota(metav1.NamespaceDefault, utils.ResourceQuotaName, &v1Core.ResourceList{
+		v1Core.ResourceMemory: resource.MustParse("4Gi"),
+	})
+	encodeQuota := func(resourceQuota *v1Core.ResourceQuota) []byte {
+		raw, err := json.Marshal(resourceQuota)
+		assert.NilError(t, err)
+		return raw
+	}
+	reviewQuota := func(operation admissionv1.Operation, username string, name string, object *v1Core.ResourceQuota) *admissionv1.AdmissionResponse {
+		return sendReview(t, server, &admissionv1.AdmissionRequest{
+			UID:       types.UID("review-1"),
+			Namespace: metav1.NamespaceDefault,
+			Name:      name,
+			Operation: operation,
+			UserInfo:  authenticationv1.UserInfo{Username: username},
+			Object:    runtime.RawExtension{Raw: encodeQuota(object)},
+			OldObject: runtime.RawExtension{Raw: encodeQuota(managedQuota)},
+		})
+	}
+	edited := managedQuota.DeepCopy()
+	edited.Spec.Hard[v1Core.ResourceMemory] = resource.MustParse("64Gi")
+
+	t.Run("edit of the managed quota by a user should be denied", func(t *testing.T) {
+		response := reviewQuota(admissionv1.Update, "jane", utils.ResourceQuotaName, edited)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "ResourceQuota default/managed-quota is managed by kotary-controller, it can only be changed by a ResourceQuotaClaim")
+
+		response = reviewQuota(admissionv1.Delete, "jane", utils.ResourceQuotaName, managedQuota)
+		assert.Equal(t, response.Allowed, false)
+	})
+
+	t.Run("edit of the managed quota by the controller should be allowed", func(t *testing.T) {
+		response := reviewQuota(admissionv1.Update, controllerUser, utils.ResourceQuotaName, edited)
+		assert.Equal(t, response.Allowed, true)
+	})
+
+	t.Run("edit keeping the spec and other quotas should be allowed", func(t *testing.T) {
+		labeled := managedQuota.DeepCopy()
+		labeled.Labels["team"] = "a"
+		response := reviewQuota(admissionv1.Update, "jane", utils.ResourceQuotaName, labeled)
+		assert.Equal(t, response.Allowed, true)
+
+		response = reviewQuota(admissionv1.Update, "jane", "team-quota", edited)
+		assert.Equal(t, response.Allowed, true)
+	})
+}
+
+func TestApprovalWebhook(t *testing.T) {
+	newApprovalServer := func(t *testing.T) (*fixture, *httptest.Server) {
+		f := newFixture(t)
+		f.settings.ApproverGroup = "kotary-approvers"
+		c, _, _, _, _, _ := f.newController()
+		server := httptest.NewServer(NewApprovalWebhook(c))
+		t.Cleanup(server.Close)
+		return f, server
+	}
+	spec := &v1Core.ResourceList{v1Core.ResourceMemory: resource.MustParse("4Gi")}
+	claim := newTestResourceQuotaClaim("test", spec)
+	claim.UID = "test-claim"
+	newDecision := func(decision string, approver string) *cagipv1.ResourceQuotaClaim {
+		decided := claim.DeepCopy()
+		decided.Annotations = map[string]string{cagipv1.AnnotationApproval: decision}
+		if approver != "" {
+			decided.Annotations[cagipv1.AnnotationApprover] = approver
+		}
+		return decided
+	}
+	reviewDecision := func(server *httptest.Server, user authenticationv1.UserInfo, object *cagipv1.ResourceQuotaClaim, oldObject *cagipv1.ResourceQuotaClaim) *admissionv1.AdmissionResponse {
+		return sendReview(t, server, &admissionv1.AdmissionRequest{
+			UID:       types.UID("review-1"),
+			Namespace: metav1.NamespaceDefault,
+			Name:      "test",
+			Operation: admissionv1.Update,
+			UserInfo:  user,
+			Object:    runtime.RawExtension{Raw: encodeClaim(t, object)},
+			OldObject: runtime.RawExtension{Raw: encodeClaim(t, oldObject)},
+		})
+	}
+	signedPatch := func(approver string) string {
+		signature := utils.SignApproval(testApprovalKey, claim, cagipv1.ApprovalApproved, approver)
+		patch, err := json.Marshal([]jsonPatchOperation{
+			{Op: "add", Path: "/metadata/annotations/cagip.github.com~1approver", Value: approver},
+			{Op: "add", Path: "/metadata/annotations/cagip.github.com~1approval-signature", Value: signature},
+		})
+		assert.NilError(t, err)
+		return string(patch)
+	}
+
+	t.Run("decision of a member of the approver group should be signed", func(t *testing.T) {
+		_, server := newApprovalServer(t)
+		user := authenticationv1.UserInfo{Username: "alice", Groups: []string{"kotary-approvers"}}
+		response := reviewDecision(server, user, newDecision(cagipv1.ApprovalApproved, ""), claim)
+		assert.Equal(t, response.Allowed, true)
+		assert.Equal(t, *response.PatchType, admissionv1.PatchTypeJSONPatch)
+		assert.Equal(t, string(response.Patch), signedPatch("alice"))
+	})
+
+	t.Run("decision of a user allowed on the approval subresource should be signed", func(t *testing.T) {
+		f, server := newApprovalServer(t)
+		f.namespaceclientset.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+			review := action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			assert.Equal(t, review.Spec.ResourceAttributes.Subresource, "approval")
+			review.Status.Allowed = review.Spec.User == "alice"
+			return true, review, nil
+		})
+		response := reviewDecision(server, authenticationv1.UserInfo{Username: "alice"}, newDecision(cagipv1.ApprovalApproved, "alice"), claim)
+		assert.Equal(t, response.Allowed, true)
+		assert.Equal(t, string(response.Patch), signedPatch("alice"))
+
+		response = reviewDecision(server, authenticationv1.UserInfo{Username: "mallory"}, newDecision(cagipv1.ApprovalApproved, ""), claim)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "Only the approvers can approve or deny a claim, mallory is not in the kotary-approvers group")
+	})
+
+	t.Run("decision on behalf of another approver should be denied", func(t *testing.T) {
+		_, server := newApprovalServer(t)
+		user := authenticationv1.UserInfo{Username: "mallory", Groups: []string{"kotary-approvers"}}
+		response := reviewDecision(server, user, newDecision(cagipv1.ApprovalApproved, "alice"), claim)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "The cagip.github.com/approver annotation must be the user approving or denying the claim, alice is not mallory")
+	})
+
+	t.Run("spec edit keeping the approval should be denied", func(t *testing.T) {
+		_, server := newApprovalServer(t)
+		approved := newDecision(cagipv1.ApprovalApproved, "alice")
+		approved.Annotations[cagipv1.AnnotationApprovalSignature] = utils.SignApproval(testApprovalKey, approved, cagipv1.ApprovalApproved, "alice")
+		edited := approved.DeepCopy()
+		edited.Spec[v1Core.ResourceMemory] = resource.MustParse("64Gi")
+		user := authenticationv1.UserInfo{Username: "jane"}
+		response := reviewDecision(server, user, edited, approved)
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "The approval must be removed before editing the spec of the claim")
+
+		labeled := approved.DeepCopy()
+		labeled.Labels = map[string]string{"team": "a"}
+		response = reviewDecision(server, user, labeled, approved)
+		assert.Equal(t, response.Allowed, true)
+		assert.Assert(t, response.Patch == nil)
+	})
+
+	t.Run("decision set on creation should be denied", func(t *testing.T) {
+		_, server := newApprovalServer(t)
+		created := newDecision(cagipv1.ApprovalApproved, "")
+		created.UID = ""
+		response := sendReview(t, server, &admissionv1.AdmissionRequest{
+			UID:       types.UID("review-1"),
+			Namespace: metav1.NamespaceDefault,
+			Name:      "test",
+			Operation: admissionv1.Create,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice", Groups: []string{"kotary-approvers"}},
+			Object:    runtime.RawExtension{Raw: encodeClaim(t, created)},
+		})
+		assert.Equal(t, response.Allowed, false)
+		assert.Equal(t, response.Result.Message, "A claim can only be approved or denied once created")
+	})
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Sign the spec accepted by the controller for the managed quota of a namespace
+// Return the value of the accepted spec annotation and its signature
+func SignAcceptedSpec(key []byte, namespace string, hard v1.ResourceList) (string, string) {
+	spec, _ := json.Marshal(hard)
+	return string(spec), acceptedSpecSignature(key, namespace, string(spec))
+}
+
+// Return the accepted spec recorded on a managed quota, false when it is missing or its signature is not valid
+func VerifyAcceptedSpec(key []byte, resourceQuota *v1.ResourceQuota) (v1.ResourceList, bool) {
+	spec, found := resourceQuota.Annotations[cagipv1.AnnotationAcceptedSpec]
+	if !found || len(key) == 0 {
+		return nil, false
+	}
+	signature := resourceQuota.Annotations[cagipv1.AnnotationAcceptedSpecSignature]
+	if !hmac.Equal([]byte(signature), []byte(acceptedSpecSignature(key, resourceQuota.Namespace, spec))) {
+		return nil, false
+	}
+	hard := v1.ResourceList{}
+	if err := json.Unmarshal([]byte(spec), &hard); err != nil {
+		return nil, false
+	}
+	return hard, true
+}
+
+// The signature is bound to the accepted specs, it can not be mistaken for the one of an approval decision
+func acceptedSpecSignature(key []byte, namespace string, spec string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{"accepted-spec", namespace, spec}, "\n")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
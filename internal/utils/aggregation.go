@@ -1,51 +1,115 @@
 package utils
 
 import (
+	"strings"
+	"time"
+
 	"k8s.io/apimachinery/pkg/api/resource"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	resourcehelper "k8s.io/component-helpers/resource"
 
 	underscore "github.com/ahl5esoft/golang-underscore"
 	v1 "k8s.io/api/core/v1"
 )
 
-func PodsCpuRequest(pods []*v1.Pod) (result int64) {
-	underscore.
-		Chain(pods).
-		Map(func(pod v1.Pod, _ int) []v1.Container { return pod.Spec.Containers }).
-		Map(func(containers []v1.Container, _ int) (cpuReq int64) {
-			underscore.
-				Chain(containers).
-				Aggregate(int64(0), func(acc int64, cur v1.Container, _ int) int64 { return acc + cur.Resources.Requests.Cpu().MilliValue() }).
-				Value(&cpuReq)
-			return
-		}).
-		Aggregate(int64(0), func(acc int64, cur int64, _ int) int64 { return acc + cur }).
-		Value(&result)
+// Sum the effective requests of the pods for every resource, keyed by capacity resource name
+// The requests follow the rules of the ResourceQuota admission : the largest init container,
+// sidecars, pod overhead and the resources resized in place are accounted as the scheduler does
+func TotalRequestNS(pods []*v1.Pod) *v1.ResourceList {
+	total := v1.ResourceList{
+		v1.ResourceCPU:    *resource.NewMilliQuantity(0, resource.DecimalSI),
+		v1.ResourceMemory: *resource.NewQuantity(0, resource.BinarySI),
+	}
+	for _, pod := range pods {
+		total = quota.Add(total, resourcehelper.PodRequests(pod, podResourcesOptions))
+	}
+	return &total
+}
 
-	return
+// Sum the usage the ResourceQuota admission charges to the pods, keyed by quota resource name
+// Pending pods are charged as soon as they are created, terminal pods and pods stuck terminating are not
+func PodsQuotaUsage(pods []*v1.Pod, now time.Time) *v1.ResourceList {
+	total := v1.ResourceList{}
+	for _, pod := range pods {
+		total = quota.Add(total, v1.ResourceList{podObjectCountName: *resource.NewQuantity(1, resource.DecimalSI)})
+		if !IsQuotaChargedPod(pod, now) {
+			continue
+		}
+		total = quota.Add(total, podQuotaUsage(
+			resourcehelper.PodRequests(pod, podResourcesOptions),
+			resourcehelper.PodLimits(pod, podResourcesOptions)))
+	}
+	return &total
 }
 
-func PodsMemRequest(pods []*v1.Pod) (result int64) {
-	underscore.
-		Chain(pods).
-		Map(func(pod v1.Pod, _ int) []v1.Container { return pod.Spec.Containers }).
-		Map(func(containers []v1.Container, _ int) (memReq int64) {
-			underscore.
-				Chain(containers).
-				Aggregate(int64(0), func(acc int64, cur v1.Container, _ int) int64 { return acc + cur.Resources.Requests.Memory().Value() }).
-				Value(&memReq)
-			return
-		}).
-		Aggregate(int64(0), func(acc int64, cur int64, _ int) int64 { return acc + cur }).
-		Value(&result)
+// Check if an update of a pod lowers the usage charged to the quota of its namespace for any resource
+func LowersQuotaUsage(old *v1.Pod, new *v1.Pod, now time.Time) bool {
+	oldUsage := PodsQuotaUsage([]*v1.Pod{old}, now)
+	newUsage := PodsQuotaUsage([]*v1.Pod{new}, now)
+	for name, quantity := range *oldUsage {
+		if current, found := (*newUsage)[name]; !found || current.Cmp(quantity) < 0 {
+			return true
+		}
+	}
+	return false
+}
 
-	return
+// Check that the compute resources of a pod are charged to the quota of its namespace
+func IsQuotaChargedPod(pod *v1.Pod, now time.Time) bool {
+	if pod.Status.Phase == v1.PodFailed || pod.Status.Phase == v1.PodSucceeded {
+		return false
+	}
+	// Pods stuck terminating (lost node) are released after their grace period
+	if pod.DeletionTimestamp != nil && pod.DeletionGracePeriodSeconds != nil {
+		gracePeriod := time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+		if now.After(pod.DeletionTimestamp.Add(gracePeriod)) {
+			return false
+		}
+	}
+	return true
 }
 
-func TotalRequestNS(pods []*v1.Pod) *v1.ResourceList {
-	return &v1.ResourceList{
-		v1.ResourceCPU:    *resource.NewMilliQuantity(PodsCpuRequest(pods), resource.DecimalSI),
-		v1.ResourceMemory: *resource.NewQuantity(PodsMemRequest(pods), resource.BinarySI),
+// Map the requests and limits of a pod to the quota resource names charged by the admission
+func podQuotaUsage(requests v1.ResourceList, limits v1.ResourceList) v1.ResourceList {
+	usage := v1.ResourceList{v1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI)}
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage} {
+		if request, found := requests[name]; found {
+			usage[name] = request
+			usage[v1.DefaultResourceRequestsPrefix+name] = request
+		}
+		if limit, found := limits[name]; found {
+			usage[v1.ResourceName("limits."+name)] = limit
+		}
+	}
+	for name, request := range requests {
+		if strings.HasPrefix(string(name), v1.ResourceHugePagesPrefix) {
+			usage[name] = request
+			usage[v1.DefaultResourceRequestsPrefix+name] = request
+		} else if isDeviceResource(name) {
+			// Extended resources are only quoted on their requests.* name
+			usage[v1.DefaultResourceRequestsPrefix+name] = request
+		}
+	}
+	return usage
+}
+
+// Sum the allocatable of the nodes for every resource
+func NodesAllocatable(workerNodes []*v1.Node) *v1.ResourceList {
+	total := v1.ResourceList{
+		v1.ResourceCPU:    *resource.NewMilliQuantity(NodesCpuAllocatable(workerNodes), resource.DecimalSI),
+		v1.ResourceMemory: *resource.NewQuantity(NodesMemAllocatable(workerNodes), resource.BinarySI),
+	}
+	for _, node := range workerNodes {
+		for name, quantity := range node.Status.Allocatable {
+			if name == v1.ResourceCPU || name == v1.ResourceMemory {
+				continue
+			}
+			sum := total[name]
+			sum.Add(quantity)
+			total[name] = sum
+		}
 	}
+	return &total
 }
 
 func NodesCpuAllocatable(workerNodes []*v1.Node) (result int64) {
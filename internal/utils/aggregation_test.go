@@ -2,14 +2,18 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	quota "k8s.io/apiserver/pkg/quota/v1"
 )
 
 func TestTotalRequestNS(t *testing.T) {
+	sidecarRestartPolicy := v1.ContainerRestartPolicyAlways
+
 	testCases := map[string]struct {
 		pods   []*v1.Pod
 		expect *v1.ResourceList
@@ -114,6 +118,85 @@ func TestTotalRequestNS(t *testing.T) {
 				v1.ResourceMemory: resource.MustParse("4Gi"),
 			},
 		},
+		"1 pod requesting a gpu": {
+			pods: []*v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-pod-0",
+					},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("1"),
+										v1.ResourceMemory: resource.MustParse("2Gi"),
+										"nvidia.com/gpu":  resource.MustParse("1"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expect: &v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("2Gi"),
+				"nvidia.com/gpu":  resource.MustParse("1"),
+			},
+		},
+		"1 pod with init containers, a sidecar and overhead": {
+			pods: []*v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-pod-0",
+					},
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{
+							{
+								Name: "sidecar",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("100m"),
+										v1.ResourceMemory: resource.MustParse("512Mi"),
+									},
+								},
+								RestartPolicy: &sidecarRestartPolicy,
+							},
+							{
+								Name: "migration",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("3"),
+										v1.ResourceMemory: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+						Containers: []v1.Container{
+							{
+								Name: "app",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("1"),
+										v1.ResourceMemory: resource.MustParse("2Gi"),
+									},
+								},
+							},
+						},
+						Overhead: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("1"),
+							v1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				},
+			},
+			// max(sidecar + migration, sidecar + app) + overhead
+			expect: &v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4100m"),
+				v1.ResourceMemory: resource.MustParse("3Gi"),
+			},
+		},
 		"2 pods without container": {
 			pods: []*v1.Pod{
 				{
@@ -144,9 +227,120 @@ func TestTotalRequestNS(t *testing.T) {
 		t.Run(testName, func(t *testing.T) {
 			result := TotalRequestNS(testCase.pods)
 
-			assert.Equal(t, result.Cpu().Value(), testCase.expect.Cpu().Value())
+			assert.Equal(t, result.Cpu().MilliValue(), testCase.expect.Cpu().MilliValue())
 			assert.Equal(t, result.Memory().Value(), testCase.expect.Memory().Value())
+			assert.Equal(t, result.Name("nvidia.com/gpu", resource.DecimalSI).Value(), testCase.expect.Name("nvidia.com/gpu", resource.DecimalSI).Value())
 		})
 	}
 
 }
+
+func TestPodsQuotaUsage(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	gracePeriod := int64(30)
+
+	newPod := func(name string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse("1"),
+								v1.ResourceMemory: resource.MustParse("1Gi"),
+								"nvidia.com/gpu":  resource.MustParse("1"),
+							},
+							Limits: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse("2"),
+								v1.ResourceMemory: resource.MustParse("1Gi"),
+								"nvidia.com/gpu":  resource.MustParse("1"),
+							},
+						},
+					},
+				},
+			},
+			Status: v1.PodStatus{
+				Phase: phase,
+			},
+		}
+	}
+
+	stuck := newPod("stuck", v1.PodRunning)
+	stuck.DeletionTimestamp = &metav1.Time{Time: now.Add(-time.Minute)}
+	stuck.DeletionGracePeriodSeconds = &gracePeriod
+
+	terminating := newPod("terminating", v1.PodRunning)
+	terminating.DeletionTimestamp = &metav1.Time{Time: now}
+	terminating.DeletionGracePeriodSeconds = &gracePeriod
+
+	result := PodsQuotaUsage([]*v1.Pod{
+		newPod("running", v1.PodRunning),
+		newPod("pending", v1.PodPending),
+		newPod("succeeded", v1.PodSucceeded),
+		newPod("failed", v1.PodFailed),
+		stuck,
+		terminating,
+	}, now)
+
+	expect := v1.ResourceList{
+		podObjectCountName:        resource.MustParse("6"),
+		v1.ResourcePods:           resource.MustParse("3"),
+		v1.ResourceCPU:            resource.MustParse("3"),
+		v1.ResourceRequestsCPU:    resource.MustParse("3"),
+		v1.ResourceLimitsCPU:      resource.MustParse("6"),
+		v1.ResourceMemory:         resource.MustParse("3Gi"),
+		v1.ResourceRequestsMemory: resource.MustParse("3Gi"),
+		v1.ResourceLimitsMemory:   resource.MustParse("3Gi"),
+		"requests.nvidia.com/gpu": resource.MustParse("3"),
+	}
+	assert.Assert(t, quota.Equals(*result, expect), "usage %v, expected %v", *result, expect)
+}
+
+func TestLowersQuotaUsage(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	running := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("1"),
+							v1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+		},
+	}
+
+	relabeled := running.DeepCopy()
+	relabeled.Labels = map[string]string{"app": "test"}
+
+	failed := running.DeepCopy()
+	failed.Status.Phase = v1.PodFailed
+
+	resized := running.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("500m")
+	resized.Status.ContainerStatuses = []v1.ContainerStatus{{
+		Resources: &v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("500m"),
+				v1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		},
+	}}
+
+	started := running.DeepCopy()
+	started.Status.Phase = v1.PodPending
+
+	assert.Equal(t, LowersQuotaUsage(running, relabeled, now), false)
+	assert.Equal(t, LowersQuotaUsage(running, failed, now), true)
+	assert.Equal(t, LowersQuotaUsage(running, resized, now), true)
+	assert.Equal(t, LowersQuotaUsage(started, running, now), false)
+}
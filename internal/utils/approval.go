@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Secret holding the key signing the approval decisions, shared by the replicas of the controller
+const ApprovalKeySecretName = "kotary-approval-key"
+
+// Key of the Secret data holding the signing key
+const approvalKeyField = "key"
+
+// Size in bytes of a generated signing key
+const approvalKeySize = 32
+
+// Load the key signing the approval decisions, it is generated when the Secret does not exist yet
+func LoadApprovalKey(clientset kubernetes.Interface, namespace string) ([]byte, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), ApprovalKeySecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		key := make([]byte, approvalKeySize)
+		if _, err = rand.Read(key); err != nil {
+			return nil, err
+		}
+		secret, err = clientset.CoreV1().Secrets(namespace).Create(context.TODO(), &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: ApprovalKeySecretName, Namespace: namespace},
+			Data:       map[string][]byte{approvalKeyField: key},
+		}, metav1.CreateOptions{})
+		// Another replica created it meanwhile
+		if errors.IsAlreadyExists(err) {
+			secret, err = clientset.CoreV1().Secrets(namespace).Get(context.TODO(), ApprovalKeySecretName, metav1.GetOptions{})
+		} else if err == nil {
+			klog.Infof("Generated the approval signing key in Secret %s/%s", namespace, ApprovalKeySecretName)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data[approvalKeyField], nil
+}
+
+// Sign the decision of an approver on a claim
+// The signature covers the claim identity and its spec, it is not valid anymore once the spec is edited
+// The UID is part of the identity, a decision copied onto a claim created again under the same name is not valid either
+func SignApproval(key []byte, claim *cagipv1.ResourceQuotaClaim, decision string, approver string) string {
+	spec, _ := json.Marshal(claim.Spec)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{claim.Namespace, claim.Name, string(claim.UID), decision, approver, string(spec)}, "\n")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Check the signature of the decision recorded on a claim
+func VerifyApproval(key []byte, claim *cagipv1.ResourceQuotaClaim, decision string, approver string) bool {
+	if len(key) == 0 {
+		return false
+	}
+	signature := claim.Annotations[cagipv1.AnnotationApprovalSignature]
+	return hmac.Equal([]byte(signature), []byte(SignApproval(key, claim, decision, approver)))
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Status ConfigMap of cluster-autoscaler when it is not set
+const defaultAutoscalerStatusConfigMap = "kube-system/cluster-autoscaler-status"
+
+// Capacity providers used when none is set
+var capacityProvidersByDefault = []cagipv1.CapacityProviderSpec{{Type: cagipv1.CapacityProviderNodes}}
+
+// Return the capacity providers to query, the ready worker nodes when none is set
+func (c Config) EffectiveCapacityProviders() []cagipv1.CapacityProviderSpec {
+	if len(c.CapacityProviders) == 0 {
+		return capacityProvidersByDefault
+	}
+	return c.CapacityProviders
+}
+
+// Split the namespace/name of the status ConfigMap of cluster-autoscaler
+func AutoscalerStatusConfigMap(provider cagipv1.CapacityProviderSpec) (namespace string, name string) {
+	key := provider.StatusConfigMap
+	if key == "" {
+		key = defaultAutoscalerStatusConfigMap
+	}
+	namespace, name, _ = strings.Cut(key, "/")
+	return namespace, name
+}
+
+// Check the capacity providers and the way they are combined
+func validateCapacityProviders(providers []cagipv1.CapacityProviderSpec, combination string) (errs []string) {
+	switch combination {
+	case "", cagipv1.CapacityCombinationMax, cagipv1.CapacityCombinationSum:
+	default:
+		errs = append(errs, fmt.Sprintf(MessageInvalidCapacityCombination, combination))
+	}
+
+	for i, provider := range providers {
+		field := fmt.Sprintf("capacityProviders[%d]", i)
+		switch provider.Type {
+		case cagipv1.CapacityProviderNodes, cagipv1.CapacityProviderKarpenter:
+		case cagipv1.CapacityProviderStatic:
+			if len(provider.Resources) == 0 {
+				errs = append(errs, fmt.Sprintf(MessageTierMissingField, field, "resources"))
+			}
+			for _, name := range SortedResourceNames(provider.Resources) {
+				if quantity := provider.Resources[name]; quantity.Sign() < 0 {
+					errs = append(errs, fmt.Sprintf(MessagePolicyNegativeQuantity, fmt.Sprintf("%s.resources.%s", field, name), quantity.String()))
+				}
+			}
+		case cagipv1.CapacityProviderClusterAutoscaler:
+			if provider.NodeGroupLabel == "" {
+				errs = append(errs, fmt.Sprintf(MessageTierMissingField, field, "nodeGroupLabel"))
+			}
+			if namespace, name := AutoscalerStatusConfigMap(provider); namespace == "" || name == "" || strings.Contains(name, "/") {
+				errs = append(errs, fmt.Sprintf(MessageInvalidStatusConfigMap, field, provider.StatusConfigMap))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf(MessageUnknownCapacityProvider, field, provider.Type))
+		}
+	}
+	return errs
+}
+
+// Check the settings selecting the nodes that provide capacity
+func validateNodeEligibility(eligibility cagipv1.NodeEligibilitySpec) (errs []string) {
+	if eligibility.NodeSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(eligibility.NodeSelector); err != nil {
+			errs = append(errs, fmt.Sprintf("nodeEligibility.nodeSelector: %s", err))
+		}
+	}
+	for i, taint := range eligibility.ExcludedTaints {
+		if taint.Key == "" {
+			errs = append(errs, fmt.Sprintf(MessageTierMissingField, fmt.Sprintf("nodeEligibility.excludedTaints[%d]", i), "key"))
+		}
+	}
+	for i, toleration := range eligibility.Tolerations {
+		switch toleration.Operator {
+		case "", v1.TolerationOpExists, v1.TolerationOpEqual:
+		default:
+			errs = append(errs, fmt.Sprintf(MessageInvalidTolerationOperator, fmt.Sprintf("nodeEligibility.tolerations[%d]", i), toleration.Operator))
+		}
+	}
+	for _, roleLabel := range eligibility.ExcludedRoleLabels {
+		for _, msg := range validation.IsQualifiedName(roleLabel) {
+			errs = append(errs, fmt.Sprintf("nodeEligibility.excludedRoleLabels: %s %s", roleLabel, msg))
+		}
+	}
+	return errs
+}
+
+// Check that the maintenance annotation is a valid annotation key
+func validateMaintenanceAnnotation(annotation string) (errs []string) {
+	if annotation == "" {
+		return nil
+	}
+	for _, msg := range validation.IsQualifiedName(annotation) {
+		errs = append(errs, fmt.Sprintf("maintenanceAnnotation: %s %s", annotation, msg))
+	}
+	return errs
+}
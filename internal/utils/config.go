@@ -2,10 +2,18 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"path"
 	"strings"
+	"time"
 
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	clientset "github.com/ca-gip/kotary/pkg/generated/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
@@ -14,13 +22,21 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Name of the ConfigMap holding the configuration when there is no QuotaPolicy
+const ConfigMapName = "kotary-config"
+
 const (
-	configMapName              = "kotary-config"
 	defaultMaxAllocationMemory = 1
 	defaultMaxAllocationCPU    = 1
 	defaultOverCommitMemory    = 1
 	defaultOverCommitCPU       = 1
+	defaultMaxAllocation       = 1
+	defaultOverCommit          = 1
 	nsSecretPath               = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+	defaultPendingRequeueInterval = 5 * time.Minute
+
+	defaultApproverGroup = "kotary-approvers"
 )
 
 var claimSpecByDefault = &v1.ResourceList{
@@ -28,6 +44,13 @@ var claimSpecByDefault = &v1.ResourceList{
 	v1.ResourceMemory: resource.MustParse("6Gi"),
 }
 
+// Namespaces receiving a default claim when no selector has been set
+var namespaceSelectorByDefault = &metav1.LabelSelector{
+	MatchLabels: map[string]string{
+		"quota": "managed",
+	},
+}
+
 // Hold the configurations specification
 type Config struct {
 
@@ -43,24 +66,326 @@ type Config struct {
 	// Represented as a percentage (could be under 100 to under provision)
 	RatioOverCommitMemory float64 `yaml:"ratioOverCommitMemory"`
 	RatioOverCommitCPU    float64 `yaml:"ratioOverCommitCPU"`
+
+	// Keep accepted claims instead of deleting them (GitOps mode)
+	// The managed-quota is reconciled against the last accepted claim of the namespace
+	KeepAcceptedClaims bool `yaml:"keepAcceptedClaims"`
+
+	// Policy applied to each resource that can be claimed
+	// CPU and Memory fallback on the ratios above when they are not set
+	ResourcePolicies map[v1.ResourceName]ResourcePolicy `yaml:"resourcePolicies"`
+
+	// Evaluate the rejected and pending claims again when a reload makes the policy looser
+	RequeueOnLooserPolicy bool `yaml:"requeueOnLooserPolicy"`
+
+	// Select the Namespaces that receive the default ResourceQuotaClaim
+	// Namespaces labeled quota=managed are selected when it is not set
+	NamespaceSelector *metav1.LabelSelector `yaml:"namespaceSelector"`
+
+	// Name patterns of the Namespaces that never receive the default ResourceQuotaClaim, even when selected
+	// kube-* -> All the namespaces starting with kube-
+	ExcludedNamespaces []string `yaml:"excludedNamespaces"`
+
+	// Name patterns of the Namespaces without ResourceQuota whose pod requests are reserved on the cluster capacity
+	// The pods of every Namespace without ResourceQuota are counted when it is not set
+	SystemNamespaces []string `yaml:"systemNamespaces"`
+
+	// Interval at which the pending claims are evaluated again, on top of the pod events of their namespace
+	// 0 -> The pending claims are only evaluated again on pod events
+	PendingRequeueInterval time.Duration `yaml:"pendingRequeueInterval"`
+
+	// Time after which a claim that is still pending is rejected
+	// 0 -> The claims stay pending until the usage of their namespace allows them
+	PendingTimeout time.Duration `yaml:"pendingTimeout"`
+
+	// Hold the claims that do not fit in the cluster capacity in a queue instead of rejecting them
+	// They are accepted first in first out once quotas are lowered, namespaces deleted or nodes added
+	WaitForCapacity bool `yaml:"waitForCapacity"`
+
+	// Window during which the claims fitting the capacity are collected, they are then admitted in priority order
+	// 0 -> The claims are admitted one by one in the order they are processed
+	AdmissionBatchWindow time.Duration `yaml:"admissionBatchWindow"`
+
+	// Lower the managed quotas of the namespaces of lower priority down to their usage when a claim does not fit
+	// Ignored in GitOps mode, the accepted claims would restore them
+	ReclaimUnusedQuota bool `yaml:"reclaimUnusedQuota"`
+
+	// Priority of the claims competing for the capacity, set by the tiers
+	// A priority annotation on the claim or a priority label on its namespace takes precedence
+	Priority int32 `yaml:"priority"`
+
+	// Claims growing a resource of the managed quota by more than this ratio await an approval
+	// 0.5 -> Claims asking for more than 150% of the current quota, 0 -> No approval on growth ratio
+	ApprovalGrowthRatio float64 `yaml:"approvalGrowthRatio"`
+
+	// Claims growing the CPU of the managed quota by more than this quantity await an approval
+	// 0 -> No approval on CPU growth
+	ApprovalCPUThreshold resource.Quantity `yaml:"approvalCPUThreshold"`
+
+	// Every claim growing the managed quota awaits an approval, usually set by the tier of production namespaces
+	RequireApproval bool `yaml:"requireApproval"`
+
+	// Group whose members can approve or deny any claim, on top of the users allowed to update resourcequotaclaims/approval
+	ApproverGroup string `yaml:"approverGroup"`
+
+	// Sources of the capacity the claims are evaluated against, the ready worker nodes when it is not set
+	CapacityProviders []cagipv1.CapacityProviderSpec `yaml:"capacityProviders"`
+
+	// How the capacities of the providers are combined
+	// max -> The largest capacity of each resource (default), sum -> The capacities are added
+	CapacityCombination string `yaml:"capacityCombination"`
+
+	// Nodes providing capacity beyond the ready worker nodes, all of them when it is not set
+	NodeEligibility cagipv1.NodeEligibilitySpec `yaml:"nodeEligibility"`
+
+	// Pools of nodes the Namespaces they select are evaluated against, instead of the whole cluster
+	NodePools []NodePool `yaml:"nodePools"`
+
+	// Window over which the largest capacity of the nodes seen is used, drained nodes do not lower it meanwhile
+	// 0 -> The current capacity of the nodes is used
+	CapacitySmoothingWindow time.Duration `yaml:"capacitySmoothingWindow"`
+
+	// Annotation of the cordoned nodes that keep providing capacity during a maintenance
+	// "" -> Every cordoned node is left out
+	MaintenanceAnnotation string `yaml:"maintenanceAnnotation"`
+
+	// Settings overriding the ones above for the Namespaces selected by each tier
+	Tiers []Tier `yaml:"tiers"`
+}
+
+// Hold the settings of a class of Namespaces
+type Tier struct {
+	Name string
+
+	// Select the Namespaces of the tier
+	NamespaceSelector *metav1.LabelSelector
+
+	// Settings applied to the Namespaces of the tier, resolved against the global settings
+	Settings Config
+}
+
+// Hold the ratios applied to a single resource
+type ResourcePolicy struct {
+	// Maximum amount that can be claimed compared to the cluster capacity of the resource
+	RatioMaxAllocation float64 `json:"ratioMaxAllocation"`
+
+	// Over provisioning applied on the nodes allocatable of the resource
+	RatioOverCommit float64 `json:"ratioOverCommit"`
+
+	// Resource like GPUs that can never be over-committed, the over-commit ratio is capped to 1
+	NoOverCommit bool `json:"noOverCommit"`
+}
+
+// Over-commit ratio to apply on the resource capacity
+func (p ResourcePolicy) OverCommitRatio() float64 {
+	if p.NoOverCommit && p.RatioOverCommit > 1 {
+		return 1
+	}
+	return p.RatioOverCommit
+}
+
+// Return the policy of a resource
+// CPU and Memory use the global ratios unless a dedicated policy is set
+func (c Config) ResourcePolicy(name v1.ResourceName) ResourcePolicy {
+	if policy, found := c.ResourcePolicies[name]; found {
+		return policy
+	}
+	switch name {
+	case v1.ResourceCPU:
+		return ResourcePolicy{RatioMaxAllocation: c.RatioMaxAllocationCPU, RatioOverCommit: c.RatioOverCommitCPU}
+	case v1.ResourceMemory:
+		return ResourcePolicy{RatioMaxAllocation: c.RatioMaxAllocationMemory, RatioOverCommit: c.RatioOverCommitMemory}
+	}
+	return defaultResourcePolicy(name)
+}
+
+// Check if the config allows more than a previous one for at least one resource
+// A higher allocation or over-commit ratio can turn a rejected claim into an accepted one
+// Tiers are compared with the tier of the same name, or with the global settings when it does not exist
+func (c Config) LooserThan(previous Config) bool {
+	if c.looserRatiosThan(previous) {
+		return true
+	}
+	for _, tier := range c.Tiers {
+		if tier.Settings.looserRatiosThan(previous.tierSettings(tier.Name)) {
+			return true
+		}
+	}
+	for _, previousTier := range previous.Tiers {
+		if c.tierSettings(previousTier.Name).looserRatiosThan(previousTier.Settings) {
+			return true
+		}
+	}
+	for i := range c.NodePools {
+		if c.NodePools[i].Apply(c).looserRatiosThan(previous.nodePoolSettings(c.NodePools[i].Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the global settings or a tier require an approval for some claims
+func (c Config) RequiresApproval() bool {
+	if c.RequireApproval || c.ApprovalGrowthRatio > 0 || !c.ApprovalCPUThreshold.IsZero() {
+		return true
+	}
+	for _, tier := range c.Tiers {
+		if tier.Settings.RequiresApproval() {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the settings of a node pool, or the global settings when it does not exist
+func (c Config) nodePoolSettings(name string) Config {
+	for i := range c.NodePools {
+		if c.NodePools[i].Name == name {
+			return c.NodePools[i].Apply(c)
+		}
+	}
+	return c
+}
+
+// Return the settings of a tier, or the global settings when it does not exist
+func (c Config) tierSettings(name string) Config {
+	for _, tier := range c.Tiers {
+		if tier.Name == name {
+			return tier.Settings
+		}
+	}
+	return c
+}
+
+// Compare the ratios of the resources, without the tiers
+func (c Config) looserRatiosThan(previous Config) bool {
+	names := []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
+	for name := range c.ResourcePolicies {
+		names = append(names, name)
+	}
+	for name := range previous.ResourcePolicies {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		policy, previousPolicy := c.ResourcePolicy(name), previous.ResourcePolicy(name)
+		if policy.RatioMaxAllocation > previousPolicy.RatioMaxAllocation || policy.OverCommitRatio() > previousPolicy.OverCommitRatio() {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the settings applied to a namespace and the name of its tier
+// The first tier selecting the namespace applies, the global settings and an empty name are returned when there is none
+// The ratios of the node pool of the namespace override the ones of its tier
+func (c Config) ForNamespace(namespace *v1.Namespace) (Config, string) {
+	if namespace == nil {
+		return c, ""
+	}
+	settings, tierName := c, ""
+	for _, tier := range c.Tiers {
+		selector, err := metav1.LabelSelectorAsSelector(tier.NamespaceSelector)
+		if err != nil {
+			klog.Errorf("Invalid namespaceSelector of tier %s : %s", tier.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(namespace.Labels)) {
+			settings, tierName = tier.Settings, tier.Name
+			break
+		}
+	}
+	if pool := c.NodePoolOf(namespace); pool != nil {
+		settings = pool.Apply(settings)
+	}
+	return settings, tierName
+}
+
+// Check if a namespace should receive the default claim
+// The exclusion patterns take precedence over the selector
+func (c Config) ManagesNamespace(namespace *v1.Namespace) bool {
+	for _, pattern := range c.ExcludedNamespaces {
+		if excluded, _ := path.Match(pattern, namespace.Name); excluded {
+			return false
+		}
+	}
+
+	labelSelector := c.NamespaceSelector
+	if labelSelector == nil {
+		labelSelector = namespaceSelectorByDefault
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		klog.Errorf("Invalid namespaceSelector : %s", err)
+		return false
+	}
+
+	return selector.Matches(labels.Set(namespace.Labels))
+}
+
+// Check if the pod requests of a namespace without ResourceQuota are reserved on the cluster capacity
+func (c Config) ReservesUnquotedNamespace(namespace string) bool {
+	if len(c.SystemNamespaces) == 0 {
+		return true
+	}
+	for _, pattern := range c.SystemNamespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Check the selector and the exclusion patterns of the namespaces
+func validateNamespaceSelection(selector *metav1.LabelSelector, patterns []string) (errs []string) {
+	if selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			errs = append(errs, fmt.Sprintf("namespaceSelector: %s", err))
+		}
+	}
+	return append(errs, validateNamespacePatterns("excludedNamespaces", patterns)...)
+}
+
+// Check the name patterns of a namespaces field
+func validateNamespacePatterns(field string, patterns []string) (errs []string) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s %s", field, err, pattern))
+		}
+	}
+	return errs
+}
+
+// Policy of a resource that has not been configured
+func defaultResourcePolicy(name v1.ResourceName) ResourcePolicy {
+	return ResourcePolicy{
+		RatioMaxAllocation: defaultMaxAllocation,
+		RatioOverCommit:    defaultOverCommit,
+		NoOverCommit:       isDeviceResource(name),
+	}
 }
 
-// Hold the config and a clienset to retrieve it
+// Hold the config and the clientsets to retrieve it
 type ConfigurationManager struct {
-	clientset kubernetes.Interface
-	Conf      Config
+	clientset       kubernetes.Interface
+	policyClientset clientset.Interface
+	Conf            Config
+	// Namespace where the ConfigMap is read, empty when it could not be found
+	Namespace string
 }
 
 // Create a new instance
-func NewSettingManger(clientset kubernetes.Interface) *ConfigurationManager {
+func NewSettingManger(clientset kubernetes.Interface, policyClientset clientset.Interface) *ConfigurationManager {
 	return &ConfigurationManager{
-		clientset: clientset,
+		clientset:       clientset,
+		policyClientset: policyClientset,
 	}
 
 }
 
 // Fallback when nothing has been set
-func (c *ConfigurationManager) generateDefaultSettings() *Config {
+func generateDefaultSettings() *Config {
 	klog.V(4).Info("Generating default setting ...")
 	klog.V(6).Info("Default setting will not select any Namespaces to provision default ResourceQuotaClaim")
 	klog.V(6).Info("Default will not apply over commitment to Nodes available resources")
@@ -71,34 +396,41 @@ func (c *ConfigurationManager) generateDefaultSettings() *Config {
 		RatioMaxAllocationCPU:    defaultMaxAllocationCPU,
 		RatioOverCommitMemory:    defaultOverCommitMemory,
 		RatioOverCommitCPU:       defaultOverCommitCPU,
+		PendingRequeueInterval:   defaultPendingRequeueInterval,
+		ApproverGroup:            defaultApproverGroup,
 	}
 
-	setKotaryMetrics(defaultConfig)
-
 	return defaultConfig
 
 }
 
-// Load configmap based on where the controller is running
+// Load the QuotaPolicy and the configmap based on where the controller is running
 func (c *ConfigurationManager) Load() {
 
-	namespace, err := findExecutionNamespace()
-
+	policy, err := c.loadQuotaPolicy()
 	if err != nil {
-		klog.Infof("Could not load namespace via %s ", nsSecretPath)
-		c.Conf = *c.generateDefaultSettings()	
-		return
+		if !errors.IsNotFound(err) {
+			klog.Errorf("Could not load QuotaPolicy %s : %s", cagipv1.QuotaPolicyName, err)
+		}
+		policy = nil
 	}
 
-	configMap, err := c.loadConfigMap(namespace)
+	var configMap *v1.ConfigMap
+	namespace, err := findExecutionNamespace()
 
 	if err != nil {
-		klog.Infof("Could not find %s configMap in ns %s", configMapName, namespace)
-		c.Conf = *c.generateDefaultSettings()
-		return
+		klog.Infof("Could not load namespace via %s ", nsSecretPath)
+	} else {
+		c.Namespace = namespace
+		configMap, err = c.loadConfigMap(namespace)
+		if err != nil {
+			klog.Infof("Could not find %s configMap in ns %s", ConfigMapName, namespace)
+			configMap = nil
+		}
 	}
 
-	config, _ := parseConfigMap(configMap)
+	config := ResolveConfig(policy, configMap)
+	SetKotaryMetrics(config)
 
 	c.Conf = *config
 
@@ -106,6 +438,30 @@ func (c *ConfigurationManager) Load() {
 
 }
 
+// Select the config to apply
+// A valid QuotaPolicy takes precedence over the ConfigMap, the default settings are used when there is none
+// An invalid QuotaPolicy is ignored, its errors are reported in its status by the controller
+func ResolveConfig(policy *cagipv1.QuotaPolicy, configMap *v1.ConfigMap) *Config {
+	if policy != nil {
+		config, errs := ParseQuotaPolicy(policy.Spec)
+		if len(errs) == 0 {
+			klog.Infof("Loaded QuotaPolicy %s : %+v\n", policy.Name, config)
+			return config
+		}
+		klog.Errorf("Ignoring invalid QuotaPolicy %s : %s", policy.Name, strings.Join(errs, ", "))
+	}
+
+	if configMap != nil {
+		config, err := parseConfigMap(configMap)
+		if err != nil {
+			klog.Errorf("Invalid %s configMap, the default value is used instead : %s", ConfigMapName, err)
+		}
+		return config
+	}
+
+	return generateDefaultSettings()
+}
+
 // Find the namespace where the controller is being executed
 func findExecutionNamespace() (namespace string, err error) {
 
@@ -122,7 +478,7 @@ func findExecutionNamespace() (namespace string, err error) {
 
 // Load the configmap
 func (c ConfigurationManager) loadConfigMap(namespace string) (configMap *v1.ConfigMap, err error) {
-	configMap, err = c.clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), configMapName, metav1.GetOptions{})
+	configMap, err = c.clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), ConfigMapName, metav1.GetOptions{})
 
 	if err != nil {
 		return configMap, err
@@ -133,36 +489,115 @@ func (c ConfigurationManager) loadConfigMap(namespace string) (configMap *v1.Con
 }
 
 // Parse the ConfigMap to the Config struct
+// The keys that are missing or cannot be parsed use the default value, the parse errors are returned
 func parseConfigMap(configMap *v1.ConfigMap) (parsed *Config, err error) {
 
-	var ratioMaxAllocationMemory float64
-	err = yaml.Unmarshal([]byte(configMap.Data["ratioMaxAllocationMemory"]), &ratioMaxAllocationMemory)
-	if len(configMap.Data["ratioMaxAllocationMemory"]) == 0 || err != nil {
-		ratioMaxAllocationMemory = defaultMaxAllocationMemory
+	var errs []string
+
+	ratioMaxAllocationMemory := float64(defaultMaxAllocationMemory)
+	errs = append(errs, parseConfigMapKey(configMap, "ratioMaxAllocationMemory", &ratioMaxAllocationMemory)...)
+
+	ratioMaxAllocationCPU := float64(defaultMaxAllocationCPU)
+	errs = append(errs, parseConfigMapKey(configMap, "ratioMaxAllocationCPU", &ratioMaxAllocationCPU)...)
+
+	ratioOverCommitMemory := float64(defaultOverCommitMemory)
+	errs = append(errs, parseConfigMapKey(configMap, "ratioOverCommitMemory", &ratioOverCommitMemory)...)
+
+	ratioOverCommitCPU := float64(defaultOverCommitCPU)
+	errs = append(errs, parseConfigMapKey(configMap, "ratioOverCommitCPU", &ratioOverCommitCPU)...)
+
+	defaultClaimSpec := claimSpecByDefault.DeepCopy()
+	errs = append(errs, parseConfigMapKey(configMap, "defaultClaimSpec", &defaultClaimSpec)...)
+
+	keepAcceptedClaims := false
+	errs = append(errs, parseConfigMapKey(configMap, "keepAcceptedClaims", &keepAcceptedClaims)...)
+
+	requeueOnLooserPolicy := false
+	errs = append(errs, parseConfigMapKey(configMap, "requeueOnLooserPolicy", &requeueOnLooserPolicy)...)
+
+	var namespaceSelector *metav1.LabelSelector
+	errs = append(errs, parseConfigMapKey(configMap, "namespaceSelector", &namespaceSelector)...)
+
+	var excludedNamespaces []string
+	errs = append(errs, parseConfigMapKey(configMap, "excludedNamespaces", &excludedNamespaces)...)
+
+	// An invalid selection falls back on the default one, without exclusions
+	if selectionErrs := validateNamespaceSelection(namespaceSelector, excludedNamespaces); len(selectionErrs) > 0 {
+		errs = append(errs, selectionErrs...)
+		namespaceSelector, excludedNamespaces = nil, nil
 	}
 
-	var ratioMaxAllocationCPU float64
-	err = yaml.Unmarshal([]byte(configMap.Data["ratioMaxAllocationCPU"]), &ratioMaxAllocationCPU)
-	if len(configMap.Data["ratioMaxAllocationCPU"]) == 0 || err != nil {
-		ratioMaxAllocationCPU = defaultMaxAllocationCPU
+	var systemNamespaces []string
+	errs = append(errs, parseConfigMapKey(configMap, "systemNamespaces", &systemNamespaces)...)
+
+	// Invalid patterns fall back on every namespace without quota
+	if patternErrs := validateNamespacePatterns("systemNamespaces", systemNamespaces); len(patternErrs) > 0 {
+		errs = append(errs, patternErrs...)
+		systemNamespaces = nil
 	}
 
-	var ratioOverCommitMemory float64
-	err = yaml.Unmarshal([]byte(configMap.Data["ratioOverCommitMemory"]), &ratioOverCommitMemory)
-	if len(configMap.Data["ratioOverCommitMemory"]) == 0 || err != nil {
-		ratioOverCommitMemory = defaultOverCommitMemory
+	pendingRequeueInterval := metav1.Duration{Duration: defaultPendingRequeueInterval}
+	errs = append(errs, parseConfigMapKey(configMap, "pendingRequeueInterval", &pendingRequeueInterval)...)
+	errs = append(errs, validateDuration("pendingRequeueInterval", &pendingRequeueInterval.Duration, defaultPendingRequeueInterval)...)
+
+	var pendingTimeout metav1.Duration
+	errs = append(errs, parseConfigMapKey(configMap, "pendingTimeout", &pendingTimeout)...)
+	errs = append(errs, validateDuration("pendingTimeout", &pendingTimeout.Duration, 0)...)
+
+	waitForCapacity := false
+	errs = append(errs, parseConfigMapKey(configMap, "waitForCapacity", &waitForCapacity)...)
+
+	var admissionBatchWindow metav1.Duration
+	errs = append(errs, parseConfigMapKey(configMap, "admissionBatchWindow", &admissionBatchWindow)...)
+	errs = append(errs, validateDuration("admissionBatchWindow", &admissionBatchWindow.Duration, 0)...)
+
+	reclaimUnusedQuota := false
+	errs = append(errs, parseConfigMapKey(configMap, "reclaimUnusedQuota", &reclaimUnusedQuota)...)
+
+	var approvalGrowthRatio float64
+	errs = append(errs, parseConfigMapKey(configMap, "approvalGrowthRatio", &approvalGrowthRatio)...)
+	errs = append(errs, validateApprovalGrowthRatio("approvalGrowthRatio", &approvalGrowthRatio)...)
+
+	var approvalCPUThreshold resource.Quantity
+	errs = append(errs, parseConfigMapKey(configMap, "approvalCPUThreshold", &approvalCPUThreshold)...)
+	errs = append(errs, validateApprovalCPUThreshold("approvalCPUThreshold", &approvalCPUThreshold)...)
+
+	requireApproval := false
+	errs = append(errs, parseConfigMapKey(configMap, "requireApproval", &requireApproval)...)
+
+	approverGroup := defaultApproverGroup
+	errs = append(errs, parseConfigMapKey(configMap, "approverGroup", &approverGroup)...)
+
+	var capacityProviders []cagipv1.CapacityProviderSpec
+	errs = append(errs, parseConfigMapKey(configMap, "capacityProviders", &capacityProviders)...)
+
+	var capacityCombination string
+	errs = append(errs, parseConfigMapKey(configMap, "capacityCombination", &capacityCombination)...)
+
+	// Invalid providers fall back on the worker nodes
+	if capacityErrs := validateCapacityProviders(capacityProviders, capacityCombination); len(capacityErrs) > 0 {
+		errs = append(errs, capacityErrs...)
+		capacityProviders, capacityCombination = nil, ""
 	}
 
-	var ratioOverCommitCPU float64
-	err = yaml.Unmarshal([]byte(configMap.Data["ratioOverCommitCPU"]), &ratioOverCommitCPU)
-	if len(configMap.Data["ratioOverCommitCPU"]) == 0 || err != nil {
-		ratioOverCommitCPU = defaultOverCommitCPU
+	var capacitySmoothingWindow metav1.Duration
+	errs = append(errs, parseConfigMapKey(configMap, "capacitySmoothingWindow", &capacitySmoothingWindow)...)
+	errs = append(errs, validateDuration("capacitySmoothingWindow", &capacitySmoothingWindow.Duration, 0)...)
+
+	var maintenanceAnnotation string
+	errs = append(errs, parseConfigMapKey(configMap, "maintenanceAnnotation", &maintenanceAnnotation)...)
+	if annotationErrs := validateMaintenanceAnnotation(maintenanceAnnotation); len(annotationErrs) > 0 {
+		errs = append(errs, annotationErrs...)
+		maintenanceAnnotation = ""
 	}
 
-	var defaultClaimSpec v1.ResourceList
-	err = yaml.Unmarshal([]byte(configMap.Data["defaultClaimSpec"]), &defaultClaimSpec)
-	if len(configMap.Data["defaultClaimSpec"]) == 0 || err != nil {
-		defaultClaimSpec = *claimSpecByDefault
+	var nodeEligibility cagipv1.NodeEligibilitySpec
+	errs = append(errs, parseConfigMapKey(configMap, "nodeEligibility", &nodeEligibility)...)
+
+	// Invalid eligibility settings fall back on every ready worker node
+	if eligibilityErrs := validateNodeEligibility(nodeEligibility); len(eligibilityErrs) > 0 {
+		errs = append(errs, eligibilityErrs...)
+		nodeEligibility = cagipv1.NodeEligibilitySpec{}
 	}
 
 	parsed = &Config{
@@ -171,20 +606,121 @@ func parseConfigMap(configMap *v1.ConfigMap) (parsed *Config, err error) {
 		RatioMaxAllocationCPU:    ratioMaxAllocationCPU,
 		RatioOverCommitMemory:    ratioOverCommitMemory,
 		RatioOverCommitCPU:       ratioOverCommitCPU,
+		KeepAcceptedClaims:       keepAcceptedClaims,
+		RequeueOnLooserPolicy:    requeueOnLooserPolicy,
+		NamespaceSelector:        namespaceSelector,
+		ExcludedNamespaces:       excludedNamespaces,
+		SystemNamespaces:         systemNamespaces,
+		PendingRequeueInterval:   pendingRequeueInterval.Duration,
+		PendingTimeout:           pendingTimeout.Duration,
+		WaitForCapacity:          waitForCapacity,
+		AdmissionBatchWindow:     admissionBatchWindow.Duration,
+		ReclaimUnusedQuota:       reclaimUnusedQuota,
+		ApprovalGrowthRatio:      approvalGrowthRatio,
+		ApprovalCPUThreshold:     approvalCPUThreshold,
+		RequireApproval:          requireApproval,
+		ApproverGroup:            approverGroup,
+		CapacityProviders:        capacityProviders,
+		CapacityCombination:      capacityCombination,
+		NodeEligibility:          nodeEligibility,
+		CapacitySmoothingWindow:  capacitySmoothingWindow.Duration,
+		MaintenanceAnnotation:    maintenanceAnnotation,
+	}
+
+	parsed.ResourcePolicies, err = parseResourcePolicies(configMap.Data["resourcePolicies"], parsed)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("resourcePolicies: %s", err))
+		parsed.ResourcePolicies = nil
+	}
+
+	// Node pools are all dropped when one is invalid, the namespaces are evaluated against the whole cluster
+	var nodePools []cagipv1.NodePoolSpec
+	errs = append(errs, parseConfigMapKey(configMap, "nodePools", &nodePools)...)
+	parsedPools, poolErrs := ParseNodePools(nodePools)
+	if len(poolErrs) > 0 {
+		errs = append(errs, poolErrs...)
+		parsedPools = nil
+	}
+	parsed.NodePools = parsedPools
+
+	// Tiers are resolved against the global settings, they are all dropped when one is invalid
+	var tiers []cagipv1.PolicyTier
+	errs = append(errs, parseConfigMapKey(configMap, "tiers", &tiers)...)
+	parsedTiers, tierErrs := ParseTiers(tiers, *parsed)
+	if len(tierErrs) > 0 {
+		errs = append(errs, tierErrs...)
+		parsedTiers = nil
 	}
+	parsed.Tiers = parsedTiers
 
 	klog.Infof("Loaded config map : %+v\n", parsed)
-	setKotaryMetrics(parsed)
-	return
 
+	if len(errs) > 0 {
+		return parsed, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return parsed, nil
+
+}
+
+// Unmarshal a key of the ConfigMap, the value is left unchanged when the key is missing or invalid
+func parseConfigMapKey[T any](configMap *v1.ConfigMap, key string, value *T) []string {
+	data := configMap.Data[key]
+	if len(data) == 0 {
+		return nil
+	}
+	var parsed T
+	if err := yaml.Unmarshal([]byte(data), &parsed); err != nil {
+		return []string{fmt.Sprintf("%s: %s", key, err)}
+	}
+	*value = parsed
+	return nil
 }
 
-func setKotaryMetrics(kotaryConfig *Config) {
+// Parse the resource policies, the fields that are not set keep the current policy of the resource
+func parseResourcePolicies(data string, base *Config) (policies map[v1.ResourceName]ResourcePolicy, err error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var raw map[v1.ResourceName]json.RawMessage
+	if err = yaml.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+
+	policies = make(map[v1.ResourceName]ResourcePolicy, len(raw))
+	for name, rawPolicy := range raw {
+		policy := base.ResourcePolicy(name)
+		if err = json.Unmarshal(rawPolicy, &policy); err != nil {
+			return nil, err
+		}
+		policies[name] = policy
+	}
+
+	return policies, nil
+}
+
+// Update the ratio gauges with the config applied by the controller
+func SetKotaryMetrics(kotaryConfig *Config) {
 
 	RatioMaxAllocationCPUGauge.Set(float64(kotaryConfig.RatioMaxAllocationCPU))
 	RatioMaxAllocationMemoryGauge.Set(float64(kotaryConfig.RatioMaxAllocationMemory))
 	RatioOverCommitCPUGauge.Set(float64(kotaryConfig.RatioOverCommitCPU))
 	RatioOverCommitMemoryGauge.Set(float64(kotaryConfig.RatioOverCommitMemory))
 
+	// Tiers that have been removed must not be reported anymore
+	TierRatioGauge.Reset()
+	setTierRatioMetrics(TierLabel(""), kotaryConfig)
+	for _, tier := range kotaryConfig.Tiers {
+		setTierRatioMetrics(TierLabel(tier.Name), &tier.Settings)
+	}
+
 	klog.Infof("Kotary metrics updated from configuration")
 }
+
+// Update the ratio gauges of a tier
+func setTierRatioMetrics(tier string, settings *Config) {
+	TierRatioGauge.WithLabelValues(tier, "max_allocation_cpu").Set(settings.RatioMaxAllocationCPU)
+	TierRatioGauge.WithLabelValues(tier, "max_allocation_memory").Set(settings.RatioMaxAllocationMemory)
+	TierRatioGauge.WithLabelValues(tier, "over_commit_cpu").Set(settings.RatioOverCommitCPU)
+	TierRatioGauge.WithLabelValues(tier, "over_commit_memory").Set(settings.RatioOverCommitMemory)
+}
@@ -0,0 +1,462 @@
+package utils
+
+import (
+	"testing"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourcePolicy(t *testing.T) {
+	config := &Config{
+		RatioMaxAllocationCPU:    0.33,
+		RatioMaxAllocationMemory: 0.5,
+		RatioOverCommitCPU:       1.5,
+		RatioOverCommitMemory:    1.2,
+	}
+
+	testCases := map[string]struct {
+		data   string
+		name   v1.ResourceName
+		expect ResourcePolicy
+	}{
+		"cpu without policy should use the global ratios": {
+			name:   v1.ResourceCPU,
+			expect: ResourcePolicy{RatioMaxAllocation: 0.33, RatioOverCommit: 1.5},
+		},
+		"memory with a partial policy should keep the global ratios": {
+			data:   "memory:\n  ratioOverCommit: 1\n",
+			name:   v1.ResourceMemory,
+			expect: ResourcePolicy{RatioMaxAllocation: 0.5, RatioOverCommit: 1},
+		},
+		"gpu without policy should not be over-committable": {
+			name:   "nvidia.com/gpu",
+			expect: ResourcePolicy{RatioMaxAllocation: 1, RatioOverCommit: 1, NoOverCommit: true},
+		},
+		"gpu with a policy": {
+			data:   "nvidia.com/gpu:\n  ratioMaxAllocation: 0.25\n",
+			name:   "nvidia.com/gpu",
+			expect: ResourcePolicy{RatioMaxAllocation: 0.25, RatioOverCommit: 1, NoOverCommit: true},
+		},
+		"ephemeral-storage with a policy": {
+			data:   "ephemeral-storage:\n  ratioMaxAllocation: 0.1\n  ratioOverCommit: 2\n",
+			name:   v1.ResourceEphemeralStorage,
+			expect: ResourcePolicy{RatioMaxAllocation: 0.1, RatioOverCommit: 2},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			policies, err := parseResourcePolicies(testCase.data, config)
+			assert.NilError(t, err)
+
+			parsed := *config
+			parsed.ResourcePolicies = policies
+
+			assert.Equal(t, parsed.ResourcePolicy(testCase.name), testCase.expect)
+		})
+	}
+
+	t.Run("gpu over-commit should be capped", func(t *testing.T) {
+		policy := ResourcePolicy{RatioMaxAllocation: 1, RatioOverCommit: 2, NoOverCommit: true}
+		assert.Equal(t, policy.OverCommitRatio(), float64(1))
+	})
+}
+
+func TestLooserThan(t *testing.T) {
+	previous := Config{
+		RatioMaxAllocationCPU:    0.33,
+		RatioMaxAllocationMemory: 0.5,
+		RatioOverCommitCPU:       1.5,
+		RatioOverCommitMemory:    1.2,
+		ResourcePolicies: map[v1.ResourceName]ResourcePolicy{
+			"nvidia.com/gpu": {RatioMaxAllocation: 0.25, RatioOverCommit: 1, NoOverCommit: true},
+		},
+	}
+
+	testCases := map[string]struct {
+		update func(config *Config)
+		expect bool
+	}{
+		"same config should not be looser": {
+			update: func(config *Config) {},
+			expect: false,
+		},
+		"higher cpu over-commit should be looser": {
+			update: func(config *Config) { config.RatioOverCommitCPU = 2 },
+			expect: true,
+		},
+		"lower memory allocation should not be looser": {
+			update: func(config *Config) { config.RatioMaxAllocationMemory = 0.4 },
+			expect: false,
+		},
+		"higher gpu over-commit should not be looser when it is capped": {
+			update: func(config *Config) {
+				config.ResourcePolicies = map[v1.ResourceName]ResourcePolicy{
+					"nvidia.com/gpu": {RatioMaxAllocation: 0.25, RatioOverCommit: 2, NoOverCommit: true},
+				}
+			},
+			expect: false,
+		},
+		"removed gpu policy should be looser": {
+			update: func(config *Config) { config.ResourcePolicies = nil },
+			expect: true,
+		},
+		"tier with a higher cpu allocation should be looser": {
+			update: func(config *Config) {
+				settings := previous
+				settings.RatioMaxAllocationCPU = 0.66
+				config.Tiers = []Tier{{Name: "large", Settings: settings}}
+			},
+			expect: true,
+		},
+		"tier with lower ratios should not be looser": {
+			update: func(config *Config) {
+				settings := previous
+				settings.RatioOverCommitMemory = 1
+				config.Tiers = []Tier{{Name: "restricted", Settings: settings}}
+			},
+			expect: false,
+		},
+		"node pool with a higher memory over-commit should be looser": {
+			update: func(config *Config) {
+				ratio := 1.5
+				config.NodePools = []NodePool{{Name: "highmem", RatioOverCommitMemory: &ratio}}
+			},
+			expect: true,
+		},
+		"node pool with a lower cpu allocation should not be looser": {
+			update: func(config *Config) {
+				ratio := 0.2
+				config.NodePools = []NodePool{{Name: "highmem", RatioMaxAllocationCPU: &ratio}}
+			},
+			expect: false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			config := previous
+			testCase.update(&config)
+			assert.Equal(t, config.LooserThan(previous), testCase.expect)
+		})
+	}
+}
+
+func TestRequiresApproval(t *testing.T) {
+	testCases := map[string]struct {
+		config Config
+		expect bool
+	}{
+		"config without approval policy should not require any": {
+			config: Config{RatioMaxAllocationCPU: 0.5},
+			expect: false,
+		},
+		"growth ratio should require an approval": {
+			config: Config{ApprovalGrowthRatio: 0.5},
+			expect: true,
+		},
+		"cpu threshold should require an approval": {
+			config: Config{ApprovalCPUThreshold: resource.MustParse("2")},
+			expect: true,
+		},
+		"tier requiring an approval should require one": {
+			config: Config{Tiers: []Tier{{Name: "production", Settings: Config{RequireApproval: true}}}},
+			expect: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testCase.config.RequiresApproval(), testCase.expect)
+		})
+	}
+}
+
+func TestManagesNamespace(t *testing.T) {
+	namespace := func(name string, labels map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	tenantSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"type": "customer"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "environment", Operator: metav1.LabelSelectorOpIn, Values: []string{"development", "production"}},
+		},
+	}
+
+	testCases := map[string]struct {
+		config    Config
+		namespace *v1.Namespace
+		expect    bool
+	}{
+		"quota label should be selected by default": {
+			namespace: namespace("team-1", map[string]string{"quota": "managed"}),
+			expect:    true,
+		},
+		"namespace without labels should not be selected by default": {
+			namespace: namespace("team-1", nil),
+			expect:    false,
+		},
+		"namespace matching the expressions should be selected": {
+			config:    Config{NamespaceSelector: tenantSelector},
+			namespace: namespace("team-1-development", map[string]string{"type": "customer", "environment": "development"}),
+			expect:    true,
+		},
+		"namespace not matching the expressions should not be selected": {
+			config:    Config{NamespaceSelector: tenantSelector},
+			namespace: namespace("team-1-sandbox", map[string]string{"type": "customer", "environment": "sandbox"}),
+			expect:    false,
+		},
+		"empty selector should select every namespace": {
+			config:    Config{NamespaceSelector: &metav1.LabelSelector{}},
+			namespace: namespace("team-1", nil),
+			expect:    true,
+		},
+		"excluded namespace should not be selected": {
+			config:    Config{NamespaceSelector: &metav1.LabelSelector{}, ExcludedNamespaces: []string{"kube-*", "monitoring"}},
+			namespace: namespace("kube-system", nil),
+			expect:    false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testCase.config.ManagesNamespace(testCase.namespace), testCase.expect)
+		})
+	}
+
+	t.Run("invalid selection should be reported", func(t *testing.T) {
+		errs := validateNamespaceSelection(&metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "type", Operator: "Like"}},
+		}, []string{"kube-[", "monitoring"})
+		assert.Equal(t, len(errs), 2)
+	})
+}
+
+func TestForNamespace(t *testing.T) {
+	ratio := func(value float64) *float64 { return &value }
+	namespace := func(labels map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-1", Labels: labels}}
+	}
+	config := Config{
+		RatioMaxAllocationCPU: 0.33,
+		Tiers: []Tier{
+			{
+				Name:              "production",
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+				Settings:          Config{RatioMaxAllocationCPU: 0.5},
+			},
+			{
+				Name:              "customer",
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"type": "customer"}},
+				Settings:          Config{RatioMaxAllocationCPU: 0.1},
+			},
+		},
+		NodePools: []NodePool{
+			{
+				Name:                  "highmem",
+				NodeSelector:          &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}},
+				NamespaceSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}},
+				RatioMaxAllocationCPU: ratio(0.8),
+			},
+			{
+				Name:              "arm64",
+				NodeSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/arch": "arm64"}},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "arm64"}},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		namespace *v1.Namespace
+		tier      string
+		ratio     float64
+	}{
+		"namespace without tier should use the global settings": {
+			namespace: namespace(map[string]string{"environment": "development"}),
+			tier:      "",
+			ratio:     0.33,
+		},
+		"namespace should use the settings of its tier": {
+			namespace: namespace(map[string]string{"type": "customer"}),
+			tier:      "customer",
+			ratio:     0.1,
+		},
+		"first tier selecting the namespace should apply": {
+			namespace: namespace(map[string]string{"type": "customer", "environment": "production"}),
+			tier:      "production",
+			ratio:     0.5,
+		},
+		"unknown namespace should use the global settings": {
+			tier:  "",
+			ratio: 0.33,
+		},
+		"ratios of the node pool should override the ones of the tier": {
+			namespace: namespace(map[string]string{"type": "customer", "pool": "highmem"}),
+			tier:      "customer",
+			ratio:     0.8,
+		},
+		"node pool without ratios should keep the ones of the tier": {
+			namespace: namespace(map[string]string{"type": "customer", "pool": "arm64"}),
+			tier:      "customer",
+			ratio:     0.1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			settings, tier := config.ForNamespace(testCase.namespace)
+			assert.Equal(t, tier, testCase.tier)
+			assert.Equal(t, settings.RatioMaxAllocationCPU, testCase.ratio)
+		})
+	}
+}
+
+func TestReservesUnquotedNamespace(t *testing.T) {
+	testCases := map[string]struct {
+		config    Config
+		namespace string
+		expect    bool
+	}{
+		"every namespace should be reserved by default": {
+			namespace: "monitoring",
+			expect:    true,
+		},
+		"system namespace should be reserved": {
+			config:    Config{SystemNamespaces: []string{"kube-*", "monitoring"}},
+			namespace: "kube-system",
+			expect:    true,
+		},
+		"other namespace should not be reserved": {
+			config:    Config{SystemNamespaces: []string{"kube-*", "monitoring"}},
+			namespace: "team-1",
+			expect:    false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testCase.config.ReservesUnquotedNamespace(testCase.namespace), testCase.expect)
+		})
+	}
+}
+
+func TestNodeExclusion(t *testing.T) {
+	ready := v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}}
+	gpuTaint := v1.Taint{Key: "nvidia.com/gpu", Value: "true", Effect: v1.TaintEffectNoSchedule}
+	dedicatedTaint := v1.Taint{Key: "dedicated", Value: "ingress", Effect: v1.TaintEffectNoExecute}
+
+	testCases := map[string]struct {
+		eligibility cagipv1.NodeEligibilitySpec
+		node        v1.Node
+		expect      string
+	}{
+		"ready worker node should be eligible": {
+			node:   v1.Node{Status: ready},
+			expect: "",
+		},
+		"unschedulable node should be excluded": {
+			node:   v1.Node{Spec: v1.NodeSpec{Unschedulable: true}, Status: ready},
+			expect: NodeUnschedulable,
+		},
+		"cordoned node in maintenance should be eligible": {
+			node: v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"cagip.github.com/maintenance": ""}},
+				Spec:       v1.NodeSpec{Unschedulable: true},
+				Status:     ready,
+			},
+			expect: "",
+		},
+		"cordoned node in maintenance should be eligible whatever its cordon taint": {
+			eligibility: cagipv1.NodeEligibilitySpec{
+				ExcludedTaints:           []v1.Taint{{Key: v1.TaintNodeUnschedulable}},
+				ExcludeUntoleratedTaints: true,
+			},
+			node: v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"cagip.github.com/maintenance": ""}},
+				Spec:       v1.NodeSpec{Unschedulable: true, Taints: []v1.Taint{{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}}},
+				Status:     ready,
+			},
+			expect: "",
+		},
+		"node in maintenance should still be excluded by its other untolerated taints": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludeUntoleratedTaints: true},
+			node: v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"cagip.github.com/maintenance": ""}},
+				Spec:       v1.NodeSpec{Unschedulable: true, Taints: []v1.Taint{{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}, dedicatedTaint}},
+				Status:     ready,
+			},
+			expect: NodeUntoleratedTaint,
+		},
+		"tainted unschedulable node without maintenance should be excluded": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludeUntoleratedTaints: true},
+			node:        v1.Node{Spec: v1.NodeSpec{Unschedulable: true, Taints: []v1.Taint{{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}}}, Status: ready},
+			expect:      NodeUnschedulable,
+		},
+		"not ready node in maintenance should be excluded": {
+			node: v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"cagip.github.com/maintenance": ""}},
+				Spec:       v1.NodeSpec{Unschedulable: true},
+				Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}}},
+			},
+			expect: NodeNotReady,
+		},
+		"not ready node should be excluded": {
+			node:   v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}}},
+			expect: NodeNotReady,
+		},
+		"control plane node should be excluded": {
+			node:   v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"node-role.kubernetes.io/control-plane": ""}}, Status: ready},
+			expect: NodeControlPlane,
+		},
+		"tainted node should be eligible by default": {
+			node:   v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{gpuTaint}}, Status: ready},
+			expect: "",
+		},
+		"node not matching the selector should be excluded": {
+			eligibility: cagipv1.NodeEligibilitySpec{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "shared"}}},
+			node:        v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "batch"}}, Status: ready},
+			expect:      NodeNotSelected,
+		},
+		"node matching the selector should be eligible": {
+			eligibility: cagipv1.NodeEligibilitySpec{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "shared"}}},
+			node:        v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "shared"}}, Status: ready},
+			expect:      "",
+		},
+		"node with an excluded role should be excluded": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludedRoleLabels: []string{"node-role.kubernetes.io/infra"}},
+			node:        v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"node-role.kubernetes.io/infra": ""}}, Status: ready},
+			expect:      NodeExcludedRole,
+		},
+		"node with an excluded taint key should be excluded": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludedTaints: []v1.Taint{{Key: "nvidia.com/gpu"}}},
+			node:        v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{gpuTaint}}, Status: ready},
+			expect:      NodeExcludedTaint,
+		},
+		"node with another value of an excluded taint should be eligible": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludedTaints: []v1.Taint{{Key: "dedicated", Value: "monitoring"}}},
+			node:        v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{dedicatedTaint}}, Status: ready},
+			expect:      "",
+		},
+		"node with an untolerated taint should be excluded": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludeUntoleratedTaints: true, Tolerations: []v1.Toleration{{Key: "nvidia.com/gpu", Operator: v1.TolerationOpExists}}},
+			node:        v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{gpuTaint, dedicatedTaint}}, Status: ready},
+			expect:      NodeUntoleratedTaint,
+		},
+		"node with tolerated taints should be eligible": {
+			eligibility: cagipv1.NodeEligibilitySpec{ExcludeUntoleratedTaints: true, Tolerations: []v1.Toleration{{Key: "nvidia.com/gpu", Operator: v1.TolerationOpExists}}},
+			node:        v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{gpuTaint, {Key: "spot", Effect: v1.TaintEffectPreferNoSchedule}}}, Status: ready},
+			expect:      "",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			config := Config{NodeEligibility: testCase.eligibility, MaintenanceAnnotation: "cagip.github.com/maintenance"}
+			assert.Equal(t, config.NodeExclusion(&testCase.node), testCase.expect)
+		})
+	}
+}
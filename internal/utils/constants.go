@@ -4,16 +4,89 @@ const (
 	ControllerName = "kotary-controller"
 
 	SharedInformerNotSync = "%s shared informer not synced"
+	MessageNoLeader       = "replica %s has not observed a leader yet"
 
 	MessageRejectedMemory = "Not enough Memory claiming %s but %s currently available"
 	MessageRejectedCPU    = "Not enough CPU claiming %s but %s currently available"
 
+	MessageRejectedResource = "Not enough %s claiming %s but %s currently available"
+
 	MessageMemoryAllocationLimit = "Exceeded Memory allocation limit claiming %s but limited to %s"
 	MessageCpuAllocationLimit    = "Exceeded CPU allocation limit claiming %s but limited to %s"
 
+	MessageResourceAllocationLimit = "Exceeded %s allocation limit claiming %s but limited to %s"
+
 	MessagePendingMemoryDownscale = "Awaiting lower Memory consumption claiming %s but current total of request is %s"
 	MessagePendingCpuDownscale    = "Awaiting lower CPU consumption claiming %s but current total of CPU request is %s"
 
+	MessagePendingResourceDownscale = "Awaiting lower %s consumption claiming %s but current total of request is %s"
+
+	MessagePendingTimeout = "Still pending after %s: %s"
+
+	MessageWaitingForCapacity = "Waiting for capacity at position %d of the queue: %s"
+	MessageWaitingBehind      = "Waiting for capacity at position %d of the queue behind claim %s"
+
+	MessageAwaitingAdmission = "Collected for admission, the claims received within %s are admitted by priority"
+
+	MessageSuperseded = "Superseded by claim %s"
+
+	MessageInvalidExpiry  = "Invalid %s annotation %s"
+	MessageAlreadyExpired = "Claim already expired at %s"
+	MessageExpired        = "Expired at %s, previous quota restored"
+
+	MessageInvalidPriority = "Invalid %s annotation %s, an integer is expected"
+	MessageQuotaReclaimed  = "Reclaimed unused %s for claim %s"
+
+	MessageApprovalGrowth          = "Awaiting approval, %s grows from %s to %s, more than %v%% without approval"
+	MessageApprovalCPU             = "Awaiting approval, CPU grows by %s, more than the %s allowed without approval"
+	MessageApprovalRequired        = "Awaiting approval, the claims of tier %s require an approval"
+	MessageApprovalDenied          = "Denied by %s"
+	MessageApproved                = "Approved by %s"
+	MessageInvalidApproval         = "Invalid %s annotation %s, approved or denied is expected along with the %s annotation"
+	MessageUnsignedApproval        = "Decision %s of %s was not recorded by the claim webhook, an approver must set it again"
+	MessageNotApprover             = "Only the approvers can approve or deny a claim, %s is not in the %s group"
+	MessageApproverMismatch        = "The %s annotation must be the user approving or denying the claim, %s is not %s"
+	MessageApprovedSpecEdit        = "The approval must be removed before editing the spec of the claim"
+	MessageApprovalOnCreate        = "A claim can only be approved or denied once created"
+	MessageApprovalWebhookDisabled = "The approval policy requires the claim webhook to record the decisions but it is disabled, set --webhook-port"
+	MessageAwaitingDisabledWebhook = "%s, no approver can decide while the claim webhook is disabled"
+
+	MessageQuotaDriftRestored  = "Restored the accepted spec %s, it was edited to %s"
+	MessageQuotaDriftRecreated = "Recreated with the accepted spec %s, it was deleted"
+	MessageQuotaProtected      = "ResourceQuota %s is managed by %s, it can only be changed by a ResourceQuotaClaim"
+
+	MessageInvalidClaim    = "Invalid claim: %s"
+	MessageUnknownResource = "Unknown resource %s, it can not be limited by a ResourceQuota"
+	MessageNegativeClaim   = "Invalid %s claiming %s, a quantity must not be negative"
+
+	MessageInvalidTimeZone         = "Invalid time zone %s"
+	MessageInvalidSchedule         = "Invalid cron expression of schedule %s: %s"
+	MessageClaimEmitted            = "Emitted claim %s from schedule %s"
+	MessageScheduledRunNotRecorded = "Run of claim %s in namespace %s not recorded yet by schedule %s"
+
+	MessagePolicyInvalidRatio     = "%s must be greater than 0 but is %v"
+	MessagePolicyNegativeRatio    = "%s must not be negative but is %v"
+	MessagePolicyNegativeQuantity = "%s must not be negative but is %s"
+	MessagePolicyNegativeDuration = "%s must not be negative but is %s"
+	MessagePolicyIgnored          = "Only the QuotaPolicy named %s is read by the controller"
+	MessagePolicyValid            = "Policy is valid"
+	MessageTierMissingField       = "%s.%s must be set"
+	MessageTierDuplicateName      = "%s.name %s is already used by another tier"
+	MessageNodePoolDuplicateName  = "%s.name %s is already used by another node pool"
+
+	MessageUnknownCapacityProvider    = "%s.type %s must be one of nodes, static, clusterAutoscaler or karpenter"
+	MessageInvalidCapacityCombination = "capacityCombination %s must be max or sum"
+	MessageInvalidStatusConfigMap     = "%s.statusConfigMap %s must be namespace/name"
+	MessageInvalidTolerationOperator  = "%s.operator %s must be Exists or Equal"
+
+	MessageReservedOverAllocatable   = "%s %s reserved over the %s allocatable on %s"
+	MessageReservedWithinAllocatable = "The reserved capacity fits the allocatable of the nodes"
+
+	MessageProviderUnavailable       = "Capacity of provider %s unavailable (%s), %s"
+	MessageProviderFallbackLastKnown = "its capacity known at %s is used"
+	MessageProviderFallbackNodes     = "the capacity of the nodes is used"
+	MessageProvidersAvailable        = "Every capacity provider answered"
+
 	ResourceQuotaName = "managed-quota"
 
 	EmptyMsg = ""
@@ -2,34 +2,121 @@ package utils
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/cmd/kubeadm/app/constants"
 )
 
-// Predicate to filter out un-schedulable, role master labeled or not ready nodes
-func FilterWorkerNode() func(*v1.Node) bool {
-	return func(node *v1.Node) bool {
-		// Unschedulable Node
-		if node.Spec.Unschedulable {
-			return false
-		}
-		// Role Master Label
-		if _, hasMasterRoleLabel := node.Labels[constants.LabelNodeRoleControlPlane]; hasMasterRoleLabel {
-			return false
-		}
-		// No conditions available
-		if len(node.Status.Conditions) == 0 {
-			return false
-		}
-		for _, cond := range node.Status.Conditions {
-			// We consider the node only when its NodeReady condition status is ConditionTrue
-			if cond.Type == v1.NodeReady && cond.Status != v1.ConditionTrue {
-				klog.V(4).Infof("Ignoring node %v with %v condition status %v", node.Name, cond.Type, cond.Status)
-				return false
+// Reasons why a node does not provide capacity
+const (
+	NodeUnschedulable      = "unschedulable"
+	NodeControlPlane       = "control-plane"
+	NodeNotReady           = "not-ready"
+	NodeNotSelected        = "not-selected"
+	NodeExcludedRole       = "excluded-role"
+	NodeExcludedTaint      = "excluded-taint"
+	NodeUntoleratedTaint   = "untolerated-taint"
+	nodeInvalidEligibility = "invalid-eligibility"
+
+	// Label of the nodes providing capacity in the metrics
+	NodeEligible = "eligible"
+	// Label of the cordoned nodes that keep providing capacity during a maintenance
+	NodeMaintenance = "maintenance"
+)
+
+// Return why a node does not provide capacity, an empty reason when it does
+// Un-schedulable, role master labeled and not ready nodes never do, the eligibility settings exclude more nodes
+// A cordoned node carrying the maintenance annotation keeps providing capacity, its cordon taint is ignored
+func (c Config) NodeExclusion(node *v1.Node) string {
+	inMaintenance := c.InMaintenance(node)
+	// Unschedulable Node
+	if node.Spec.Unschedulable && !inMaintenance {
+		return NodeUnschedulable
+	}
+	// Role Master Label
+	if _, hasMasterRoleLabel := node.Labels[constants.LabelNodeRoleControlPlane]; hasMasterRoleLabel {
+		return NodeControlPlane
+	}
+	// No conditions available
+	if len(node.Status.Conditions) == 0 {
+		return NodeNotReady
+	}
+	for _, cond := range node.Status.Conditions {
+		// We consider the node only when its NodeReady condition status is ConditionTrue
+		if cond.Type == v1.NodeReady && cond.Status != v1.ConditionTrue {
+			klog.V(4).Infof("Ignoring node %v with %v condition status %v", node.Name, cond.Type, cond.Status)
+			return NodeNotReady
+		}
+	}
+
+	eligibility := c.NodeEligibility
+	if eligibility.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(eligibility.NodeSelector)
+		if err != nil {
+			klog.Errorf("Invalid nodeEligibility.nodeSelector : %s", err)
+			return nodeInvalidEligibility
+		}
+		if !selector.Matches(labels.Set(node.Labels)) {
+			return NodeNotSelected
+		}
+	}
+	for _, roleLabel := range eligibility.ExcludedRoleLabels {
+		if _, hasRoleLabel := node.Labels[roleLabel]; hasRoleLabel {
+			return NodeExcludedRole
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if inMaintenance && isCordonTaint(taint) {
+			continue
+		}
+		for _, excluded := range eligibility.ExcludedTaints {
+			if matchesTaint(excluded, taint) {
+				return NodeExcludedTaint
 			}
 		}
-		return true
+		if eligibility.ExcludeUntoleratedTaints && taint.Effect != v1.TaintEffectPreferNoSchedule && !toleratesTaint(eligibility.Tolerations, taint) {
+			return NodeUntoleratedTaint
+		}
+	}
+	return ""
+}
+
+// Check if a node provides capacity
+func (c Config) IsEligibleNode(node *v1.Node) bool {
+	return c.NodeExclusion(node) == ""
+}
+
+// Check if a node is cordoned for a maintenance, it is expected to provide capacity again once it is over
+func (c Config) InMaintenance(node *v1.Node) bool {
+	if c.MaintenanceAnnotation == "" || !node.Spec.Unschedulable {
+		return false
+	}
+	_, found := node.Annotations[c.MaintenanceAnnotation]
+	return found
+}
+
+// Check if a taint is the one added by the node controller to the cordoned nodes
+func isCordonTaint(taint *v1.Taint) bool {
+	return taint.Key == v1.TaintNodeUnschedulable && taint.Effect == v1.TaintEffectNoSchedule
+}
+
+// Check if a taint matches an excluded one, an empty value or effect matches any
+func matchesTaint(excluded v1.Taint, taint *v1.Taint) bool {
+	return excluded.Key == taint.Key &&
+		(excluded.Value == "" || excluded.Value == taint.Value) &&
+		(excluded.Effect == "" || excluded.Effect == taint.Effect)
+}
+
+// Check if a taint is tolerated by one of the tolerations
+func toleratesTaint(tolerations []v1.Toleration, taint *v1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(klog.Background(), taint, false) {
+			return true
+		}
 	}
+	return false
 }
 
 func FilterRunningPods(pods []*v1.Pod) []*v1.Pod {
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// Hold a pool of nodes and the Namespaces bound to it
+type NodePool struct {
+	Name string
+
+	// Select the nodes of the pool
+	NodeSelector *metav1.LabelSelector
+
+	// Select the Namespaces bound to the pool
+	NamespaceSelector *metav1.LabelSelector
+
+	// Ratios of the pool, the ratios of the Namespace apply when they are not set
+	RatioMaxAllocationMemory *float64
+	RatioMaxAllocationCPU    *float64
+	RatioOverCommitMemory    *float64
+	RatioOverCommitCPU       *float64
+}
+
+// Return the node pool a Namespace is bound to, nil when it is evaluated against the whole cluster
+// The first pool selecting the Namespace applies
+func (c Config) NodePoolOf(namespace *v1.Namespace) *NodePool {
+	if namespace == nil {
+		return nil
+	}
+	for i := range c.NodePools {
+		pool := &c.NodePools[i]
+		selector, err := metav1.LabelSelectorAsSelector(pool.NamespaceSelector)
+		if err != nil {
+			klog.Errorf("Invalid namespaceSelector of node pool %s : %s", pool.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(namespace.Labels)) {
+			return pool
+		}
+	}
+	return nil
+}
+
+// Check if a node belongs to the pool
+func (p *NodePool) SelectsNode(node *v1.Node) bool {
+	selector, err := metav1.LabelSelectorAsSelector(p.NodeSelector)
+	if err != nil {
+		klog.Errorf("Invalid nodeSelector of node pool %s : %s", p.Name, err)
+		return false
+	}
+	return selector.Matches(labels.Set(node.Labels))
+}
+
+// Override the CPU and Memory ratios of the settings of a Namespace with the ones of the pool
+func (p *NodePool) Apply(settings Config) Config {
+	override := func(ratio *float64, target *float64) {
+		if ratio != nil {
+			*target = *ratio
+		}
+	}
+	override(p.RatioMaxAllocationMemory, &settings.RatioMaxAllocationMemory)
+	override(p.RatioMaxAllocationCPU, &settings.RatioMaxAllocationCPU)
+	override(p.RatioOverCommitMemory, &settings.RatioOverCommitMemory)
+	override(p.RatioOverCommitCPU, &settings.RatioOverCommitCPU)
+
+	// A dedicated CPU or Memory policy follows the ratios of the pool
+	if len(settings.ResourcePolicies) > 0 {
+		policies := make(map[v1.ResourceName]ResourcePolicy, len(settings.ResourcePolicies))
+		for name, policy := range settings.ResourcePolicies {
+			switch name {
+			case v1.ResourceCPU:
+				override(p.RatioMaxAllocationCPU, &policy.RatioMaxAllocation)
+				override(p.RatioOverCommitCPU, &policy.RatioOverCommit)
+			case v1.ResourceMemory:
+				override(p.RatioMaxAllocationMemory, &policy.RatioMaxAllocation)
+				override(p.RatioOverCommitMemory, &policy.RatioOverCommit)
+			}
+			policies[name] = policy
+		}
+		settings.ResourcePolicies = policies
+	}
+	return settings
+}
+
+// Convert the node pools of the configuration
+// Return the errors found, the invalid pools are still returned
+func ParseNodePools(specs []cagipv1.NodePoolSpec) (pools []NodePool, errs []string) {
+	names := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		field := fmt.Sprintf("nodePools[%d]", i)
+
+		if spec.Name == "" {
+			errs = append(errs, fmt.Sprintf(MessageTierMissingField, field, "name"))
+		} else if names[spec.Name] {
+			errs = append(errs, fmt.Sprintf(MessageNodePoolDuplicateName, field, spec.Name))
+		}
+		names[spec.Name] = true
+
+		errs = append(errs, validateSelector(spec.NodeSelector, field, "nodeSelector")...)
+		errs = append(errs, validateSelector(spec.NamespaceSelector, field, "namespaceSelector")...)
+
+		// The ratios are only checked, they are applied on the settings of each Namespace
+		var ratio float64
+		errs = append(errs, parseRatio(spec.RatioMaxAllocationMemory, field+".ratioMaxAllocationMemory", &ratio)...)
+		errs = append(errs, parseRatio(spec.RatioMaxAllocationCPU, field+".ratioMaxAllocationCPU", &ratio)...)
+		errs = append(errs, parseRatio(spec.RatioOverCommitMemory, field+".ratioOverCommitMemory", &ratio)...)
+		errs = append(errs, parseRatio(spec.RatioOverCommitCPU, field+".ratioOverCommitCPU", &ratio)...)
+
+		pool := spec.DeepCopy()
+		pools = append(pools, NodePool{
+			Name:                     pool.Name,
+			NodeSelector:             pool.NodeSelector,
+			NamespaceSelector:        pool.NamespaceSelector,
+			RatioMaxAllocationMemory: pool.RatioMaxAllocationMemory,
+			RatioMaxAllocationCPU:    pool.RatioMaxAllocationCPU,
+			RatioOverCommitMemory:    pool.RatioOverCommitMemory,
+			RatioOverCommitCPU:       pool.RatioOverCommitCPU,
+		})
+	}
+	return pools, errs
+}
+
+// Check that a mandatory label selector is set and valid
+func validateSelector(selector *metav1.LabelSelector, field string, name string) []string {
+	if selector == nil {
+		return []string{fmt.Sprintf(MessageTierMissingField, field, name)}
+	}
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		return []string{fmt.Sprintf("%s.%s: %s", field, name, err)}
+	}
+	return nil
+}
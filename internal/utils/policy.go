@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Load the QuotaPolicy read by the controller
+func (c ConfigurationManager) loadQuotaPolicy() (policy *cagipv1.QuotaPolicy, err error) {
+	return c.policyClientset.CagipV1().QuotaPolicies().Get(context.TODO(), cagipv1.QuotaPolicyName, metav1.GetOptions{})
+}
+
+// Convert a QuotaPolicy spec to the Config struct
+// The fields that are not set use the default configuration
+// Return the validation errors, the config must not be used when there is any
+func ParseQuotaPolicy(spec cagipv1.QuotaPolicySpec) (parsed *Config, errs []string) {
+	parsed = &Config{
+		DefaultClaimSpec:         *claimSpecByDefault,
+		RatioMaxAllocationMemory: defaultMaxAllocationMemory,
+		RatioMaxAllocationCPU:    defaultMaxAllocationCPU,
+		RatioOverCommitMemory:    defaultOverCommitMemory,
+		RatioOverCommitCPU:       defaultOverCommitCPU,
+		KeepAcceptedClaims:       spec.KeepAcceptedClaims,
+		RequeueOnLooserPolicy:    spec.RequeueOnLooserPolicy,
+		NamespaceSelector:        spec.NamespaceSelector.DeepCopy(),
+		ExcludedNamespaces:       append([]string(nil), spec.ExcludedNamespaces...),
+		SystemNamespaces:         append([]string(nil), spec.SystemNamespaces...),
+		PendingRequeueInterval:   defaultPendingRequeueInterval,
+		WaitForCapacity:          spec.WaitForCapacity,
+		ReclaimUnusedQuota:       spec.ReclaimUnusedQuota,
+		RequireApproval:          spec.RequireApproval,
+		ApproverGroup:            defaultApproverGroup,
+		CapacityCombination:      spec.CapacityCombination,
+		MaintenanceAnnotation:    spec.MaintenanceAnnotation,
+	}
+	if spec.ApproverGroup != "" {
+		parsed.ApproverGroup = spec.ApproverGroup
+	}
+	for _, provider := range spec.CapacityProviders {
+		parsed.CapacityProviders = append(parsed.CapacityProviders, *provider.DeepCopy())
+	}
+	if spec.NodeEligibility != nil {
+		parsed.NodeEligibility = *spec.NodeEligibility.DeepCopy()
+	}
+
+	errs = append(errs, validateNamespaceSelection(parsed.NamespaceSelector, parsed.ExcludedNamespaces)...)
+	errs = append(errs, validateNamespacePatterns("systemNamespaces", parsed.SystemNamespaces)...)
+
+	errs = append(errs, parseDefaultClaimSpec(spec.DefaultClaimSpec, "defaultClaimSpec", &parsed.DefaultClaimSpec)...)
+
+	errs = append(errs, parseRatio(spec.RatioMaxAllocationMemory, "ratioMaxAllocationMemory", &parsed.RatioMaxAllocationMemory)...)
+	errs = append(errs, parseRatio(spec.RatioMaxAllocationCPU, "ratioMaxAllocationCPU", &parsed.RatioMaxAllocationCPU)...)
+	errs = append(errs, parseRatio(spec.RatioOverCommitMemory, "ratioOverCommitMemory", &parsed.RatioOverCommitMemory)...)
+	errs = append(errs, parseRatio(spec.RatioOverCommitCPU, "ratioOverCommitCPU", &parsed.RatioOverCommitCPU)...)
+
+	errs = append(errs, parseDuration(spec.PendingRequeueInterval, "pendingRequeueInterval", &parsed.PendingRequeueInterval)...)
+	errs = append(errs, parseDuration(spec.PendingTimeout, "pendingTimeout", &parsed.PendingTimeout)...)
+	errs = append(errs, parseDuration(spec.AdmissionBatchWindow, "admissionBatchWindow", &parsed.AdmissionBatchWindow)...)
+	errs = append(errs, parseDuration(spec.CapacitySmoothingWindow, "capacitySmoothingWindow", &parsed.CapacitySmoothingWindow)...)
+
+	errs = append(errs, parseApprovalPolicy(spec.ApprovalGrowthRatio, spec.ApprovalCPUThreshold, "", parsed)...)
+	errs = append(errs, validateCapacityProviders(parsed.CapacityProviders, parsed.CapacityCombination)...)
+	errs = append(errs, validateNodeEligibility(parsed.NodeEligibility)...)
+	errs = append(errs, validateMaintenanceAnnotation(parsed.MaintenanceAnnotation)...)
+
+	if len(spec.ResourcePolicies) > 0 {
+		var policyErrs []string
+		parsed.ResourcePolicies, policyErrs = parseResourcePolicySpecs(spec.ResourcePolicies, "resourcePolicies", *parsed)
+		errs = append(errs, policyErrs...)
+	}
+
+	var poolErrs []string
+	parsed.NodePools, poolErrs = ParseNodePools(spec.NodePools)
+	errs = append(errs, poolErrs...)
+
+	var tierErrs []string
+	parsed.Tiers, tierErrs = ParseTiers(spec.Tiers, *parsed)
+	errs = append(errs, tierErrs...)
+
+	// Maps are iterated in a random order, the errors are sorted to keep the status stable
+	sort.Strings(errs)
+	return parsed, errs
+}
+
+// Set a ratio when it is defined, it must be strictly positive
+func parseRatio(value *float64, field string, ratio *float64) []string {
+	if value == nil {
+		return nil
+	}
+	if *value <= 0 {
+		return []string{fmt.Sprintf(MessagePolicyInvalidRatio, field, *value)}
+	}
+	*ratio = *value
+	return nil
+}
+
+// Set a duration when it is defined, it must not be negative
+func parseDuration(value *metav1.Duration, field string, duration *time.Duration) []string {
+	if value == nil {
+		return nil
+	}
+	*duration = value.Duration
+	return validateDuration(field, duration, 0)
+}
+
+// Check that a duration read from the configuration is not negative, it is reset to its default otherwise
+// A duration of 0 disables the feature it controls
+func validateDuration(field string, duration *time.Duration, fallback time.Duration) []string {
+	if *duration < 0 {
+		errs := []string{fmt.Sprintf(MessagePolicyNegativeDuration, field, duration.String())}
+		*duration = fallback
+		return errs
+	}
+	return nil
+}
+
+// Set the approval thresholds when they are defined, the fields are prefixed with the tier path if any
+func parseApprovalPolicy(growthRatio *float64, cpuThreshold *resource.Quantity, prefix string, settings *Config) (errs []string) {
+	if growthRatio != nil {
+		settings.ApprovalGrowthRatio = *growthRatio
+		errs = append(errs, validateApprovalGrowthRatio(prefix+"approvalGrowthRatio", &settings.ApprovalGrowthRatio)...)
+	}
+	if cpuThreshold != nil {
+		settings.ApprovalCPUThreshold = cpuThreshold.DeepCopy()
+		errs = append(errs, validateApprovalCPUThreshold(prefix+"approvalCPUThreshold", &settings.ApprovalCPUThreshold)...)
+	}
+	return errs
+}
+
+// Check that the approval growth ratio is not negative, it is reset to 0 otherwise
+// A ratio of 0 does not require any approval on growth
+func validateApprovalGrowthRatio(field string, ratio *float64) []string {
+	if *ratio < 0 {
+		errs := []string{fmt.Sprintf(MessagePolicyNegativeRatio, field, *ratio)}
+		*ratio = 0
+		return errs
+	}
+	return nil
+}
+
+// Check that the approval CPU threshold is not negative, it is reset to 0 otherwise
+// A threshold of 0 does not require any approval on CPU growth
+func validateApprovalCPUThreshold(field string, threshold *resource.Quantity) []string {
+	if threshold.Sign() < 0 {
+		errs := []string{fmt.Sprintf(MessagePolicyNegativeQuantity, field, threshold.String())}
+		*threshold = resource.Quantity{}
+		return errs
+	}
+	return nil
+}
+
+// Set the default claim spec when it is defined, its quantities must not be negative
+func parseDefaultClaimSpec(value v1.ResourceList, field string, spec *v1.ResourceList) (errs []string) {
+	if value == nil {
+		return nil
+	}
+	for name, quantity := range value {
+		if quantity.Sign() < 0 {
+			errs = append(errs, fmt.Sprintf(MessagePolicyNegativeQuantity, field+"."+string(name), quantity.String()))
+		}
+	}
+	*spec = value.DeepCopy()
+	return errs
+}
+
+// Convert the resource policies of a spec, the fields that are not set keep the policy of the base config
+// The policies of the base config are kept for the other resources
+func parseResourcePolicySpecs(specs map[v1.ResourceName]cagipv1.ResourcePolicySpec, field string, base Config) (policies map[v1.ResourceName]ResourcePolicy, errs []string) {
+	policies = make(map[v1.ResourceName]ResourcePolicy, len(base.ResourcePolicies)+len(specs))
+	for name, policy := range base.ResourcePolicies {
+		policies[name] = policy
+	}
+	for name, resourcePolicy := range specs {
+		policyField := field + "." + string(name)
+		policy := base.ResourcePolicy(name)
+		errs = append(errs, parseRatio(resourcePolicy.RatioMaxAllocation, policyField+".ratioMaxAllocation", &policy.RatioMaxAllocation)...)
+		errs = append(errs, parseRatio(resourcePolicy.RatioOverCommit, policyField+".ratioOverCommit", &policy.RatioOverCommit)...)
+		if resourcePolicy.NoOverCommit != nil {
+			policy.NoOverCommit = *resourcePolicy.NoOverCommit
+		}
+		policies[name] = policy
+	}
+	return policies, errs
+}
+
+// Convert the tiers of a policy, the fields a tier does not set keep the value of the base config
+// Return the validation errors, the tiers must not be used when there is any
+func ParseTiers(specs []cagipv1.PolicyTier, base Config) (tiers []Tier, errs []string) {
+	names := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		field := fmt.Sprintf("tiers[%d]", i)
+
+		if spec.Name == "" {
+			errs = append(errs, fmt.Sprintf(MessageTierMissingField, field, "name"))
+		} else if names[spec.Name] {
+			errs = append(errs, fmt.Sprintf(MessageTierDuplicateName, field, spec.Name))
+		}
+		names[spec.Name] = true
+
+		if spec.NamespaceSelector == nil {
+			errs = append(errs, fmt.Sprintf(MessageTierMissingField, field, "namespaceSelector"))
+		} else if _, err := metav1.LabelSelectorAsSelector(spec.NamespaceSelector); err != nil {
+			errs = append(errs, fmt.Sprintf("%s.namespaceSelector: %s", field, err))
+		}
+
+		settings := base
+		settings.Tiers = nil
+		errs = append(errs, parseDefaultClaimSpec(spec.DefaultClaimSpec, field+".defaultClaimSpec", &settings.DefaultClaimSpec)...)
+		errs = append(errs, parseRatio(spec.RatioMaxAllocationMemory, field+".ratioMaxAllocationMemory", &settings.RatioMaxAllocationMemory)...)
+		errs = append(errs, parseRatio(spec.RatioMaxAllocationCPU, field+".ratioMaxAllocationCPU", &settings.RatioMaxAllocationCPU)...)
+		errs = append(errs, parseRatio(spec.RatioOverCommitMemory, field+".ratioOverCommitMemory", &settings.RatioOverCommitMemory)...)
+		errs = append(errs, parseRatio(spec.RatioOverCommitCPU, field+".ratioOverCommitCPU", &settings.RatioOverCommitCPU)...)
+		if spec.Priority != nil {
+			settings.Priority = *spec.Priority
+		}
+		errs = append(errs, parseApprovalPolicy(spec.ApprovalGrowthRatio, spec.ApprovalCPUThreshold, field+".", &settings)...)
+		if spec.RequireApproval != nil {
+			settings.RequireApproval = *spec.RequireApproval
+		}
+
+		// A dedicated CPU or Memory policy of the base config follows the ratios of the tier
+		overrides := map[v1.ResourceName]cagipv1.ResourcePolicySpec{}
+		if _, found := base.ResourcePolicies[v1.ResourceCPU]; found {
+			overrides[v1.ResourceCPU] = cagipv1.ResourcePolicySpec{RatioMaxAllocation: spec.RatioMaxAllocationCPU, RatioOverCommit: spec.RatioOverCommitCPU}
+		}
+		if _, found := base.ResourcePolicies[v1.ResourceMemory]; found {
+			overrides[v1.ResourceMemory] = cagipv1.ResourcePolicySpec{RatioMaxAllocation: spec.RatioMaxAllocationMemory, RatioOverCommit: spec.RatioOverCommitMemory}
+		}
+		settings.ResourcePolicies, _ = parseResourcePolicySpecs(overrides, field+".resourcePolicies", settings)
+
+		var policyErrs []string
+		settings.ResourcePolicies, policyErrs = parseResourcePolicySpecs(spec.ResourcePolicies, field+".resourcePolicies", settings)
+		errs = append(errs, policyErrs...)
+		if len(settings.ResourcePolicies) == 0 {
+			settings.ResourcePolicies = nil
+		}
+
+		tiers = append(tiers, Tier{
+			Name:              spec.Name,
+			NamespaceSelector: spec.NamespaceSelector.DeepCopy(),
+			Settings:          settings,
+		})
+	}
+	return tiers, errs
+}
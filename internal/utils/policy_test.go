@@ -0,0 +1,359 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cagipv1 "github.com/ca-gip/kotary/pkg/apis/cagip/v1"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseQuotaPolicy(t *testing.T) {
+	ratio := func(value float64) *float64 { return &value }
+	noOverCommit := false
+
+	t.Run("empty policy should use the default configuration", func(t *testing.T) {
+		parsed, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{})
+		assert.Equal(t, len(errs), 0)
+		assert.DeepEqual(t, *parsed, *generateDefaultSettings())
+	})
+
+	t.Run("policy should be converted", func(t *testing.T) {
+		parsed, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			DefaultClaimSpec:         v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			RatioMaxAllocationMemory: ratio(0.5),
+			RatioMaxAllocationCPU:    ratio(0.33),
+			RatioOverCommitMemory:    ratio(1.2),
+			RatioOverCommitCPU:       ratio(1.5),
+			KeepAcceptedClaims:       true,
+			WaitForCapacity:          true,
+			ResourcePolicies: map[v1.ResourceName]cagipv1.ResourcePolicySpec{
+				v1.ResourceMemory: {RatioOverCommit: ratio(1)},
+				"nvidia.com/gpu":  {RatioMaxAllocation: ratio(0.25), NoOverCommit: &noOverCommit},
+			},
+		})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, parsed.DefaultClaimSpec.Cpu().String(), "1")
+		assert.Equal(t, parsed.KeepAcceptedClaims, true)
+		assert.Equal(t, parsed.WaitForCapacity, true)
+		assert.Equal(t, parsed.ResourcePolicy(v1.ResourceCPU), ResourcePolicy{RatioMaxAllocation: 0.33, RatioOverCommit: 1.5})
+		assert.Equal(t, parsed.ResourcePolicy(v1.ResourceMemory), ResourcePolicy{RatioMaxAllocation: 0.5, RatioOverCommit: 1})
+		assert.Equal(t, parsed.ResourcePolicy("nvidia.com/gpu"), ResourcePolicy{RatioMaxAllocation: 0.25, RatioOverCommit: 1})
+	})
+
+	t.Run("invalid values should be reported", func(t *testing.T) {
+		_, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			DefaultClaimSpec:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("-1Gi")},
+			RatioOverCommitCPU: ratio(-1),
+			ResourcePolicies: map[v1.ResourceName]cagipv1.ResourcePolicySpec{
+				"nvidia.com/gpu": {RatioMaxAllocation: ratio(0)},
+			},
+		})
+		assert.DeepEqual(t, errs, []string{
+			"defaultClaimSpec.memory must not be negative but is -1Gi",
+			"ratioOverCommitCPU must be greater than 0 but is -1",
+			"resourcePolicies.nvidia.com/gpu.ratioMaxAllocation must be greater than 0 but is 0",
+		})
+	})
+
+	t.Run("tiers should override the global settings", func(t *testing.T) {
+		priority := int32(10)
+		requireApproval := true
+		cpuThreshold := resource.MustParse("8")
+		parsed, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			RatioMaxAllocationCPU: ratio(0.33),
+			ApprovalGrowthRatio:   ratio(0.5),
+			ApprovalCPUThreshold:  &cpuThreshold,
+			RatioOverCommitCPU:    ratio(1.5),
+			ResourcePolicies: map[v1.ResourceName]cagipv1.ResourcePolicySpec{
+				v1.ResourceCPU: {RatioMaxAllocation: ratio(0.25)},
+			},
+			Tiers: []cagipv1.PolicyTier{{
+				Name:                  "production",
+				NamespaceSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+				DefaultClaimSpec:      v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+				RatioMaxAllocationCPU: ratio(0.5),
+				Priority:              &priority,
+				RequireApproval:       &requireApproval,
+				ResourcePolicies: map[v1.ResourceName]cagipv1.ResourcePolicySpec{
+					"nvidia.com/gpu": {RatioMaxAllocation: ratio(0.5)},
+				},
+			}},
+		})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(parsed.Tiers), 1)
+
+		settings := parsed.Tiers[0].Settings
+		assert.Equal(t, settings.DefaultClaimSpec.Cpu().String(), "4")
+		assert.Equal(t, settings.Priority, int32(10))
+		assert.Equal(t, parsed.Priority, int32(0))
+		assert.Equal(t, settings.RequireApproval, true)
+		assert.Equal(t, parsed.RequireApproval, false)
+		assert.Equal(t, settings.ApprovalGrowthRatio, 0.5)
+		assert.Equal(t, settings.ApprovalCPUThreshold.String(), "8")
+		assert.Equal(t, settings.ResourcePolicy(v1.ResourceCPU), ResourcePolicy{RatioMaxAllocation: 0.5, RatioOverCommit: 1.5})
+		assert.Equal(t, settings.ResourcePolicy(v1.ResourceMemory), parsed.ResourcePolicy(v1.ResourceMemory))
+		assert.Equal(t, settings.ResourcePolicy("nvidia.com/gpu"), ResourcePolicy{RatioMaxAllocation: 0.5, RatioOverCommit: 1, NoOverCommit: true})
+		assert.Equal(t, parsed.ResourcePolicy(v1.ResourceCPU).RatioMaxAllocation, 0.25)
+	})
+
+	t.Run("pending durations should be converted", func(t *testing.T) {
+		parsed, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			PendingRequeueInterval: &metav1.Duration{Duration: time.Minute},
+			PendingTimeout:         &metav1.Duration{Duration: 24 * time.Hour},
+			AdmissionBatchWindow:   &metav1.Duration{Duration: 5 * time.Second},
+		})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, parsed.PendingRequeueInterval, time.Minute)
+		assert.Equal(t, parsed.PendingTimeout, 24*time.Hour)
+		assert.Equal(t, parsed.AdmissionBatchWindow, 5*time.Second)
+
+		_, errs = ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			PendingTimeout: &metav1.Duration{Duration: -time.Hour},
+		})
+		assert.DeepEqual(t, errs, []string{"pendingTimeout must not be negative but is -1h0m0s"})
+	})
+
+	t.Run("invalid tiers should be reported", func(t *testing.T) {
+		_, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			Tiers: []cagipv1.PolicyTier{
+				{Name: "production", NamespaceSelector: &metav1.LabelSelector{}, RatioOverCommitMemory: ratio(0)},
+				{Name: "production"},
+			},
+		})
+		assert.DeepEqual(t, errs, []string{
+			"tiers[0].ratioOverCommitMemory must be greater than 0 but is 0",
+			"tiers[1].name production is already used by another tier",
+			"tiers[1].namespaceSelector must be set",
+		})
+	})
+
+	t.Run("negative approval thresholds should be reported", func(t *testing.T) {
+		cpuThreshold := resource.MustParse("-1")
+		parsed, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			ApprovalGrowthRatio:  ratio(-0.5),
+			ApprovalCPUThreshold: &cpuThreshold,
+		})
+		assert.DeepEqual(t, errs, []string{
+			"approvalCPUThreshold must not be negative but is -1",
+			"approvalGrowthRatio must not be negative but is -0.5",
+		})
+		assert.Equal(t, parsed.ApprovalGrowthRatio, float64(0))
+		assert.Equal(t, parsed.ApprovalCPUThreshold.IsZero(), true)
+	})
+
+	t.Run("invalid capacity providers should be reported", func(t *testing.T) {
+		_, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			CapacityCombination: "min",
+			CapacityProviders: []cagipv1.CapacityProviderSpec{
+				{Type: cagipv1.CapacityProviderNodes},
+				{Type: cagipv1.CapacityProviderStatic, Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("-1")}},
+				{Type: cagipv1.CapacityProviderClusterAutoscaler, StatusConfigMap: "cluster-autoscaler-status"},
+				{Type: "cloud"},
+			},
+		})
+		assert.DeepEqual(t, errs, []string{
+			"capacityCombination min must be max or sum",
+			"capacityProviders[1].resources.cpu must not be negative but is -1",
+			"capacityProviders[2].nodeGroupLabel must be set",
+			"capacityProviders[2].statusConfigMap cluster-autoscaler-status must be namespace/name",
+			"capacityProviders[3].type cloud must be one of nodes, static, clusterAutoscaler or karpenter",
+		})
+	})
+	t.Run("invalid capacity smoothing should be reported", func(t *testing.T) {
+		_, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			CapacitySmoothingWindow: &metav1.Duration{Duration: -time.Minute},
+			MaintenanceAnnotation:   "maintenance window",
+		})
+		assert.Equal(t, len(errs), 2)
+		assert.Equal(t, errs[0], "capacitySmoothingWindow must not be negative but is -1m0s")
+		assert.Assert(t, strings.HasPrefix(errs[1], "maintenanceAnnotation: maintenance window "))
+	})
+
+	t.Run("invalid node pools should be reported", func(t *testing.T) {
+		highmem := &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}}
+		_, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			NodePools: []cagipv1.NodePoolSpec{
+				{Name: "highmem", NodeSelector: highmem, NamespaceSelector: highmem, RatioOverCommitMemory: ratio(0)},
+				{Name: "highmem", NodeSelector: highmem},
+			},
+		})
+		assert.DeepEqual(t, errs, []string{
+			"nodePools[0].ratioOverCommitMemory must be greater than 0 but is 0",
+			"nodePools[1].name highmem is already used by another node pool",
+			"nodePools[1].namespaceSelector must be set",
+		})
+	})
+
+	t.Run("invalid node eligibility should be reported", func(t *testing.T) {
+		_, errs := ParseQuotaPolicy(cagipv1.QuotaPolicySpec{
+			NodeEligibility: &cagipv1.NodeEligibilitySpec{
+				NodeSelector:       &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "pool", Operator: "Within"}}},
+				ExcludedTaints:     []v1.Taint{{Effect: v1.TaintEffectNoSchedule}},
+				Tolerations:        []v1.Toleration{{Key: "dedicated", Operator: "Matches"}},
+				ExcludedRoleLabels: []string{"node-role.kubernetes.io/infra"},
+			},
+		})
+		assert.DeepEqual(t, errs, []string{
+			"nodeEligibility.excludedTaints[0].key must be set",
+			"nodeEligibility.nodeSelector: \"Within\" is not a valid label selector operator",
+			"nodeEligibility.tolerations[0].operator Matches must be Exists or Equal",
+		})
+	})
+}
+
+func TestParseConfigMap(t *testing.T) {
+
+	t.Run("invalid keys should use the default value and be reported", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"ratioOverCommitCPU":    "1,5",
+			"ratioOverCommitMemory": "1.2",
+			"defaultClaimSpec":      "cpu: one",
+		}})
+		assert.ErrorContains(t, err, "ratioOverCommitCPU")
+		assert.ErrorContains(t, err, "defaultClaimSpec")
+		assert.Equal(t, parsed.RatioOverCommitCPU, float64(defaultOverCommitCPU))
+		assert.Equal(t, parsed.RatioOverCommitMemory, 1.2)
+		assert.Equal(t, parsed.ApproverGroup, "kotary-approvers")
+		assert.DeepEqual(t, parsed.DefaultClaimSpec, *claimSpecByDefault)
+	})
+
+	t.Run("valid keys should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"ratioMaxAllocationCPU": "0.33",
+			"keepAcceptedClaims":    "true",
+			"waitForCapacity":       "true",
+			"reclaimUnusedQuota":    "true",
+			"admissionBatchWindow":  "5s",
+			"approvalGrowthRatio":   "0.5",
+			"approvalCPUThreshold":  "4",
+			"requireApproval":       "true",
+			"approverGroup":         "platform-admins",
+			"defaultClaimSpec":      "cpu: 1\nmemory: 2Gi\n",
+		}})
+		assert.NilError(t, err)
+		assert.Equal(t, parsed.RatioMaxAllocationCPU, 0.33)
+		assert.Equal(t, parsed.KeepAcceptedClaims, true)
+		assert.Equal(t, parsed.WaitForCapacity, true)
+		assert.Equal(t, parsed.ReclaimUnusedQuota, true)
+		assert.Equal(t, parsed.AdmissionBatchWindow, 5*time.Second)
+		assert.Equal(t, parsed.ApprovalGrowthRatio, 0.5)
+		assert.Equal(t, parsed.ApprovalCPUThreshold.String(), "4")
+		assert.Equal(t, parsed.RequireApproval, true)
+		assert.Equal(t, parsed.ApproverGroup, "platform-admins")
+		assert.Equal(t, parsed.DefaultClaimSpec.Memory().String(), "2Gi")
+	})
+
+	t.Run("pending durations should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"pendingTimeout": "2h",
+		}})
+		assert.NilError(t, err)
+		assert.Equal(t, parsed.PendingRequeueInterval, defaultPendingRequeueInterval)
+		assert.Equal(t, parsed.PendingTimeout, 2*time.Hour)
+
+		parsed, err = parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"pendingRequeueInterval": "-1m",
+			"pendingTimeout":         "one day",
+		}})
+		assert.ErrorContains(t, err, "pendingRequeueInterval must not be negative")
+		assert.ErrorContains(t, err, "pendingTimeout")
+		assert.Equal(t, parsed.PendingRequeueInterval, defaultPendingRequeueInterval)
+		assert.Equal(t, parsed.PendingTimeout, time.Duration(0))
+	})
+
+	t.Run("tiers should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"ratioMaxAllocationCPU": "0.33",
+			"tiers":                 "- name: production\n  namespaceSelector:\n    matchLabels:\n      environment: production\n  ratioOverCommitCPU: 2\n",
+		}})
+		assert.NilError(t, err)
+		assert.Equal(t, len(parsed.Tiers), 1)
+		assert.Equal(t, parsed.Tiers[0].Name, "production")
+		assert.Equal(t, parsed.Tiers[0].Settings.RatioMaxAllocationCPU, 0.33)
+		assert.Equal(t, parsed.Tiers[0].Settings.RatioOverCommitCPU, float64(2))
+	})
+
+	t.Run("invalid system namespaces should be dropped and reported", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"systemNamespaces": "- kube-[\n- monitoring\n",
+		}})
+		assert.ErrorContains(t, err, "systemNamespaces")
+		assert.Equal(t, len(parsed.SystemNamespaces), 0)
+	})
+
+	t.Run("invalid tiers should be dropped and reported", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"tiers": "- name: production\n",
+		}})
+		assert.ErrorContains(t, err, "tiers[0].namespaceSelector must be set")
+		assert.Equal(t, len(parsed.Tiers), 0)
+	})
+
+	t.Run("capacity providers should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"capacityProviders":   "- type: nodes\n- type: static\n  resources:\n    cpu: 200\n",
+			"capacityCombination": "sum",
+		}})
+		assert.NilError(t, err)
+		assert.Equal(t, len(parsed.CapacityProviders), 2)
+		assert.Equal(t, parsed.CapacityProviders[1].Resources.Cpu().String(), "200")
+		assert.Equal(t, parsed.CapacityCombination, cagipv1.CapacityCombinationSum)
+
+		parsed, err = parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"capacityProviders": "- type: static\n",
+		}})
+		assert.ErrorContains(t, err, "capacityProviders[0].resources must be set")
+		assert.DeepEqual(t, parsed.EffectiveCapacityProviders(), capacityProvidersByDefault)
+	})
+	t.Run("capacity smoothing should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"capacitySmoothingWindow": "30m",
+			"maintenanceAnnotation":   "cagip.github.com/maintenance",
+		}})
+		assert.NilError(t, err)
+		assert.Equal(t, parsed.CapacitySmoothingWindow, 30*time.Minute)
+		assert.Equal(t, parsed.MaintenanceAnnotation, "cagip.github.com/maintenance")
+
+		parsed, err = parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"maintenanceAnnotation": "-maintenance",
+		}})
+		assert.ErrorContains(t, err, "maintenanceAnnotation: -maintenance")
+		assert.Equal(t, parsed.MaintenanceAnnotation, "")
+	})
+
+	t.Run("node pools should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"nodePools": "- name: highmem\n  nodeSelector:\n    matchLabels:\n      pool: highmem\n  namespaceSelector:\n    matchLabels:\n      pool: highmem\n  ratioOverCommitMemory: 1\n",
+		}})
+		assert.NilError(t, err)
+		assert.Equal(t, len(parsed.NodePools), 1)
+		assert.Equal(t, parsed.NodePools[0].Name, "highmem")
+		assert.Equal(t, *parsed.NodePools[0].RatioOverCommitMemory, float64(1))
+
+		parsed, err = parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"nodePools": "- name: highmem\n",
+		}})
+		assert.ErrorContains(t, err, "nodePools[0].nodeSelector must be set")
+		assert.Equal(t, len(parsed.NodePools), 0)
+	})
+
+	t.Run("node eligibility should be parsed", func(t *testing.T) {
+		parsed, err := parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"nodeEligibility": "excludedTaints:\n- key: nvidia.com/gpu\nexcludedRoleLabels:\n- node-role.kubernetes.io/infra\n",
+		}})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, parsed.NodeEligibility.ExcludedTaints, []v1.Taint{{Key: "nvidia.com/gpu"}})
+		assert.DeepEqual(t, parsed.NodeEligibility.ExcludedRoleLabels, []string{"node-role.kubernetes.io/infra"})
+
+		parsed, err = parseConfigMap(&v1.ConfigMap{Data: map[string]string{
+			"nodeEligibility": "excludedTaints:\n- effect: NoSchedule\n",
+		}})
+		assert.ErrorContains(t, err, "nodeEligibility.excludedTaints[0].key must be set")
+		assert.DeepEqual(t, parsed.NodeEligibility, cagipv1.NodeEligibilitySpec{})
+	})
+}
@@ -34,3 +34,70 @@ var RatioOverCommitMemoryGauge = promauto.NewGauge(prometheus.GaugeOpts{
 	Name: "kotary_ratio_over_commit_memory",
 	Help: "Memory over-commit ratio applied to node available resources (percentage)",
 })
+
+var TierClaimCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "kotary_tier_claims",
+	Help: "Number of claims evaluated per policy tier",
+}, []string{"tier", "status"})
+
+var TierRatioGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kotary_tier_ratio",
+	Help: "Allocation and over-commit ratios applied per policy tier",
+}, []string{"tier", "ratio"})
+
+var LeaderGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kotary_leader",
+	Help: "Whether the replica holds the leader election lease and runs the workers",
+}, []string{"identity"})
+
+var ReservedCapacityGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kotary_reserved_capacity",
+	Help: "Capacity reserved at the last claim evaluation, by ResourceQuotas or by the pods of namespaces without ResourceQuota",
+}, []string{"source", "resource"})
+
+var UnquotedRequestsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kotary_unquoted_namespace_requests",
+	Help: "Requests of the running pods of the namespaces without ResourceQuota counted as reserved capacity",
+}, []string{"namespace", "resource"})
+
+var QuotaDriftCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "kotary_quota_drifts",
+	Help: "Number of managed quotas edited or deleted outside of the controller and restored",
+}, []string{"namespace", "drift"})
+
+var CapacityNodesGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kotary_capacity_nodes",
+	Help: "Nodes evaluated at the last claim evaluation, eligible or the reason why they do not provide capacity",
+}, []string{"node", "eligibility"})
+
+var SmoothedCapacityGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kotary_nodes_capacity",
+	Help: "Capacity of the nodes at the last claim evaluation, raw or smoothed with the nodes in maintenance and the smoothing window",
+}, []string{"pool", "resource", "value"})
+
+var CapacitySmoothedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "kotary_capacity_smoothed",
+	Help: "Number of evaluations whose smoothed capacity of the nodes differs from the raw one",
+}, []string{"pool"})
+
+// Label of the namespaces that are not in any tier
+const globalTierLabel = "global"
+
+// Return the label of a tier in the metrics
+func TierLabel(tier string) string {
+	if tier == "" {
+		return globalTierLabel
+	}
+	return tier
+}
+
+// Label of the capacity of the whole cluster, shared by the namespaces bound to no node pool
+const clusterPoolLabel = "cluster"
+
+// Return the label of a node pool in the metrics
+func NodePoolLabel(pool string) string {
+	if pool == "" {
+		return clusterPoolLabel
+	}
+	return pool
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/component-helpers/resource"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Name of the object count quota of the pods, it tracks every pod independent of its phase
+const podObjectCountName v1.ResourceName = "count/pods"
+
+// Options used by the ResourceQuota admission to compute the pod resources, the resources resized
+// in place and the pod level resources are enabled by default since Kubernetes 1.34
+var podResourcesOptions = resourcehelper.PodResourcesOptions{
+	UseStatusResources:    true,
+	SkipPodLevelResources: false,
+}
+
+// Return the name of the node allocatable resource backing a quota resource name
+// requests.cpu -> cpu, requests.nvidia.com/gpu -> nvidia.com/gpu
+func CapacityResourceName(name v1.ResourceName) v1.ResourceName {
+	return v1.ResourceName(strings.TrimPrefix(string(name), v1.DefaultResourceRequestsPrefix))
+}
+
+// Check if a quota only binds the pods of some scopes (priority class, best effort...), the other pods of its namespace
+// are not limited by it
+func IsScopedQuota(resourceQuota *v1.ResourceQuota) bool {
+	return len(resourceQuota.Spec.Scopes) > 0 ||
+		(resourceQuota.Spec.ScopeSelector != nil && len(resourceQuota.Spec.ScopeSelector.MatchExpressions) > 0)
+}
+
+// Return the binding limits of the quotas of a namespace keyed by capacity resource name
+// Equivalent names (cpu and requests.cpu) bind the same requests, limits.* are kept apart,
+// and a resource bound by several quotas is limited by the lowest of them
+// The scoped quotas must be left out, they do not bind every pod of the namespace
+func EffectiveQuotaHard(hards ...v1.ResourceList) v1.ResourceList {
+	effective := v1.ResourceList{}
+	for _, hard := range hards {
+		for name, quantity := range hard {
+			name = CapacityResourceName(name)
+			if current, found := effective[name]; !found || quantity.Cmp(current) < 0 {
+				effective[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return effective
+}
+
+// Return the resource names of a list in evaluation order
+// Memory and CPU are always evaluated first, the others are sorted by name
+func SortedResourceNames(list v1.ResourceList) (names []v1.ResourceName) {
+	for name := range list {
+		names = append(names, name)
+	}
+	rank := func(name v1.ResourceName) int {
+		switch name {
+		case v1.ResourceMemory:
+			return 0
+		case v1.ResourceCPU:
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if rank(names[i]) != rank(names[j]) {
+			return rank(names[i]) < rank(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return
+}
+
+// Multiply a quantity by a ratio, CPU keeps its milli precision
+func ScaleQuantity(name v1.ResourceName, quantity resource.Quantity, ratio float64) resource.Quantity {
+	if name == v1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(math.Round(float64(quantity.MilliValue())*ratio)), resource.DecimalSI)
+	}
+	format := quantity.Format
+	if format == "" {
+		format = resource.DecimalSI
+	}
+	return *resource.NewQuantity(int64(math.Round(float64(quantity.Value())*ratio)), format)
+}
+
+// Extended resources (nvidia.com/gpu) and hugepages are backed by physical devices or
+// pre-allocated memory and cannot be over-committed by default
+func isDeviceResource(name v1.ResourceName) bool {
+	if strings.HasPrefix(string(name), v1.ResourceHugePagesPrefix) {
+		return true
+	}
+	return strings.Contains(string(name), "/") && !strings.Contains(string(name), v1.ResourceDefaultNamespacePrefix)
+}
+
+// Resource names a ResourceQuota can limit without a prefix or a domain
+var standardQuotaResourceNames = map[v1.ResourceName]bool{
+	v1.ResourceCPU:                      true,
+	v1.ResourceMemory:                   true,
+	v1.ResourceEphemeralStorage:         true,
+	v1.ResourceRequestsCPU:              true,
+	v1.ResourceRequestsMemory:           true,
+	v1.ResourceRequestsEphemeralStorage: true,
+	v1.ResourceRequestsStorage:          true,
+	v1.ResourceLimitsCPU:                true,
+	v1.ResourceLimitsMemory:             true,
+	v1.ResourceLimitsEphemeralStorage:   true,
+	v1.ResourcePods:                     true,
+	v1.ResourceServices:                 true,
+	v1.ResourceServicesNodePorts:        true,
+	v1.ResourceServicesLoadBalancers:    true,
+	v1.ResourceReplicationControllers:   true,
+	v1.ResourceQuotas:                   true,
+	v1.ResourceSecrets:                  true,
+	v1.ResourceConfigMaps:               true,
+	v1.ResourcePersistentVolumeClaims:   true,
+}
+
+// Suffix of the quota resources limiting a storage class, gold.storageclass.storage.k8s.io/requests.storage
+const storageClassQuotaSuffix = ".storageclass.storage.k8s.io/"
+
+// Check if a resource name can be limited by a ResourceQuota
+// Extended resources are only limited on their requests, requests.nvidia.com/gpu
+func IsQuotaResourceName(name v1.ResourceName) bool {
+	if standardQuotaResourceNames[name] {
+		return true
+	}
+	value := string(name)
+	switch {
+	case strings.HasPrefix(value, "count/"),
+		strings.HasPrefix(value, v1.ResourceHugePagesPrefix),
+		strings.HasPrefix(value, v1.DefaultResourceRequestsPrefix+v1.ResourceHugePagesPrefix),
+		strings.Contains(value, storageClassQuotaSuffix):
+		return true
+	case strings.HasPrefix(value, v1.DefaultResourceRequestsPrefix):
+		return isDeviceResource(CapacityResourceName(name))
+	}
+	return false
+}
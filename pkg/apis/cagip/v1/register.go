@@ -33,6 +33,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&ResourceQuotaClaim{},
 		&ResourceQuotaClaimList{},
+		&ScheduledQuotaClaim{},
+		&ScheduledQuotaClaimList{},
+		&QuotaPolicy{},
+		&QuotaPolicyList{},
+		&QuotaCapacity{},
+		&QuotaCapacityList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
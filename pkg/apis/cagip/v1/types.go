@@ -2,6 +2,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -18,15 +19,125 @@ type ResourceQuotaClaim struct {
 }
 
 const (
-	PhaseAccepted = "ACCEPTED"
-	PhaseRejected = "REJECTED"
-	PhasePending  = "PENDING"
+	PhaseAccepted   = "ACCEPTED"
+	PhaseRejected   = "REJECTED"
+	PhasePending    = "PENDING"
+	PhaseSuperseded = "SUPERSEDED"
+	PhaseExpired    = "EXPIRED"
+	PhaseWaiting    = "WAITING"
+
+	PhaseAwaitingApproval = "AWAITING_APPROVAL"
+)
+
+// Annotations turning a claim into a temporary burst claim
+// The quota in place before the claim is restored once it expires
+const (
+	// Lifetime of the claim from its acceptance, as a Go duration (ex: 48h)
+	AnnotationDuration = "cagip.github.com/duration"
+	// Absolute expiry of the claim, as a RFC3339 timestamp
+	AnnotationExpiresAt = "cagip.github.com/expires-at"
+)
+
+// Priority of the claims competing for the cluster capacity, as an integer
+// Set as an annotation on a claim or as a label on its namespace, the higher is admitted first
+const AnnotationPriority = "cagip.github.com/priority"
+
+// Annotations recording the decision of an approver on a claim awaiting approval
+// The approval admission policy only lets approvers set them, the approver must be the user setting them
+const (
+	// Decision of the approver, approved or denied
+	AnnotationApproval = "cagip.github.com/approval"
+	// Name of the user who took the decision, recorded by the claim webhook
+	AnnotationApprover = "cagip.github.com/approver"
+	// Signature of the decision set by the claim webhook, the controller only trusts the signed decisions
+	AnnotationApprovalSignature = "cagip.github.com/approval-signature"
+)
+
+// Annotations recording on a managed quota the spec accepted by the controller
+// The managed quotas drifting from it are restored, the replicas taking over the leadership read it from the quotas
+const (
+	// Accepted spec of the quota, as a JSON resource list
+	AnnotationAcceptedSpec = "cagip.github.com/accepted-spec"
+	// Signature of the accepted spec, the controller only trusts the specs it signed
+	AnnotationAcceptedSpecSignature = "cagip.github.com/accepted-spec-signature"
+)
+
+// Decisions of an approver
+const (
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+)
+
+// Condition types of a ResourceQuotaClaim
+const (
+	// The claim has been evaluated against the cluster capacity and the policies
+	ConditionEvaluated = "Evaluated"
+	// The claim passed all the verifications
+	ConditionAccepted = "Accepted"
+	// The claim spec is applied on the managed-quota
+	ConditionApplied = "Applied"
+)
+
+// Machine-readable reasons of the claim status
+const (
+	ReasonAccepted                = "Accepted"
+	ReasonSuperseded              = "Superseded"
+	ReasonAllocationLimitExceeded = "AllocationLimitExceeded"
+	ReasonInsufficientCapacity    = "InsufficientCapacity"
+	ReasonAwaitingLowerUsage      = "AwaitingLowerUsage"
+	ReasonInvalidExpiry           = "InvalidExpiry"
+	ReasonExpired                 = "Expired"
+	ReasonInvalidSchedule         = "InvalidSchedule"
+	ReasonClaimEmitted            = "ClaimEmitted"
+	ReasonPendingTimeout          = "PendingTimeout"
+	ReasonAwaitingCapacity        = "AwaitingCapacity"
+	ReasonInvalidPriority         = "InvalidPriority"
+	ReasonAwaitingApproval        = "AwaitingApproval"
+	ReasonApprovalDenied          = "ApprovalDenied"
+	ReasonInvalidApproval         = "InvalidApproval"
+	ReasonAwaitingAdmission       = "AwaitingAdmission"
 )
 
 // ResourceQuotaClaimStatus defines the observed state of ResourceQuotaClaim
 type ResourceQuotaClaimStatus struct {
 	Phase   string `json:"phase,omitempty"`
 	Details string `json:"details,omitempty"`
+	// Machine-readable code explaining the phase
+	Reason string `json:"reason,omitempty"`
+	// Generation of the claim spec the status refers to
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Last time the claim has been evaluated by the controller
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+	// Standard conditions : Evaluated, Accepted and Applied
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Time at which a burst claim expires
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// Time at which the claim started waiting for a lower usage of the namespace
+	PendingSince *metav1.Time `json:"pendingSince,omitempty"`
+	// Time at which the claim joined the queue of the claims waiting for capacity
+	WaitingSince *metav1.Time `json:"waitingSince,omitempty"`
+	// Position of the claim in the queue of the claims waiting for capacity, starting at 1
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+	// Priority the claim competes with for the cluster capacity
+	Priority int32 `json:"priority,omitempty"`
+	// Quota restored on the namespace once the burst claim expires
+	RevertTo corev1.ResourceList `json:"revertTo,omitempty"`
+	// Policy tier of the namespace the claim has been evaluated with, empty for the global settings
+	Tier string `json:"tier,omitempty"`
+	// Decision of the approver of a claim that requires an approval
+	Approval *ClaimApproval `json:"approval,omitempty"`
+}
+
+// ClaimApproval records who approved or denied a claim and when
+type ClaimApproval struct {
+	// approved or denied
+	Decision string `json:"decision"`
+	// Name of the user who took the decision
+	Approver string `json:"approver"`
+	// Time at which the controller observed the decision
+	Time metav1.Time `json:"time"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -37,3 +148,391 @@ type ResourceQuotaClaimList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []ResourceQuotaClaim `json:"items"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ScheduledQuotaClaim emits ResourceQuotaClaims on a cron schedule
+type ScheduledQuotaClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScheduledQuotaClaimSpec   `json:"spec"`
+	Status ScheduledQuotaClaimStatus `json:"status,omitempty"`
+}
+
+// Label set on the claims emitted by a ScheduledQuotaClaim
+const LabelScheduledQuotaClaim = "cagip.github.com/scheduled-quota-claim"
+
+// ScheduledQuotaClaimSpec defines when and which claims are emitted
+type ScheduledQuotaClaimSpec struct {
+	// IANA time zone of the cron expressions, UTC when empty
+	TimeZone string `json:"timeZone,omitempty"`
+	// Claims emitted at each occurrence of their cron expression
+	Schedules []QuotaSchedule `json:"schedules"`
+}
+
+// QuotaSchedule defines a claim emitted at each occurrence of a cron expression
+type QuotaSchedule struct {
+	Name string `json:"name"`
+	// Standard cron expression : minute hour day-of-month month day-of-week
+	Cron string              `json:"cron"`
+	Spec corev1.ResourceList `json:"spec"`
+}
+
+// ScheduledQuotaClaimStatus defines the observed state of ScheduledQuotaClaim
+type ScheduledQuotaClaimStatus struct {
+	// Error in the schedules definition
+	Details string `json:"details,omitempty"`
+	// Generation of the spec the status refers to
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Last time a claim has been emitted
+	LastExecutionTime *metav1.Time `json:"lastExecutionTime,omitempty"`
+	// Next time a claim will be emitted, and the schedule it comes from
+	NextExecutionTime *metav1.Time `json:"nextExecutionTime,omitempty"`
+	NextSchedule      string       `json:"nextSchedule,omitempty"`
+	// Most recent runs first
+	Runs []ScheduledRun `json:"runs,omitempty"`
+}
+
+// ScheduledRun records a claim emitted by a ScheduledQuotaClaim and its outcome
+type ScheduledRun struct {
+	Schedule      string      `json:"schedule"`
+	ExecutionTime metav1.Time `json:"executionTime"`
+	// Name of the emitted ResourceQuotaClaim
+	Claim string `json:"claim"`
+	// Phase, reason and details of the emitted claim once evaluated
+	Phase   string `json:"phase,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ScheduledQuotaClaimList contains a list of ScheduledQuotaClaim
+type ScheduledQuotaClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScheduledQuotaClaim `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// QuotaPolicy holds the configuration of the controller
+// It takes precedence over the kotary-config ConfigMap
+type QuotaPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   QuotaPolicySpec   `json:"spec"`
+	Status QuotaPolicyStatus `json:"status,omitempty"`
+}
+
+// Name of the QuotaPolicy read by the controller
+const QuotaPolicyName = "default"
+
+// Condition types of a QuotaPolicy
+const (
+	// The spec passed the validation and is applied by the controller
+	ConditionValid = "Valid"
+)
+
+// Machine-readable reasons of the policy status
+const (
+	ReasonValidPolicy   = "ValidPolicy"
+	ReasonInvalidPolicy = "InvalidPolicy"
+	ReasonIgnoredPolicy = "IgnoredPolicy"
+)
+
+// QuotaPolicySpec defines the configuration of the controller
+// The fields that are not set use the default configuration
+type QuotaPolicySpec struct {
+	// The spec of the default ResourceQuotaClaim to apply on Namespaces
+	DefaultClaimSpec corev1.ResourceList `json:"defaultClaimSpec,omitempty"`
+
+	// Maximum resource size that can be claimed compared to the total cluster size
+	RatioMaxAllocationMemory *float64 `json:"ratioMaxAllocationMemory,omitempty"`
+	RatioMaxAllocationCPU    *float64 `json:"ratioMaxAllocationCPU,omitempty"`
+
+	// Over provisioning applied on the available resources of all the nodes
+	RatioOverCommitMemory *float64 `json:"ratioOverCommitMemory,omitempty"`
+	RatioOverCommitCPU    *float64 `json:"ratioOverCommitCPU,omitempty"`
+
+	// Keep accepted claims instead of deleting them (GitOps mode)
+	KeepAcceptedClaims bool `json:"keepAcceptedClaims,omitempty"`
+
+	// Policy applied to each resource that can be claimed
+	ResourcePolicies map[corev1.ResourceName]ResourcePolicySpec `json:"resourcePolicies,omitempty"`
+
+	// Evaluate the rejected and pending claims again when the policy gets looser
+	RequeueOnLooserPolicy bool `json:"requeueOnLooserPolicy,omitempty"`
+
+	// Select the Namespaces that receive the default ResourceQuotaClaim, quota=managed when it is not set
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Name patterns of the Namespaces that never receive the default ResourceQuotaClaim
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+
+	// Name patterns of the Namespaces without quota whose pod requests are reserved on the cluster capacity
+	// Every Namespace without quota is counted when it is not set
+	SystemNamespaces []string `json:"systemNamespaces,omitempty"`
+
+	// Interval at which the pending claims are evaluated again, 5m when it is not set
+	PendingRequeueInterval *metav1.Duration `json:"pendingRequeueInterval,omitempty"`
+
+	// Time after which a pending claim is rejected, the claims stay pending when it is not set
+	PendingTimeout *metav1.Duration `json:"pendingTimeout,omitempty"`
+
+	// Hold the claims that do not fit in the cluster capacity in a queue instead of rejecting them
+	WaitForCapacity bool `json:"waitForCapacity,omitempty"`
+
+	// Window during which the claims are collected to be admitted in priority order, they are admitted one by one when it is not set
+	AdmissionBatchWindow *metav1.Duration `json:"admissionBatchWindow,omitempty"`
+
+	// Lower the quotas of the namespaces of lower priority down to their usage when a claim does not fit
+	ReclaimUnusedQuota bool `json:"reclaimUnusedQuota,omitempty"`
+
+	// Claims growing a resource of the managed quota by more than this ratio await an approval, 0.5 -> +50%
+	ApprovalGrowthRatio *float64 `json:"approvalGrowthRatio,omitempty"`
+
+	// Claims growing the CPU of the managed quota by more than this quantity await an approval
+	ApprovalCPUThreshold *resource.Quantity `json:"approvalCPUThreshold,omitempty"`
+
+	// Every claim growing the managed quota awaits an approval
+	RequireApproval bool `json:"requireApproval,omitempty"`
+
+	// Group whose members can approve or deny any claim, kotary-approvers when it is not set
+	ApproverGroup string `json:"approverGroup,omitempty"`
+
+	// Sources of the capacity the claims are evaluated against, the ready worker nodes when it is not set
+	CapacityProviders []CapacityProviderSpec `json:"capacityProviders,omitempty"`
+
+	// How the capacities of the providers are combined, max (default) or sum
+	CapacityCombination string `json:"capacityCombination,omitempty"`
+
+	// Nodes providing capacity, the ready and schedulable workers when it is not set
+	NodeEligibility *NodeEligibilitySpec `json:"nodeEligibility,omitempty"`
+
+	// Pools of nodes the Namespaces they select are evaluated against, instead of the whole cluster
+	// The first pool selecting a Namespace applies
+	NodePools []NodePoolSpec `json:"nodePools,omitempty"`
+
+	// Window over which the largest capacity of the nodes seen is used, the current capacity is used when it is not set
+	CapacitySmoothingWindow *metav1.Duration `json:"capacitySmoothingWindow,omitempty"`
+
+	// Annotation of the cordoned nodes that keep providing capacity during a maintenance
+	MaintenanceAnnotation string `json:"maintenanceAnnotation,omitempty"`
+
+	// Tiers overriding the settings above for the Namespaces they select
+	// The first tier selecting a Namespace applies
+	Tiers []PolicyTier `json:"tiers,omitempty"`
+}
+
+// Types of capacity providers
+const (
+	// Allocatable of the ready worker nodes
+	CapacityProviderNodes = "nodes"
+	// Fixed budget set in the configuration
+	CapacityProviderStatic = "static"
+	// Maximum size of the cluster-autoscaler node groups
+	CapacityProviderClusterAutoscaler = "clusterAutoscaler"
+	// Limits of the Karpenter NodePools
+	CapacityProviderKarpenter = "karpenter"
+)
+
+// Ways to combine the capacities of the providers
+const (
+	// Maximum of each resource across the providers
+	CapacityCombinationMax = "max"
+	// Sum of each resource across the providers
+	CapacityCombinationSum = "sum"
+)
+
+// NodeEligibilitySpec defines the nodes whose allocatable counts toward the capacity
+// The unschedulable, not ready and control plane nodes are always excluded
+type NodeEligibilitySpec struct {
+	// Only the nodes matching the selector count, every node when it is not set
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// Nodes carrying one of these taints are excluded, an empty value or effect matches any
+	ExcludedTaints []corev1.Taint `json:"excludedTaints,omitempty"`
+
+	// Exclude the nodes with a NoSchedule or NoExecute taint that is not tolerated by the tolerations
+	ExcludeUntoleratedTaints bool `json:"excludeUntoleratedTaints,omitempty"`
+
+	// Taints the workloads of the claims tolerate
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Labels of the node roles that do not run the workloads of the claims, on top of the control plane one
+	ExcludedRoleLabels []string `json:"excludedRoleLabels,omitempty"`
+}
+
+// NodePoolSpec defines a pool of nodes and the Namespaces whose workloads are pinned to it
+// The ratios that are not set keep the ones of the Namespace
+type NodePoolSpec struct {
+	Name string `json:"name"`
+	// Select the nodes of the pool
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector"`
+	// Select the Namespaces bound to the pool
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector"`
+
+	RatioMaxAllocationMemory *float64 `json:"ratioMaxAllocationMemory,omitempty"`
+	RatioMaxAllocationCPU    *float64 `json:"ratioMaxAllocationCPU,omitempty"`
+	RatioOverCommitMemory    *float64 `json:"ratioOverCommitMemory,omitempty"`
+	RatioOverCommitCPU       *float64 `json:"ratioOverCommitCPU,omitempty"`
+}
+
+// CapacityProviderSpec defines a source of the capacity the claims are evaluated against
+type CapacityProviderSpec struct {
+	// nodes, static, clusterAutoscaler or karpenter
+	Type string `json:"type"`
+
+	// Budget of the static provider
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+
+	// namespace/name of the status ConfigMap of cluster-autoscaler, kube-system/cluster-autoscaler-status when it is not set
+	StatusConfigMap string `json:"statusConfigMap,omitempty"`
+
+	// Label holding the cluster-autoscaler node group of a node
+	NodeGroupLabel string `json:"nodeGroupLabel,omitempty"`
+}
+
+// PolicyTier defines the default claim and ratios of a class of Namespaces
+// The fields that are not set keep the global settings
+type PolicyTier struct {
+	Name string `json:"name"`
+	// Select the Namespaces of the tier
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector"`
+
+	DefaultClaimSpec corev1.ResourceList `json:"defaultClaimSpec,omitempty"`
+
+	RatioMaxAllocationMemory *float64 `json:"ratioMaxAllocationMemory,omitempty"`
+	RatioMaxAllocationCPU    *float64 `json:"ratioMaxAllocationCPU,omitempty"`
+	RatioOverCommitMemory    *float64 `json:"ratioOverCommitMemory,omitempty"`
+	RatioOverCommitCPU       *float64 `json:"ratioOverCommitCPU,omitempty"`
+
+	ResourcePolicies map[corev1.ResourceName]ResourcePolicySpec `json:"resourcePolicies,omitempty"`
+
+	// Priority of the claims of the namespaces of the tier, unless they set their own
+	Priority *int32 `json:"priority,omitempty"`
+
+	ApprovalGrowthRatio  *float64           `json:"approvalGrowthRatio,omitempty"`
+	ApprovalCPUThreshold *resource.Quantity `json:"approvalCPUThreshold,omitempty"`
+	RequireApproval      *bool              `json:"requireApproval,omitempty"`
+}
+
+// ResourcePolicySpec defines the ratios applied to a single resource
+// The fields that are not set keep the ratios of the resource
+type ResourcePolicySpec struct {
+	RatioMaxAllocation *float64 `json:"ratioMaxAllocation,omitempty"`
+	RatioOverCommit    *float64 `json:"ratioOverCommit,omitempty"`
+	NoOverCommit       *bool    `json:"noOverCommit,omitempty"`
+}
+
+// QuotaPolicyStatus defines the observed state of QuotaPolicy
+type QuotaPolicyStatus struct {
+	// Generation of the spec the status refers to
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Errors found while validating the spec
+	Errors []string `json:"errors,omitempty"`
+	// Standard conditions : Valid
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// QuotaPolicyList contains a list of QuotaPolicy
+type QuotaPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []QuotaPolicy `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// QuotaCapacity reports the capacity of the cluster the claims are evaluated against
+// It is maintained by the controller, only its status is set
+type QuotaCapacity struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status QuotaCapacityStatus `json:"status,omitempty"`
+}
+
+// Name of the QuotaCapacity maintained by the controller
+const QuotaCapacityName = "cluster"
+
+// Condition types of a QuotaCapacity
+const (
+	// The capacity reserved exceeds the allocatable of the nodes, the quotas rely on the over-commit
+	ConditionOverCommitted = "OverCommitted"
+	// A capacity provider could not be called, its last known capacity or the one of the nodes is used
+	ConditionCapacityDegraded = "CapacityDegraded"
+)
+
+// Machine-readable reasons of the capacity status
+const (
+	ReasonReservedOverAllocatable   = "ReservedOverAllocatable"
+	ReasonReservedWithinAllocatable = "ReservedWithinAllocatable"
+	ReasonProviderUnavailable       = "ProviderUnavailable"
+	ReasonProvidersAvailable        = "ProvidersAvailable"
+)
+
+// QuotaCapacityStatus defines the observed capacity of the cluster
+type QuotaCapacityStatus struct {
+	// Capacity of the whole cluster
+	CapacitySummary `json:",inline"`
+	// Capacity of each node pool, the Namespaces bound to a pool are evaluated against it
+	NodePools []NodePoolCapacity `json:"nodePools,omitempty"`
+	// Last time the capacity changed
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Standard conditions : OverCommitted
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// CapacitySummary details the capacity of the cluster or of a node pool
+type CapacitySummary struct {
+	// Allocatable of the capacity providers
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+	// Allocatable once the over-commit ratios are applied
+	OverCommitted corev1.ResourceList `json:"overCommitted,omitempty"`
+	// Reserved by the ResourceQuotas
+	ReservedByQuotas corev1.ResourceList `json:"reservedByQuotas,omitempty"`
+	// Reserved by the running pods of the Namespaces without ResourceQuota
+	ReservedByUnquoted corev1.ResourceList `json:"reservedByUnquoted,omitempty"`
+	// Over-committed capacity left once the reservations are removed
+	Free corev1.ResourceList `json:"free,omitempty"`
+	// Maximum a Namespace without quota and outside of the tiers can claim, the free capacity bounded by the allocation limit
+	MaxClaimable corev1.ResourceList `json:"maxClaimable,omitempty"`
+	// Maximum a Namespace without quota of each tier can claim, with the over-commit and the allocation limit of the tier
+	Tiers []TierCapacity `json:"tiers,omitempty"`
+}
+
+// TierCapacity details the capacity the Namespaces of a tier can claim
+type TierCapacity struct {
+	Name         string              `json:"name"`
+	MaxClaimable corev1.ResourceList `json:"maxClaimable,omitempty"`
+}
+
+// NodePoolCapacity details the capacity of a node pool
+type NodePoolCapacity struct {
+	Name            string `json:"name"`
+	CapacitySummary `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// QuotaCapacityList contains a list of QuotaCapacity
+type QuotaCapacityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []QuotaCapacity `json:"items"`
+}
@@ -7,15 +7,655 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityProviderSpec) DeepCopyInto(out *CapacityProviderSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityProviderSpec.
+func (in *CapacityProviderSpec) DeepCopy() *CapacityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacitySummary) DeepCopyInto(out *CapacitySummary) {
+	*out = *in
+	if in.Allocatable != nil {
+		in, out := &in.Allocatable, &out.Allocatable
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.OverCommitted != nil {
+		in, out := &in.OverCommitted, &out.OverCommitted
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ReservedByQuotas != nil {
+		in, out := &in.ReservedByQuotas, &out.ReservedByQuotas
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ReservedByUnquoted != nil {
+		in, out := &in.ReservedByUnquoted, &out.ReservedByUnquoted
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Free != nil {
+		in, out := &in.Free, &out.Free
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxClaimable != nil {
+		in, out := &in.MaxClaimable, &out.MaxClaimable
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]TierCapacity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacitySummary.
+func (in *CapacitySummary) DeepCopy() *CapacitySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacitySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimApproval) DeepCopyInto(out *ClaimApproval) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimApproval.
+func (in *ClaimApproval) DeepCopy() *ClaimApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeEligibilitySpec) DeepCopyInto(out *NodeEligibilitySpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludedTaints != nil {
+		in, out := &in.ExcludedTaints, &out.ExcludedTaints
+		*out = make([]corev1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExcludedRoleLabels != nil {
+		in, out := &in.ExcludedRoleLabels, &out.ExcludedRoleLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeEligibilitySpec.
+func (in *NodeEligibilitySpec) DeepCopy() *NodeEligibilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeEligibilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolCapacity) DeepCopyInto(out *NodePoolCapacity) {
+	*out = *in
+	in.CapacitySummary.DeepCopyInto(&out.CapacitySummary)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolCapacity.
+func (in *NodePoolCapacity) DeepCopy() *NodePoolCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RatioMaxAllocationMemory != nil {
+		in, out := &in.RatioMaxAllocationMemory, &out.RatioMaxAllocationMemory
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioMaxAllocationCPU != nil {
+		in, out := &in.RatioMaxAllocationCPU, &out.RatioMaxAllocationCPU
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommitMemory != nil {
+		in, out := &in.RatioOverCommitMemory, &out.RatioOverCommitMemory
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommitCPU != nil {
+		in, out := &in.RatioOverCommitCPU, &out.RatioOverCommitCPU
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolSpec.
+func (in *NodePoolSpec) DeepCopy() *NodePoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTier) DeepCopyInto(out *PolicyTier) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultClaimSpec != nil {
+		in, out := &in.DefaultClaimSpec, &out.DefaultClaimSpec
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.RatioMaxAllocationMemory != nil {
+		in, out := &in.RatioMaxAllocationMemory, &out.RatioMaxAllocationMemory
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioMaxAllocationCPU != nil {
+		in, out := &in.RatioMaxAllocationCPU, &out.RatioMaxAllocationCPU
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommitMemory != nil {
+		in, out := &in.RatioOverCommitMemory, &out.RatioOverCommitMemory
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommitCPU != nil {
+		in, out := &in.RatioOverCommitCPU, &out.RatioOverCommitCPU
+		*out = new(float64)
+		**out = **in
+	}
+	if in.ResourcePolicies != nil {
+		in, out := &in.ResourcePolicies, &out.ResourcePolicies
+		*out = make(map[corev1.ResourceName]ResourcePolicySpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ApprovalGrowthRatio != nil {
+		in, out := &in.ApprovalGrowthRatio, &out.ApprovalGrowthRatio
+		*out = new(float64)
+		**out = **in
+	}
+	if in.ApprovalCPUThreshold != nil {
+		in, out := &in.ApprovalCPUThreshold, &out.ApprovalCPUThreshold
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.RequireApproval != nil {
+		in, out := &in.RequireApproval, &out.RequireApproval
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTier.
+func (in *PolicyTier) DeepCopy() *PolicyTier {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaCapacity) DeepCopyInto(out *QuotaCapacity) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaCapacity.
+func (in *QuotaCapacity) DeepCopy() *QuotaCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaCapacity) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaCapacityList) DeepCopyInto(out *QuotaCapacityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]QuotaCapacity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaCapacityList.
+func (in *QuotaCapacityList) DeepCopy() *QuotaCapacityList {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaCapacityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaCapacityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaCapacityStatus) DeepCopyInto(out *QuotaCapacityStatus) {
+	*out = *in
+	in.CapacitySummary.DeepCopyInto(&out.CapacitySummary)
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make([]NodePoolCapacity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaCapacityStatus.
+func (in *QuotaCapacityStatus) DeepCopy() *QuotaCapacityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaCapacityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaPolicy) DeepCopyInto(out *QuotaPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaPolicy.
+func (in *QuotaPolicy) DeepCopy() *QuotaPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaPolicyList) DeepCopyInto(out *QuotaPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]QuotaPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaPolicyList.
+func (in *QuotaPolicyList) DeepCopy() *QuotaPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaPolicySpec) DeepCopyInto(out *QuotaPolicySpec) {
+	*out = *in
+	if in.DefaultClaimSpec != nil {
+		in, out := &in.DefaultClaimSpec, &out.DefaultClaimSpec
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.RatioMaxAllocationMemory != nil {
+		in, out := &in.RatioMaxAllocationMemory, &out.RatioMaxAllocationMemory
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioMaxAllocationCPU != nil {
+		in, out := &in.RatioMaxAllocationCPU, &out.RatioMaxAllocationCPU
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommitMemory != nil {
+		in, out := &in.RatioOverCommitMemory, &out.RatioOverCommitMemory
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommitCPU != nil {
+		in, out := &in.RatioOverCommitCPU, &out.RatioOverCommitCPU
+		*out = new(float64)
+		**out = **in
+	}
+	if in.ResourcePolicies != nil {
+		in, out := &in.ResourcePolicies, &out.ResourcePolicies
+		*out = make(map[corev1.ResourceName]ResourcePolicySpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludedNamespaces != nil {
+		in, out := &in.ExcludedNamespaces, &out.ExcludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SystemNamespaces != nil {
+		in, out := &in.SystemNamespaces, &out.SystemNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingRequeueInterval != nil {
+		in, out := &in.PendingRequeueInterval, &out.PendingRequeueInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.PendingTimeout != nil {
+		in, out := &in.PendingTimeout, &out.PendingTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.AdmissionBatchWindow != nil {
+		in, out := &in.AdmissionBatchWindow, &out.AdmissionBatchWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ApprovalGrowthRatio != nil {
+		in, out := &in.ApprovalGrowthRatio, &out.ApprovalGrowthRatio
+		*out = new(float64)
+		**out = **in
+	}
+	if in.ApprovalCPUThreshold != nil {
+		in, out := &in.ApprovalCPUThreshold, &out.ApprovalCPUThreshold
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CapacityProviders != nil {
+		in, out := &in.CapacityProviders, &out.CapacityProviders
+		*out = make([]CapacityProviderSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeEligibility != nil {
+		in, out := &in.NodeEligibility, &out.NodeEligibility
+		*out = new(NodeEligibilitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make([]NodePoolSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CapacitySmoothingWindow != nil {
+		in, out := &in.CapacitySmoothingWindow, &out.CapacitySmoothingWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]PolicyTier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaPolicySpec.
+func (in *QuotaPolicySpec) DeepCopy() *QuotaPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaPolicyStatus) DeepCopyInto(out *QuotaPolicyStatus) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaPolicyStatus.
+func (in *QuotaPolicyStatus) DeepCopy() *QuotaPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSchedule) DeepCopyInto(out *QuotaSchedule) {
+	*out = *in
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSchedule.
+func (in *QuotaSchedule) DeepCopy() *QuotaSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourcePolicySpec) DeepCopyInto(out *ResourcePolicySpec) {
+	*out = *in
+	if in.RatioMaxAllocation != nil {
+		in, out := &in.RatioMaxAllocation, &out.RatioMaxAllocation
+		*out = new(float64)
+		**out = **in
+	}
+	if in.RatioOverCommit != nil {
+		in, out := &in.RatioOverCommit, &out.RatioOverCommit
+		*out = new(float64)
+		**out = **in
+	}
+	if in.NoOverCommit != nil {
+		in, out := &in.NoOverCommit, &out.NoOverCommit
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePolicySpec.
+func (in *ResourcePolicySpec) DeepCopy() *ResourcePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourcePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceQuotaClaim) DeepCopyInto(out *ResourceQuotaClaim) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	if in.Spec != nil {
 		in, out := &in.Spec, &out.Spec
 		*out = make(corev1.ResourceList, len(*in))
@@ -80,6 +720,41 @@ func (in *ResourceQuotaClaimList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceQuotaClaimStatus) DeepCopyInto(out *ResourceQuotaClaimStatus) {
 	*out = *in
+	if in.LastEvaluationTime != nil {
+		in, out := &in.LastEvaluationTime, &out.LastEvaluationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.PendingSince != nil {
+		in, out := &in.PendingSince, &out.PendingSince
+		*out = (*in).DeepCopy()
+	}
+	if in.WaitingSince != nil {
+		in, out := &in.WaitingSince, &out.WaitingSince
+		*out = (*in).DeepCopy()
+	}
+	if in.RevertTo != nil {
+		in, out := &in.RevertTo, &out.RevertTo
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(ClaimApproval)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -92,3 +767,158 @@ func (in *ResourceQuotaClaimStatus) DeepCopy() *ResourceQuotaClaimStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledQuotaClaim) DeepCopyInto(out *ScheduledQuotaClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledQuotaClaim.
+func (in *ScheduledQuotaClaim) DeepCopy() *ScheduledQuotaClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledQuotaClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledQuotaClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledQuotaClaimList) DeepCopyInto(out *ScheduledQuotaClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScheduledQuotaClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledQuotaClaimList.
+func (in *ScheduledQuotaClaimList) DeepCopy() *ScheduledQuotaClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledQuotaClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledQuotaClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledQuotaClaimSpec) DeepCopyInto(out *ScheduledQuotaClaimSpec) {
+	*out = *in
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]QuotaSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledQuotaClaimSpec.
+func (in *ScheduledQuotaClaimSpec) DeepCopy() *ScheduledQuotaClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledQuotaClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledQuotaClaimStatus) DeepCopyInto(out *ScheduledQuotaClaimStatus) {
+	*out = *in
+	if in.LastExecutionTime != nil {
+		in, out := &in.LastExecutionTime, &out.LastExecutionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextExecutionTime != nil {
+		in, out := &in.NextExecutionTime, &out.NextExecutionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Runs != nil {
+		in, out := &in.Runs, &out.Runs
+		*out = make([]ScheduledRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledQuotaClaimStatus.
+func (in *ScheduledQuotaClaimStatus) DeepCopy() *ScheduledQuotaClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledQuotaClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledRun) DeepCopyInto(out *ScheduledRun) {
+	*out = *in
+	in.ExecutionTime.DeepCopyInto(&out.ExecutionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledRun.
+func (in *ScheduledRun) DeepCopy() *ScheduledRun {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TierCapacity) DeepCopyInto(out *TierCapacity) {
+	*out = *in
+	if in.MaxClaimable != nil {
+		in, out := &in.MaxClaimable, &out.MaxClaimable
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TierCapacity.
+func (in *TierCapacity) DeepCopy() *TierCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(TierCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -12,7 +12,10 @@ import (
 
 type CagipV1Interface interface {
 	RESTClient() rest.Interface
+	QuotaCapacitiesGetter
+	QuotaPoliciesGetter
 	ResourceQuotaClaimsGetter
+	ScheduledQuotaClaimsGetter
 }
 
 // CagipV1Client is used to interact with features provided by the cagip.github.com group.
@@ -20,10 +23,22 @@ type CagipV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *CagipV1Client) QuotaCapacities() QuotaCapacityInterface {
+	return newQuotaCapacities(c)
+}
+
+func (c *CagipV1Client) QuotaPolicies() QuotaPolicyInterface {
+	return newQuotaPolicies(c)
+}
+
 func (c *CagipV1Client) ResourceQuotaClaims(namespace string) ResourceQuotaClaimInterface {
 	return newResourceQuotaClaims(c, namespace)
 }
 
+func (c *CagipV1Client) ScheduledQuotaClaims(namespace string) ScheduledQuotaClaimInterface {
+	return newScheduledQuotaClaims(c, namespace)
+}
+
 // NewForConfig creates a new CagipV1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -12,10 +12,22 @@ type FakeCagipV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeCagipV1) QuotaCapacities() v1.QuotaCapacityInterface {
+	return &FakeQuotaCapacities{c}
+}
+
+func (c *FakeCagipV1) QuotaPolicies() v1.QuotaPolicyInterface {
+	return &FakeQuotaPolicies{c}
+}
+
 func (c *FakeCagipV1) ResourceQuotaClaims(namespace string) v1.ResourceQuotaClaimInterface {
 	return &FakeResourceQuotaClaims{c, namespace}
 }
 
+func (c *FakeCagipV1) ScheduledQuotaClaims(namespace string) v1.ScheduledQuotaClaimInterface {
+	return &FakeScheduledQuotaClaims{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCagipV1) RESTClient() rest.Interface {
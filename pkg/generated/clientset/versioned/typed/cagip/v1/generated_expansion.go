@@ -2,4 +2,10 @@
 
 package v1
 
+type QuotaCapacityExpansion interface{}
+
+type QuotaPolicyExpansion interface{}
+
 type ResourceQuotaClaimExpansion interface{}
+
+type ScheduledQuotaClaimExpansion interface{}
@@ -8,8 +8,14 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// QuotaCapacities returns a QuotaCapacityInformer.
+	QuotaCapacities() QuotaCapacityInformer
+	// QuotaPolicies returns a QuotaPolicyInformer.
+	QuotaPolicies() QuotaPolicyInformer
 	// ResourceQuotaClaims returns a ResourceQuotaClaimInformer.
 	ResourceQuotaClaims() ResourceQuotaClaimInformer
+	// ScheduledQuotaClaims returns a ScheduledQuotaClaimInformer.
+	ScheduledQuotaClaims() ScheduledQuotaClaimInformer
 }
 
 type version struct {
@@ -23,7 +29,22 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// QuotaCapacities returns a QuotaCapacityInformer.
+func (v *version) QuotaCapacities() QuotaCapacityInformer {
+	return &quotaCapacityInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// QuotaPolicies returns a QuotaPolicyInformer.
+func (v *version) QuotaPolicies() QuotaPolicyInformer {
+	return &quotaPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ResourceQuotaClaims returns a ResourceQuotaClaimInformer.
 func (v *version) ResourceQuotaClaims() ResourceQuotaClaimInformer {
 	return &resourceQuotaClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ScheduledQuotaClaims returns a ScheduledQuotaClaimInformer.
+func (v *version) ScheduledQuotaClaims() ScheduledQuotaClaimInformer {
+	return &scheduledQuotaClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
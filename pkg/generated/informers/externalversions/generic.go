@@ -37,8 +37,14 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=cagip.github.com, Version=v1
+	case v1.SchemeGroupVersion.WithResource("quotacapacities"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Cagip().V1().QuotaCapacities().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("quotapolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Cagip().V1().QuotaPolicies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("resourcequotaclaims"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Cagip().V1().ResourceQuotaClaims().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("scheduledquotaclaims"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Cagip().V1().ScheduledQuotaClaims().Informer()}, nil
 
 	}
 
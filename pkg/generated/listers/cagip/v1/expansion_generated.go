@@ -2,6 +2,14 @@
 
 package v1
 
+// QuotaCapacityListerExpansion allows custom methods to be added to
+// QuotaCapacityLister.
+type QuotaCapacityListerExpansion interface{}
+
+// QuotaPolicyListerExpansion allows custom methods to be added to
+// QuotaPolicyLister.
+type QuotaPolicyListerExpansion interface{}
+
 // ResourceQuotaClaimListerExpansion allows custom methods to be added to
 // ResourceQuotaClaimLister.
 type ResourceQuotaClaimListerExpansion interface{}
@@ -9,3 +17,11 @@ type ResourceQuotaClaimListerExpansion interface{}
 // ResourceQuotaClaimNamespaceListerExpansion allows custom methods to be added to
 // ResourceQuotaClaimNamespaceLister.
 type ResourceQuotaClaimNamespaceListerExpansion interface{}
+
+// ScheduledQuotaClaimListerExpansion allows custom methods to be added to
+// ScheduledQuotaClaimLister.
+type ScheduledQuotaClaimListerExpansion interface{}
+
+// ScheduledQuotaClaimNamespaceListerExpansion allows custom methods to be added to
+// ScheduledQuotaClaimNamespaceLister.
+type ScheduledQuotaClaimNamespaceListerExpansion interface{}